@@ -3,15 +3,10 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"net/url"
 
-	authz "github.com/canonical/identity-platform-login-ui/internal/authorization"
-	logging "github.com/canonical/identity-platform-login-ui/internal/logging"
-	monitoring "github.com/canonical/identity-platform-login-ui/internal/monitoring"
-	fga "github.com/canonical/identity-platform-login-ui/internal/openfga"
-	tracing "github.com/canonical/identity-platform-login-ui/internal/tracing"
+	"github.com/canonical/identity-platform-login-ui/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -20,11 +15,16 @@ var createFgaModelCmd = &cobra.Command{
 	Use:   "create-fga-model",
 	Short: "Creates an openfga model",
 	Long:  `Creates an openfga model`,
-	Run: func(cmd *cobra.Command, args []string) {
+	// kept for existing deployment scripts
+	Deprecated: "use fga model write instead",
+	RunE: func(cmd *cobra.Command, args []string) error {
 		apiUrl, _ := cmd.Flags().GetString("fga-api-url")
 		apiToken, _ := cmd.Flags().GetString("fga-api-token")
 		storeId, _ := cmd.Flags().GetString("store-id")
-		createModel(apiUrl, apiToken, storeId)
+
+		cmd.SilenceUsage = true
+
+		return createModel(cmd, apiUrl, apiToken, storeId)
 	},
 }
 
@@ -34,26 +34,23 @@ func init() {
 	createFgaModelCmd.PersistentFlags().String("fga-api-url", "", "The openfga API URL")
 	createFgaModelCmd.PersistentFlags().String("fga-api-token", "", "The openfga API token")
 	createFgaModelCmd.PersistentFlags().String("store-id", "", "The openfga store to create the model in")
-	createFgaModelCmd.MarkPersistentFlagRequired("api-url")
-	createFgaModelCmd.MarkPersistentFlagRequired("api-token")
+	createFgaModelCmd.MarkPersistentFlagRequired("fga-api-url")
+	createFgaModelCmd.MarkPersistentFlagRequired("fga-api-token")
 	createFgaModelCmd.MarkPersistentFlagRequired("store-id")
 }
 
-func createModel(apiUrl, apiToken, storeId string) {
-	logger := logging.NewNoopLogger()
-	tracer := tracing.NewNoopTracer()
-	monitor := monitoring.NewNoopMonitor("", logger)
+func createModel(cmd *cobra.Command, apiUrl, apiToken, storeId string) error {
 	scheme, host, err := parseURL(apiUrl)
 	if err != nil {
-		panic(err)
+		return &exitError{code: exitUsage, err: fmt.Errorf("invalid fga-api-url: %w", err)}
 	}
-	cfg := fga.NewConfig(scheme, host, storeId, apiToken, "", false, tracer, monitor, logger)
-	fgaClient := fga.NewClient(cfg)
-	modelId, err := fgaClient.WriteModel(context.Background(), []byte(authz.AuthModel))
+
+	client, err := newFGAClientFromSpecs(&config.OpenFGASpec{ApiScheme: scheme, ApiHost: host, ApiToken: apiToken, StoreId: storeId})
 	if err != nil {
-		panic(err)
+		return err
 	}
-	fmt.Printf("Created model: %s\n", modelId)
+
+	return writeModel(cmd.Context(), client, cmd.OutOrStdout())
 }
 
 func parseURL(s string) (string, string, error) {
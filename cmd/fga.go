@@ -0,0 +1,328 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	openfga "github.com/openfga/go-sdk"
+	"github.com/spf13/cobra"
+
+	authz "github.com/canonical/identity-platform-login-ui/internal/authorization"
+	"github.com/canonical/identity-platform-login-ui/internal/config"
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	fga "github.com/canonical/identity-platform-login-ui/internal/openfga"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+var fgaCmd = &cobra.Command{
+	Use:   "fga",
+	Short: "Manage the OpenFGA store of the login UI",
+	Long: `Manage the OpenFGA model and tuples used by the login UI.
+
+The OpenFGA connection is read from the same environment variables as serve:
+OPENFGA_API_SCHEME, OPENFGA_API_HOST, OPENFGA_API_TOKEN, OPENFGA_STORE_ID and
+OPENFGA_AUTHORIZATION_MODEL_ID.
+
+Exit codes: 0 on success, 1 when OpenFGA or a file cannot be accessed, 2 on an
+invalid configuration or input, 3 when a check is denied and 4 when the model
+differs from the built-in one.`,
+	// usage is only useful on invalid arguments, checked before running
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	},
+}
+
+var fgaModelCmd = &cobra.Command{
+	Use:   "model",
+	Short: "Manage the OpenFGA authorization model",
+}
+
+var fgaModelWriteCmd = &cobra.Command{
+	Use:   "write",
+	Short: "Write the built-in authorization model to the store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		return writeModel(cmd.Context(), client, cmd.OutOrStdout())
+	},
+}
+
+var fgaModelDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the store authorization model with the built-in one",
+	Long: `Compare the store authorization model with the built-in one.
+
+The model of OPENFGA_AUTHORIZATION_MODEL_ID is compared, the latest one when
+unset. Lines starting with "-" are missing from the store, "~" are defined
+differently and "+" are defined on top of the built-in model. The command
+exits with 4 when the store model is not compatible, or with --strict when it
+is not identical.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, _ := cmd.Flags().GetBool("strict")
+
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		return diffModel(cmd.Context(), client, strict, cmd.OutOrStdout())
+	},
+}
+
+var fgaTuplesCmd = &cobra.Command{
+	Use:   "tuples",
+	Short: "Import and export the provider and app group assignments",
+	Long: `Import and export the provider and app group assignments.
+
+Only provider#allowed_access, provider#denied_access and app_group#member
+tuples are managed. The YAML format groups the assignments by provider and app
+group:
+
+  providers:
+    github:
+      apps: [console]
+      app_groups: [internal]
+      tenants: [acme]
+  denied_providers:
+    password:
+      apps: [grafana]
+  app_groups:
+    internal:
+      apps: [grafana]
+
+The CSV format has one tuple per row with a user,relation,object header:
+
+  user,relation,object
+  app:console,allowed_access,provider:github
+  app_group:internal#member,allowed_access,provider:github`,
+}
+
+var fgaTuplesImportCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Write the assignments of a YAML or CSV file to the store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		tuples, err := readTuplesFile(args[0], format)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return fga.EncodeTuples(cmd.OutOrStdout(), tuples, fga.FormatCSV)
+		}
+
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		if err := client.WriteTuples(cmd.Context(), tuples); err != nil {
+			return &exitError{code: exitFailure, err: fmt.Errorf("failed to write tuples: %w", err)}
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %d tuples\n", len(tuples))
+		return nil
+	},
+}
+
+var fgaTuplesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the assignments of the store as YAML or CSV",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != fga.FormatYAML && format != fga.FormatCSV {
+			return &exitError{code: exitUsage, err: fmt.Errorf("unsupported format %q, expected %s or %s", format, fga.FormatYAML, fga.FormatCSV)}
+		}
+
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		tuples, err := client.ReadTuples(cmd.Context(), "", "", "")
+		if err != nil {
+			return &exitError{code: exitFailure, err: fmt.Errorf("failed to read tuples: %w", err)}
+		}
+
+		return fga.EncodeTuples(cmd.OutOrStdout(), tuples, format)
+	},
+}
+
+var fgaCheckCmd = &cobra.Command{
+	Use:     "check USER RELATION OBJECT",
+	Short:   "Check whether a user has a relation with an object",
+	Example: "  fga check app:console allowed_access provider:github",
+	Args:    cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		allowed, err := client.Check(cmd.Context(), args[0], args[1], args[2])
+		if err != nil {
+			return &exitError{code: exitFailure, err: fmt.Errorf("failed to check: %w", err)}
+		}
+
+		if !allowed {
+			fmt.Fprintln(cmd.OutOrStdout(), "denied")
+			return &exitError{code: exitDenied, err: fmt.Errorf("%s is not %s of %s", args[0], args[1], args[2])}
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), "allowed")
+		return nil
+	},
+}
+
+var fgaListObjectsCmd = &cobra.Command{
+	Use:     "list-objects USER RELATION TYPE",
+	Short:   "List the objects of a type a user has a relation with",
+	Example: "  fga list-objects app:console allowed_access provider",
+	Args:    cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newFGAClient()
+		if err != nil {
+			return err
+		}
+
+		objects, err := client.ListObjects(cmd.Context(), args[0], args[1], args[2])
+		if err != nil {
+			return &exitError{code: exitFailure, err: fmt.Errorf("failed to list objects: %w", err)}
+		}
+
+		for _, object := range objects {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s:%s\n", args[2], object)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fgaCmd)
+
+	fgaCmd.AddCommand(fgaModelCmd, fgaTuplesCmd, fgaCheckCmd, fgaListObjectsCmd)
+	fgaModelCmd.AddCommand(fgaModelWriteCmd, fgaModelDiffCmd)
+	fgaTuplesCmd.AddCommand(fgaTuplesImportCmd, fgaTuplesExportCmd)
+
+	fgaModelDiffCmd.Flags().Bool("strict", false, "Fail when the store model is not identical to the built-in one")
+	fgaTuplesImportCmd.Flags().String("format", "", "Format of the file, yaml or csv, guessed from the extension by default")
+	fgaTuplesImportCmd.Flags().Bool("dry-run", false, "Print the tuples as CSV instead of writing them")
+	fgaTuplesExportCmd.Flags().String("format", fga.FormatYAML, "Output format, yaml or csv")
+}
+
+// newFGAClient builds an OpenFGA client from the environment
+func newFGAClient() (client *fga.Client, err error) {
+	specs := new(config.OpenFGASpec)
+	if err := envconfig.Process("", specs); err != nil {
+		return nil, &exitError{code: exitUsage, err: fmt.Errorf("issues with environment sourcing: %w", err)}
+	}
+
+	if specs.ApiHost == "" || specs.StoreId == "" {
+		return nil, &exitError{code: exitUsage, err: fmt.Errorf("OPENFGA_API_HOST and OPENFGA_STORE_ID are required")}
+	}
+
+	return newFGAClientFromSpecs(specs)
+}
+
+func newFGAClientFromSpecs(specs *config.OpenFGASpec) (client *fga.Client, err error) {
+	// the client panics on an invalid configuration
+	defer func() {
+		if r := recover(); r != nil {
+			client = nil
+			err = &exitError{code: exitUsage, err: fmt.Errorf("%v", r)}
+		}
+	}()
+
+	logger := logging.NewNoopLogger()
+	tracer := tracing.NewNoopTracer()
+	monitor := monitoring.NewNoopMonitor("", logger)
+
+	cfg := fga.NewConfig(specs.ApiScheme, specs.ApiHost, specs.StoreId, specs.ApiToken, specs.AuthorizationModelId, false, tracer, monitor, logger)
+
+	return fga.NewClient(cfg), nil
+}
+
+func writeModel(ctx context.Context, client *fga.Client, out io.Writer) error {
+	modelID, err := client.WriteModel(ctx, []byte(authz.AuthModel))
+	if err != nil {
+		return &exitError{code: exitFailure, err: fmt.Errorf("failed to write model: %w", err)}
+	}
+
+	fmt.Fprintf(out, "Created model: %s\n", modelID)
+	return nil
+}
+
+func diffModel(ctx context.Context, client *fga.Client, strict bool, out io.Writer) error {
+	var builtin openfga.AuthorizationModel
+	if err := json.Unmarshal([]byte(authz.AuthModel), &builtin); err != nil {
+		return &exitError{code: exitFailure, err: fmt.Errorf("invalid built-in model: %w", err)}
+	}
+
+	diff, err := client.CompareModel(ctx, builtin)
+	if err != nil {
+		return &exitError{code: exitFailure, err: fmt.Errorf("failed to read model: %w", err)}
+	}
+
+	if diff.Equal() {
+		fmt.Fprintln(out, "The store model matches the built-in one")
+		return nil
+	}
+
+	fmt.Fprintln(out, diff)
+
+	if !diff.Compatible() {
+		return &exitError{code: exitModelMismatch, err: fmt.Errorf("the store model is not compatible with the built-in one")}
+	}
+
+	if strict {
+		return &exitError{code: exitModelMismatch, err: fmt.Errorf("the store model differs from the built-in one")}
+	}
+
+	return nil
+}
+
+func readTuplesFile(path, format string) ([]openfga.TupleKey, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			format = fga.FormatYAML
+		case ".csv":
+			format = fga.FormatCSV
+		default:
+			return nil, &exitError{code: exitUsage, err: fmt.Errorf("cannot guess the format of %s, use --format", path)}
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &exitError{code: exitFailure, err: err}
+	}
+	defer f.Close()
+
+	tuples, err := fga.DecodeTuples(f, format)
+	if err != nil {
+		return nil, &exitError{code: exitUsage, err: err}
+	}
+
+	return tuples, nil
+}
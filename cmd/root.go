@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"errors"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -16,13 +17,45 @@ var rootCmd = &cobra.Command{
 	Long:  `A login UI for the Ory stack`,
 }
 
+// exit codes of the commands, errors without a code exit with exitFailure
+const (
+	exitFailure = 1
+	// exitUsage signals an invalid configuration or input
+	exitUsage = 2
+	// exitDenied signals a negative authorization check
+	exitDenied = 3
+	// exitModelMismatch signals an OpenFGA model differing from the built-in one
+	exitModelMismatch = 4
+)
+
+// exitError attaches an exit code to a command error
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+	if err == nil {
+		return
 	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.code)
+	}
+
+	os.Exit(exitFailure)
 }
 
 func init() {
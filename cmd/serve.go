@@ -26,14 +26,18 @@ import (
 	ih "github.com/canonical/identity-platform-login-ui/internal/hydra"
 	ik "github.com/canonical/identity-platform-login-ui/internal/kratos"
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring/otlp"
 	"github.com/canonical/identity-platform-login-ui/internal/monitoring/prometheus"
 	fga "github.com/canonical/identity-platform-login-ui/internal/openfga"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 	"github.com/canonical/identity-platform-login-ui/pkg/web"
 
 	tenant "github.com/canonical/identity-platform-api/v0/tenant"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 //go:embed ui/dist
@@ -77,6 +81,52 @@ func serve() error {
 
 	logger.Debugf("env vars: %v", specs)
 
+	otelHeaders, err := tracing.ParseHeaders(specs.OtelHeaders)
+	if err != nil {
+		return fmt.Errorf("issues with OTEL_HEADERS: %w", err)
+	}
+
+	exporterConfig := tracing.ExporterConfig{
+		TLSEnabled: specs.OtelTLSEnabled,
+		CAFile:     specs.OtelTLSCAFile,
+		Headers:    otelHeaders,
+		Stdout:     specs.TracingStdoutEnabled,
+	}
+	resourceConfig := tracing.ResourceConfig{
+		Environment: specs.OtelDeploymentEnvironment,
+		InstanceID:  specs.OtelServiceInstanceID,
+	}
+	tracer := tracing.NewTracer(tracing.NewConfig(specs.TracingEnabled, specs.OtelGRPCEndpoint, specs.OtelHTTPEndpoint, exporterConfig, specs.OtelSampleRatio, specs.OtelPropagators, resourceConfig, logger))
+
+	var monitor monitoring.MonitorInterface
+	var otlpMonitor *otlp.Monitor
+
+	buckets, err := monitoring.NewBuckets(specs.MetricsLatencyBuckets, specs.MetricsSizeBuckets)
+	if err != nil {
+		return fmt.Errorf("issues with METRICS_LATENCY_BUCKETS or METRICS_SIZE_BUCKETS: %w", err)
+	}
+
+	monitors := make([]monitoring.MonitorInterface, 0, len(specs.MetricsExporters))
+	for _, exporter := range specs.MetricsExporters {
+		switch exporter {
+		case "prometheus":
+			monitors = append(monitors, prometheus.NewMonitor("identity-login-ui", buckets, logger))
+		case "otlp":
+			cfg := otlp.NewConfig(specs.OtelGRPCEndpoint, specs.OtelHTTPEndpoint, exporterConfig, resourceConfig, specs.OtelMetricExportInterval)
+			otlpMonitor, err = otlp.NewMonitor("identity-login-ui", cfg, buckets, logger)
+			if err != nil {
+				return err
+			}
+			monitors = append(monitors, otlpMonitor)
+		}
+	}
+
+	if len(monitors) == 1 {
+		monitor = monitors[0]
+	} else {
+		monitor = monitoring.NewMultiMonitor("identity-login-ui", monitors...)
+	}
+
 	distFS, err := fs.Sub(jsFS, "ui/dist")
 	if err != nil {
 		return fmt.Errorf("issue with js distribution files: %w", err)
@@ -84,16 +134,23 @@ func serve() error {
 
 	var grpcConn *grpc.ClientConn
 	if specs.MultiTenancyEnabled {
-		conn, err := tenants.NewGRPCConn(specs.TenantServiceGRPCAddress, specs.TenantServiceTLSEnabled)
+		tlsConfig := tenants.TLSConfig{
+			Enabled:    specs.TenantServiceTLSEnabled,
+			CAFile:     specs.TenantServiceTLSCAFile,
+			CertFile:   specs.TenantServiceTLSCertFile,
+			KeyFile:    specs.TenantServiceTLSKeyFile,
+			ServerName: specs.TenantServiceTLSServerName,
+		}
+		conn, err := tenants.NewGRPCConn(specs.TenantServiceGRPCAddress, tlsConfig, specs.TenantServiceBearerToken, tracer, monitor, logger)
 		if err != nil {
 			return err
 		}
 		grpcConn = conn
 		defer grpcConn.Close()
-		logger.Infof("Tenant validation enabled (tenant-service: %s, tls: %v, timeout: %s)", specs.TenantServiceGRPCAddress, specs.TenantServiceTLSEnabled, specs.TenantServiceGRPCTimeout)
+		logger.Infof("Tenant validation enabled (tenant-service: %s, tls: %v, mtls: %v, timeout: %s)", specs.TenantServiceGRPCAddress, specs.TenantServiceTLSEnabled, specs.TenantServiceTLSCertFile != "", specs.TenantServiceGRPCTimeout)
 	}
 
-	router, err := buildRouter(specs, distFS, logger, grpcConn)
+	router, err := buildRouter(specs, distFS, tracer, monitor, logger, grpcConn)
 	if err != nil {
 		return err
 	}
@@ -107,16 +164,18 @@ func serve() error {
 		Handler:      router,
 	}
 
-	return handleServeAndShutdown(srv, logger.Security())
-}
+	telemetry := []shutdowner{tracer}
+	if otlpMonitor != nil {
+		telemetry = append(telemetry, otlpMonitor)
+	}
 
-func buildRouter(specs *config.EnvSpec, distFS fs.FS, logger *logging.Logger, grpcConn *grpc.ClientConn) (http.Handler, error) {
-	monitor := prometheus.NewMonitor("identity-login-ui", logger)
-	tracer := tracing.NewTracer(tracing.NewConfig(specs.TracingEnabled, specs.OtelGRPCEndpoint, specs.OtelHTTPEndpoint, logger))
+	return handleServeAndShutdown(srv, logger.Security(), telemetry...)
+}
 
-	kClient := ik.NewClient(specs.KratosPublicURL, specs.Debug)
-	kAdminClient := ik.NewClient(specs.KratosAdminURL, specs.Debug)
-	hClient := ih.NewClient(specs.HydraAdminURL, specs.Debug)
+func buildRouter(specs *config.EnvSpec, distFS fs.FS, tracer *tracing.Tracer, monitor monitoring.MonitorInterface, logger *logging.Logger, grpcConn *grpc.ClientConn) (http.Handler, error) {
+	kClient := ik.NewClient(specs.KratosPublicURL, specs.Debug, monitor, logger)
+	kAdminClient := ik.NewClient(specs.KratosAdminURL, specs.Debug, monitor, logger)
+	hClient := ih.NewClient(specs.HydraAdminURL, specs.Debug, monitor, logger)
 
 	encrypt := cookies.NewEncrypt([]byte(specs.CookiesEncryptionKey), logger, tracer)
 	cookieManager := cookies.NewAuthCookieManager(
@@ -126,34 +185,96 @@ func buildRouter(specs *config.EnvSpec, distFS fs.FS, logger *logging.Logger, gr
 	)
 
 	var authzClient authz.AuthzClientInterface
-	if specs.AuthorizationEnabled {
+	if specs.AuthorizationEnabled && specs.AuthorizationBackend == "local" {
+		logger.Infof("Authorization is enabled, using the tuples from %s", specs.AuthorizationTuplesFile)
+		// evaluated in memory, the file changes are picked up on the next call
+		localClient, err := fga.NewLocalClient(specs.AuthorizationTuplesFile, []byte(authz.AuthModel), tracer, monitor, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		authzClient = localClient
+	} else if specs.AuthorizationEnabled {
 		logger.Info("Authorization is enabled")
 		cfg := fga.NewConfig(specs.ApiScheme, specs.ApiHost, specs.StoreId, specs.ApiToken, specs.AuthorizationModelId, specs.Debug, tracer, monitor, logger)
-		authzClient = fga.NewClient(cfg)
+		authzClient = authz.NewCachedClient(
+			fga.NewClient(cfg),
+			specs.AuthorizationCacheTTL,
+			specs.AuthorizationCacheSize,
+			specs.AuthorizationFailOpen,
+			specs.AuthorizationTimeout,
+			tracer,
+			monitor,
+			logger,
+		)
 	} else {
 		logger.Info("Authorization is disabled, using noop authorizer")
 		authzClient = fga.NewNoopClient(tracer, monitor, logger)
 	}
 
-	authorizer := authz.NewAuthorizer(authzClient, tracer, monitor, logger)
+	authorizer := authz.NewAuthorizer(authzClient, authz.ModelValidationMode(specs.AuthorizationModelValidation), tracer, monitor, logger)
 	if err := authorizer.ValidateModel(context.Background()); err != nil {
 		return nil, fmt.Errorf("invalid authorization model provided: %w", err)
 	}
 
+	var mfaPolicy *mfa.Policy
+	if specs.MFAPolicyFile != "" {
+		p, err := mfa.LoadPolicy(specs.MFAPolicyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Infof("Using MFA policy from %s", specs.MFAPolicyFile)
+		mfaPolicy = p
+	}
+
+	var tenantConfigSource tenants.AppConfigSourceInterface
+	if specs.TenantConfigFile != "" {
+		source, err := tenants.LoadAppConfigs(specs.TenantConfigFile)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Infof("Using tenant config from %s", specs.TenantConfigFile)
+		tenantConfigSource = source
+	}
+
 	var tenantsServiceClient tenants.TenantServiceClientInterface
+	var tenantsHealthClient healthpb.HealthClient
 	if grpcConn != nil {
-		tenantsServiceClient = tenant.NewTenantServiceClient(grpcConn)
+		retry := tenants.RetryPolicy{
+			MaxAttempts:    specs.TenantServiceRetryMaxAttempts,
+			InitialBackoff: specs.TenantServiceRetryInitialBackoff,
+			MaxBackoff:     specs.TenantServiceRetryMaxBackoff,
+		}
+		tenantsServiceClient = tenants.NewResilientClient(
+			tenant.NewTenantServiceClient(grpcConn),
+			retry,
+			specs.TenantServiceBreakerThreshold,
+			specs.TenantServiceBreakerCooldown,
+			tracer,
+			monitor,
+			logger,
+		)
+		tenantsHealthClient = healthpb.NewHealthClient(grpcConn)
 	}
 
 	router := web.NewRouter(
 		web.WithKratosClients(kClient, kAdminClient),
 		web.WithTenantsServiceClient(tenantsServiceClient),
 		web.WithTenantsGRPCTimeout(specs.TenantServiceGRPCTimeout),
+		web.WithTenantsCache(specs.TenantCacheTTL, specs.TenantCacheNegativeTTL, specs.TenantCacheSize),
+		web.WithTenantsFailOpen(specs.TenantServiceFailOpen),
+		web.WithTenantsHealthClient(tenantsHealthClient),
+		web.WithTenantsAppConfigSource(tenantConfigSource),
 		web.WithHydraClient(hClient),
 		web.WithAuthzClient(authorizer),
+		web.WithAuthorizationEnabled(specs.AuthorizationEnabled),
 		web.WithCookieManager(cookieManager),
 		web.WithFS(distFS),
 		web.WithFlags(specs.VerificationEnabled, specs.MFAEnabled, specs.OIDCWebAuthnSequencingEnabled, specs.IdentifierFirstEnabled, specs.MultiTenancyEnabled),
+		web.WithMFAPolicy(mfaPolicy),
+		web.WithBackupCodesThreshold(specs.BackupCodesThreshold),
 		web.WithBaseURL(specs.BaseURL),
 		web.WithSupportEmail(specs.SupportEmail),
 		web.WithFeatureFlags(specs.FeatureFlags),
@@ -165,8 +286,13 @@ func buildRouter(specs *config.EnvSpec, distFS fs.FS, logger *logging.Logger, gr
 	return router, nil
 }
 
-func handleServeAndShutdown(srv *http.Server, securityLogger logging.SecurityLoggerInterface) error {
-	var listenAndServeError, shutdownError error
+// shutdowner flushes buffered telemetry on graceful shutdown
+type shutdowner interface {
+	Shutdown(context.Context) error
+}
+
+func handleServeAndShutdown(srv *http.Server, securityLogger logging.SecurityLoggerInterface, telemetry ...shutdowner) error {
+	var listenAndServeError, shutdownError, telemetryShutdownError error
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
@@ -189,5 +315,12 @@ func handleServeAndShutdown(srv *http.Server, securityLogger logging.SecurityLog
 		shutdownError = fmt.Errorf("server shutdown error: %w", err)
 	}
 
-	return errors.Join(listenAndServeError, shutdownError)
+	// flush spans and metrics recorded while draining in-flight requests
+	for _, t := range telemetry {
+		if err := t.Shutdown(ctx); err != nil {
+			telemetryShutdownError = errors.Join(telemetryShutdownError, fmt.Errorf("telemetry shutdown error: %w", err))
+		}
+	}
+
+	return errors.Join(listenAndServeError, shutdownError, telemetryShutdownError)
 }
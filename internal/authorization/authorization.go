@@ -17,9 +17,25 @@ import (
 
 var ErrInvalidAuthModel = fmt.Errorf("Invalid authorization model schema")
 
+// ModelValidationMode sets how strictly the store model is checked against
+// the built-in one at startup
+type ModelValidationMode string
+
+const (
+	// ModelValidationStrict requires the store model to match the built-in one
+	ModelValidationStrict ModelValidationMode = "strict"
+	// ModelValidationCompatible requires the store model to define at least
+	// the types and relations of the built-in one
+	ModelValidationCompatible ModelValidationMode = "compatible"
+	// ModelValidationSkip does not check the store model
+	ModelValidationSkip ModelValidationMode = "skip"
+)
+
 type Authorizer struct {
 	Client AuthzClientInterface
 
+	validationMode ModelValidationMode
+
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
@@ -81,6 +97,12 @@ func (a *Authorizer) ValidateModel(ctx context.Context) error {
 	ctx, span := a.tracer.Start(ctx, "authorization.Authorizer.ValidateModel")
 	defer span.End()
 
+	if a.validationMode == ModelValidationSkip {
+		a.logger.Info("authorization model validation skipped")
+		span.SetStatus(codes.Ok, "")
+		return nil
+	}
+
 	var builtinAuthorizationModel fga.AuthorizationModel
 	err := json.Unmarshal([]byte(AuthModel), &builtinAuthorizationModel)
 	if err != nil {
@@ -89,25 +111,37 @@ func (a *Authorizer) ValidateModel(ctx context.Context) error {
 		return err
 	}
 
-	eq, err := a.Client.CompareModel(ctx, builtinAuthorizationModel)
+	diff, err := a.Client.CompareModel(ctx, builtinAuthorizationModel)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
-	if !eq {
-		span.RecordError(ErrInvalidAuthModel)
+
+	valid := diff.Compatible()
+	if a.validationMode == ModelValidationStrict {
+		valid = diff.Equal()
+	}
+
+	if !valid {
+		err := fmt.Errorf("%w, %s mode, differences with the built-in model:\n%s", ErrInvalidAuthModel, a.validationMode, diff)
+		span.RecordError(err)
 		span.SetStatus(codes.Error, ErrInvalidAuthModel.Error())
-		return ErrInvalidAuthModel
+		return err
+	}
+
+	if !diff.Equal() {
+		a.logger.Infof("authorization model is compatible with the built-in one, differences:\n%s", diff)
 	}
 
 	span.SetStatus(codes.Ok, "")
 	return nil
 }
 
-func NewAuthorizer(client AuthzClientInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Authorizer {
+func NewAuthorizer(client AuthzClientInterface, validationMode ModelValidationMode, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Authorizer {
 	authorizer := new(Authorizer)
 	authorizer.Client = client
+	authorizer.validationMode = validationMode
 	authorizer.tracer = tracer
 	authorizer.monitor = monitor
 	authorizer.logger = logger
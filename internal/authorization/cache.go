@@ -0,0 +1,239 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authorization
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/openfga"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	fga "github.com/openfga/go-sdk"
+)
+
+const (
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+
+	// allowedAccessRelation is the only relation ListObjects fails open on,
+	// no allowed objects leaves the providers unrestricted
+	allowedAccessRelation = "allowed_access"
+
+	defaultCacheMaxEntries = 10000
+)
+
+type cacheEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// CachedClient decorates an AuthzClientInterface with a bounded, least
+// recently used cache of ListObjects and Check decisions. Concurrent lookups
+// of the same key share a single OpenFGA call.
+//
+// The tuples are written out of process, by the fga command or the OpenFGA
+// API, so a change of the tuples is only seen once the cached decisions
+// expire after ttl.
+//
+// The shared OpenFGA call outlives the caller that started it but is bounded
+// by timeout. When it fails and failOpen is set, Check allows and ListObjects
+// of allowed_access returns no objects, which leaves the providers
+// unrestricted; other relations, such as denied_access, and callers that went
+// away get the error.
+type CachedClient struct {
+	client AuthzClientInterface
+
+	ttl        time.Duration
+	maxEntries int
+	failOpen   bool
+	timeout    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	group   singleflight.Group
+	now     func() time.Time
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (c *CachedClient) ListObjects(ctx context.Context, user string, relation string, objectType string) ([]string, error) {
+	ctx, span := c.tracer.Start(ctx, "authorization.CachedClient.ListObjects")
+	defer span.End()
+
+	key := strings.Join([]string{"list", user, relation, objectType}, "|")
+	value, err := c.lookup(ctx, "list_objects", key, func(ctx context.Context) (any, error) {
+		return c.client.ListObjects(ctx, user, relation, objectType)
+	})
+
+	if relation == allowedAccessRelation && c.shouldFailOpen(ctx, err) {
+		c.logger.Warnf("OpenFGA unavailable, failing open on list of %s %s for %s: %v", objectType, relation, user, err)
+		span.SetAttributes(attribute.Bool("authz.fail_open", true))
+		span.SetStatus(codes.Ok, "")
+		return []string{}, nil
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return value.([]string), nil
+}
+
+func (c *CachedClient) Check(ctx context.Context, user string, relation string, object string) (bool, error) {
+	ctx, span := c.tracer.Start(ctx, "authorization.CachedClient.Check")
+	defer span.End()
+
+	key := strings.Join([]string{"check", user, relation, object}, "|")
+	value, err := c.lookup(ctx, "check", key, func(ctx context.Context) (any, error) {
+		return c.client.Check(ctx, user, relation, object)
+	})
+
+	if c.shouldFailOpen(ctx, err) {
+		c.logger.Warnf("OpenFGA unavailable, failing open on check of %s %s for %s: %v", object, relation, user, err)
+		span.SetAttributes(attribute.Bool("authz.fail_open", true))
+		span.SetStatus(codes.Ok, "")
+		return true, nil
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return value.(bool), nil
+}
+
+func (c *CachedClient) ReadModel(ctx context.Context) (*fga.AuthorizationModel, error) {
+	return c.client.ReadModel(ctx)
+}
+
+func (c *CachedClient) CompareModel(ctx context.Context, model fga.AuthorizationModel) (*openfga.ModelDiff, error) {
+	return c.client.CompareModel(ctx, model)
+}
+
+// shouldFailOpen reports whether err, returned by a lookup, is an unavailable
+// OpenFGA to be ignored, the caller going away is not.
+func (c *CachedClient) shouldFailOpen(ctx context.Context, err error) bool {
+	return err != nil && c.failOpen && ctx.Err() == nil
+}
+
+func (c *CachedClient) lookup(ctx context.Context, cache, key string, fetch func(context.Context) (any, error)) (any, error) {
+	if value, ok := c.get(key); ok {
+		c.observe(cache, cacheHit)
+		return value, nil
+	}
+
+	c.observe(cache, cacheMiss)
+
+	// the shared call must not be cancelled when the first caller goes away,
+	// but must not hang the callers joining it either
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		defer cancel()
+
+		value, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.set(key, value)
+		return value, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		return res.Val, res.Err
+	}
+}
+
+func (c *CachedClient) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return entry.value, true
+}
+
+func (c *CachedClient) set(key string, value any) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, value: value, expiresAt: c.now().Add(c.ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *CachedClient) observe(cache, result string) {
+	tags := map[string]string{"cache": "authz_" + cache, "result": result}
+	if err := c.monitor.SetCacheLookupMetric(tags, 1); err != nil {
+		c.logger.Debugf("failed to record cache lookup metric: %v", err)
+	}
+}
+
+func NewCachedClient(client AuthzClientInterface, ttl time.Duration, maxEntries int, failOpen bool, timeout time.Duration, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *CachedClient {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return &CachedClient{
+		client:     client,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		failOpen:   failOpen,
+		timeout:    timeout,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+		tracer:     tracer,
+		monitor:    monitor,
+		logger:     logger,
+	}
+}
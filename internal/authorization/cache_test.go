@@ -0,0 +1,227 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/misc/clock"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_logger.go -source=../logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_monitor.go -source=../monitoring/interfaces.go
+
+func TestCachedClientListObjectsHit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockAuthzClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedClient(mockClient, time.Minute, 2, false, time.Minute, tracing.NewNoopTracer(), mockMonitor, NewMockLoggerInterface(ctrl))
+
+	mockClient.EXPECT().ListObjects(gomock.Any(), "app:console", "allowed_access", "provider").Return([]string{"github"}, nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_list_objects", "result": cacheMiss}, 1.0).Return(nil)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_list_objects", "result": cacheHit}, 1.0).Return(nil)
+
+	for i := 0; i < 2; i++ {
+		got, err := c.ListObjects(context.Background(), "app:console", "allowed_access", "provider")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(got) != 1 || got[0] != "github" {
+			t.Fatalf("unexpected objects %v", got)
+		}
+	}
+}
+
+func TestCachedClientCheckExpiresEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockAuthzClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	fakeClock := clock.NewFake()
+	c := NewCachedClient(mockClient, time.Minute, 2, false, time.Minute, tracing.NewNoopTracer(), mockMonitor, NewMockLoggerInterface(ctrl))
+	c.now = fakeClock.Now
+
+	mockClient.EXPECT().Check(gomock.Any(), "user:1", "member", "group:admins").Return(true, nil).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_check", "result": cacheMiss}, 1.0).Return(nil).Times(2)
+
+	if _, err := c.Check(context.Background(), "user:1", "member", "group:admins"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fakeClock.Advance(time.Minute)
+
+	if _, err := c.Check(context.Background(), "user:1", "member", "group:admins"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCachedClientEvictsLeastRecentlyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockAuthzClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedClient(mockClient, time.Minute, 2, false, time.Minute, tracing.NewNoopTracer(), mockMonitor, NewMockLoggerInterface(ctrl))
+
+	mockClient.EXPECT().Check(gomock.Any(), gomock.Any(), "member", gomock.Any()).Return(true, nil).Times(4)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_check", "result": cacheMiss}, 1.0).Return(nil).Times(4)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_check", "result": cacheHit}, 1.0).Return(nil)
+
+	for _, user := range []string{"user:1", "user:2", "user:1", "user:3", "user:2"} {
+		if _, err := c.Check(context.Background(), user, "member", "group:admins"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestCachedClientSharesConcurrentLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockAuthzClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedClient(mockClient, time.Minute, 2, false, time.Minute, tracing.NewNoopTracer(), mockMonitor, NewMockLoggerInterface(ctrl))
+
+	release := make(chan struct{})
+	mockClient.EXPECT().ListObjects(gomock.Any(), "app:console", "allowed_access", "provider").DoAndReturn(
+		func(context.Context, string, string, string) ([]string, error) {
+			<-release
+			return []string{"github"}, nil
+		},
+	).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(gomock.Any(), float64(1)).Return(nil).AnyTimes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListObjects(context.Background(), "app:console", "allowed_access", "provider"); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestCachedClientUnavailable(t *testing.T) {
+	tests := []struct {
+		name            string
+		failOpen        bool
+		err             error
+		expectedAllowed bool
+		expectedErr     bool
+	}{
+		{name: "fail closed", failOpen: false, err: errors.New("connection refused"), expectedAllowed: false, expectedErr: true},
+		{name: "fail open", failOpen: true, err: errors.New("connection refused"), expectedAllowed: true, expectedErr: false},
+		{name: "deadline exceeded", failOpen: true, err: fmt.Errorf("openfga: %w", context.DeadlineExceeded), expectedAllowed: true, expectedErr: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := NewMockAuthzClientInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			c := NewCachedClient(mockClient, time.Minute, 2, test.failOpen, time.Minute, tracing.NewNoopTracer(), mockMonitor, mockLogger)
+
+			mockClient.EXPECT().Check(gomock.Any(), "user:1", "member", "group:admins").Return(false, test.err).Times(1)
+			mockClient.EXPECT().ListObjects(gomock.Any(), "app:console", "allowed_access", "provider").Return(nil, test.err).Times(1)
+			mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_check", "result": cacheMiss}, 1.0).Return(nil)
+			mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_list_objects", "result": cacheMiss}, 1.0).Return(nil)
+			if !test.expectedErr {
+				mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(2)
+			}
+
+			allowed, err := c.Check(context.Background(), "user:1", "member", "group:admins")
+			if allowed != test.expectedAllowed || (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected check result %v, %v", allowed, err)
+			}
+
+			objects, err := c.ListObjects(context.Background(), "app:console", "allowed_access", "provider")
+			if len(objects) != 0 || (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected list result %v, %v", objects, err)
+			}
+		})
+	}
+}
+
+func TestCachedClientDeniedAccessFailsClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockAuthzClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedClient(mockClient, time.Minute, 2, true, time.Minute, tracing.NewNoopTracer(), mockMonitor, NewMockLoggerInterface(ctrl))
+
+	mockClient.EXPECT().ListObjects(gomock.Any(), "app:console", "denied_access", "provider").Return(nil, errors.New("connection refused"))
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_list_objects", "result": cacheMiss}, 1.0).Return(nil)
+
+	if objects, err := c.ListObjects(context.Background(), "app:console", "denied_access", "provider"); err == nil {
+		t.Fatalf("expected an error, got %v", objects)
+	}
+}
+
+func TestCachedClientHungOpenFGA(t *testing.T) {
+	tests := []struct {
+		name            string
+		callerGoesAway  bool
+		expectedAllowed bool
+		expectedErr     bool
+	}{
+		{name: "fail open on timeout", callerGoesAway: false, expectedAllowed: true, expectedErr: false},
+		{name: "caller went away", callerGoesAway: true, expectedAllowed: false, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := NewMockAuthzClientInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			c := NewCachedClient(mockClient, time.Minute, 2, true, 10*time.Millisecond, tracing.NewNoopTracer(), mockMonitor, mockLogger)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mockClient.EXPECT().Check(gomock.Any(), "user:1", "member", "group:admins").DoAndReturn(
+				func(ctx context.Context, _, _, _ string) (bool, error) {
+					if test.callerGoesAway {
+						cancel()
+					}
+					<-ctx.Done()
+					return false, ctx.Err()
+				},
+			)
+			mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "authz_check", "result": cacheMiss}, 1.0).Return(nil)
+			if !test.expectedErr {
+				mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+			}
+
+			allowed, err := c.Check(ctx, "user:1", "member", "group:admins")
+			if allowed != test.expectedAllowed || (err != nil) != test.expectedErr {
+				t.Fatalf("unexpected check result %v, %v", allowed, err)
+			}
+		})
+	}
+}
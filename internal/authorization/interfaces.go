@@ -6,6 +6,7 @@ package authorization
 import (
 	"context"
 
+	"github.com/canonical/identity-platform-login-ui/internal/openfga"
 	fga "github.com/openfga/go-sdk"
 )
 
@@ -20,5 +21,5 @@ type AuthzClientInterface interface {
 	ListObjects(context.Context, string, string, string) ([]string, error)
 	Check(context.Context, string, string, string) (bool, error)
 	ReadModel(context.Context) (*fga.AuthorizationModel, error)
-	CompareModel(context.Context, fga.AuthorizationModel) (bool, error)
+	CompareModel(context.Context, fga.AuthorizationModel) (*openfga.ModelDiff, error)
 }
@@ -5,15 +5,39 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"time"
 )
 
+// OpenFGASpec is the OpenFGA connection setup, shared by the server and the fga commands
+type OpenFGASpec struct {
+	ApiScheme            string `envconfig:"openfga_api_scheme" default:""`
+	ApiHost              string `envconfig:"openfga_api_host"`
+	ApiToken             string `envconfig:"openfga_api_token"`
+	StoreId              string `envconfig:"openfga_store_id"`
+	AuthorizationModelId string `envconfig:"openfga_authorization_model_id" default:""`
+}
+
 // EnvSpec is the basic environment configuration setup needed for the app to start
 type EnvSpec struct {
 	OtelGRPCEndpoint string `envconfig:"otel_grpc_endpoint"`
 	OtelHTTPEndpoint string `envconfig:"otel_http_endpoint"`
 	TracingEnabled   bool   `envconfig:"tracing_enabled" default:"true"`
 
+	OtelTLSEnabled            bool     `envconfig:"otel_tls_enabled" default:"false"`
+	OtelTLSCAFile             string   `envconfig:"otel_tls_ca_file"`
+	OtelHeaders               string   `envconfig:"otel_headers"`
+	OtelSampleRatio           float64  `envconfig:"otel_sample_ratio" default:"1" validate:"min=0,max=1"`
+	OtelPropagators           []string `envconfig:"otel_propagators" default:"tracecontext,baggage,jaeger" validate:"dive,oneof=tracecontext baggage jaeger none"`
+	OtelDeploymentEnvironment string   `envconfig:"otel_deployment_environment"`
+	OtelServiceInstanceID     string   `envconfig:"otel_service_instance_id"`
+	TracingStdoutEnabled      bool     `envconfig:"tracing_stdout_enabled" default:"false"`
+
+	MetricsExporters         []string      `envconfig:"metrics_exporters" default:"prometheus" validate:"min=1,dive,oneof=prometheus otlp"`
+	OtelMetricExportInterval time.Duration `envconfig:"otel_metric_export_interval" default:"60s"`
+	MetricsLatencyBuckets    []float64     `envconfig:"metrics_latency_buckets"`
+	MetricsSizeBuckets       []float64     `envconfig:"metrics_size_buckets"`
+
 	LogLevel string `envconfig:"log_level" default:"error"`
 	Debug    bool   `envconfig:"debug" default:"false"`
 
@@ -23,30 +47,74 @@ type EnvSpec struct {
 	CookiesEncryptionKey string `envconfig:"cookies_encryption_key" required:"true" validate:"required,min=32,max=32"`
 	CookieTTL            int    `envconfig:"cookie_ttl" default:"300"`
 
-	KratosPublicURL          string `envconfig:"kratos_public_url"`
-	KratosAdminURL           string `envconfig:"kratos_admin_url"`
-	HydraAdminURL            string `envconfig:"hydra_admin_url"`
-	TenantServiceGRPCAddress string        `envconfig:"tenant_service_grpc_address"`
-	TenantServiceGRPCTimeout time.Duration `envconfig:"tenant_service_grpc_timeout" default:"5s"`
-	TenantServiceTLSEnabled  bool          `envconfig:"tenant_service_tls_enabled" default:"false"`
+	KratosPublicURL                  string        `envconfig:"kratos_public_url"`
+	KratosAdminURL                   string        `envconfig:"kratos_admin_url"`
+	HydraAdminURL                    string        `envconfig:"hydra_admin_url"`
+	TenantServiceGRPCAddress         string        `envconfig:"tenant_service_grpc_address"`
+	TenantServiceGRPCTimeout         time.Duration `envconfig:"tenant_service_grpc_timeout" default:"5s"`
+	TenantServiceTLSEnabled          bool          `envconfig:"tenant_service_tls_enabled" default:"false"`
+	TenantServiceTLSCAFile           string        `envconfig:"tenant_service_tls_ca_file"`
+	TenantServiceTLSCertFile         string        `envconfig:"tenant_service_tls_cert_file"`
+	TenantServiceTLSKeyFile          string        `envconfig:"tenant_service_tls_key_file"`
+	TenantServiceTLSServerName       string        `envconfig:"tenant_service_tls_server_name"`
+	TenantServiceBearerToken         string        `envconfig:"tenant_service_bearer_token"`
+	TenantCacheTTL                   time.Duration `envconfig:"tenant_cache_ttl" default:"1m"`
+	TenantCacheNegativeTTL           time.Duration `envconfig:"tenant_cache_negative_ttl" default:"10s"`
+	TenantCacheSize                  int           `envconfig:"tenant_cache_size" default:"1000"`
+	TenantServiceRetryMaxAttempts    int           `envconfig:"tenant_service_retry_max_attempts" default:"3" validate:"min=1"`
+	TenantServiceRetryInitialBackoff time.Duration `envconfig:"tenant_service_retry_initial_backoff" default:"100ms"`
+	TenantServiceRetryMaxBackoff     time.Duration `envconfig:"tenant_service_retry_max_backoff" default:"1s"`
+	TenantServiceBreakerThreshold    int           `envconfig:"tenant_service_breaker_threshold" default:"5" validate:"min=0"`
+	TenantServiceBreakerCooldown     time.Duration `envconfig:"tenant_service_breaker_cooldown" default:"30s"`
+	TenantServiceFailOpen            bool          `envconfig:"tenant_service_fail_open" default:"false"`
+	TenantConfigFile                 string        `envconfig:"tenant_config_file"`
 
-	ApiScheme            string `envconfig:"openfga_api_scheme" default:""`
-	ApiHost              string `envconfig:"openfga_api_host"`
-	ApiToken             string `envconfig:"openfga_api_token"`
-	StoreId              string `envconfig:"openfga_store_id"`
-	AuthorizationModelId string `envconfig:"openfga_authorization_model_id" default:""`
-	AuthorizationEnabled bool   `envconfig:"authorization_enabled" default:"false"`
+	OpenFGASpec
+	AuthorizationEnabled bool `envconfig:"authorization_enabled" default:"false"`
+
+	AuthorizationModelValidation string        `envconfig:"openfga_model_validation" default:"compatible" validate:"oneof=strict compatible skip"`
+	AuthorizationCacheTTL        time.Duration `envconfig:"authorization_cache_ttl" default:"10s"`
+	AuthorizationCacheSize       int           `envconfig:"authorization_cache_size" default:"10000"`
+	AuthorizationFailOpen        bool          `envconfig:"authorization_fail_open" default:"false"`
+	AuthorizationTimeout         time.Duration `envconfig:"authorization_timeout" default:"5s"`
+	AuthorizationBackend         string        `envconfig:"authorization_backend" default:"openfga" validate:"oneof=openfga local"`
+	AuthorizationTuplesFile      string        `envconfig:"authorization_tuples_file" validate:"required_if=AuthorizationBackend local"`
 
 	VerificationEnabled           bool     `envconfig:"verification_enabled" default:"false"`
 	MFAEnabled                    bool     `envconfig:"mfa_enabled" default:"true"`
+	MFAPolicyFile                 string   `envconfig:"mfa_policy_file" default:""`
+	BackupCodesThreshold          int      `envconfig:"backup_codes_threshold" default:"3"`
 	OIDCWebAuthnSequencingEnabled bool     `envconfig:"oidc_webauthn_sequencing_enabled" default:"false"`
 	IdentifierFirstEnabled        bool     `envconfig:"identifier_first_enabled" default:"true"`
 	MultiTenancyEnabled           bool     `envconfig:"multi_tenancy_enabled" default:"false"`
-	FeatureFlags                  []string `envconfig:"feature_flags" default:"password,webauthn,backup_codes,totp,account_linking" validate:"dive,oneof=password webauthn backup_codes totp account_linking"`
+	FeatureFlags                  []string `envconfig:"feature_flags" default:"password,webauthn,backup_codes,totp,account_linking" validate:"dive,oneof=password webauthn backup_codes totp account_linking passkey"`
 
 	SupportEmail string `envconfig:"support_email" default:""`
 }
 
+// redacted replaces the values of secrets in the String output of the specs
+const redacted = "REDACTED"
+
+// String formats the specs with the secrets redacted, so that they can be logged
+func (s EnvSpec) String() string {
+	s.OtelHeaders = redact(s.OtelHeaders)
+	s.CookiesEncryptionKey = redact(s.CookiesEncryptionKey)
+	s.TenantServiceBearerToken = redact(s.TenantServiceBearerToken)
+	s.ApiToken = redact(s.ApiToken)
+
+	// a defined type drops the String method and avoids the recursion
+	type envSpec EnvSpec
+	return fmt.Sprintf("%+v", envSpec(s))
+}
+
+// redact hides a secret, an unset secret is left empty so it shows as missing
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
 type Flags struct {
 	ShowVersion bool
 }
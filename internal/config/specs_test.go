@@ -0,0 +1,46 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEnvSpecStringRedactsSecrets(t *testing.T) {
+	specs := &EnvSpec{
+		OtelHeaders:              "authorization=Bearer otel-secret",
+		CookiesEncryptionKey:     "cookie-secret",
+		TenantServiceBearerToken: "tenant-secret",
+		OpenFGASpec:              OpenFGASpec{ApiToken: "openfga-secret", ApiHost: "openfga:8080"},
+		KratosPublicURL:          "http://kratos:4433",
+	}
+
+	out := fmt.Sprintf("%v", specs)
+
+	for _, secret := range []string{"otel-secret", "cookie-secret", "tenant-secret", "openfga-secret"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("expected %q to be redacted in %s", secret, out)
+		}
+	}
+
+	for _, value := range []string{"OtelHeaders:" + redacted, "ApiToken:" + redacted, "openfga:8080", "http://kratos:4433"} {
+		if !strings.Contains(out, value) {
+			t.Fatalf("expected %q in %s", value, out)
+		}
+	}
+
+	if specs.ApiToken != "openfga-secret" {
+		t.Fatalf("expected the specs to be left untouched, got %q", specs.ApiToken)
+	}
+}
+
+func TestEnvSpecStringLeavesUnsetSecretsEmpty(t *testing.T) {
+	out := EnvSpec{}.String()
+
+	if strings.Contains(out, redacted) {
+		t.Fatalf("expected unset secrets to stay empty in %s", out)
+	}
+}
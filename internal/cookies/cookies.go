@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
@@ -20,6 +21,14 @@ const (
 	defaultCookiePath = "/"
 	stateCookieName   = "login_ui_state"
 
+	tenantPreferenceCookieName = "login_ui_tenant"
+	// tenantPreferenceTTL is how long a remembered tenant choice is kept,
+	// the cookie is renewed every time the choice is remembered again.
+	tenantPreferenceTTL = 30 * 24 * time.Hour
+	// maxRememberedTenants bounds the size of the cookie on browsers shared
+	// by several users.
+	maxRememberedTenants = 5
+
 	// NoTenantAvailable is a sentinel TenantID stored in FlowStateCookie
 	// when multi-tenancy is enabled but the user has no tenants to choose
 	// from. It distinguishes "selection completed with zero results" from
@@ -37,14 +46,80 @@ func ChallengeHash(loginChallenge string) string {
 	return hex.EncodeToString(h[:])
 }
 
+// SubjectHash returns the SHA-256 hex digest of the lowercased email, used to
+// key remembered tenants without storing the email in the cookie.
+func SubjectHash(email string) string {
+	return ChallengeHash(strings.ToLower(email))
+}
+
 // FlowStateCookie holds per-flow UI state persisted across redirects in an
 // encrypted browser cookie.
+//
+// TenantSwitch is set when the user asked to switch tenant, the next login
+// then asks them to pick a tenant instead of reusing a remembered or hinted
+// one.
+//
+// TenantHintError is not persisted, it carries the reason a tenant hint was
+// rejected from the tenant resolver to the tenant selection redirect.
 type FlowStateCookie struct {
-	LoginChallengeHash string `json:"lc,omitempty"`
-	TotpSetup          bool   `json:"t,omitempty"`
-	WebauthnSetup      bool   `json:"w,omitempty"`
-	BackupCodeUsed     bool   `json:"bc,omitempty"`
-	TenantID           string `json:"tid,omitempty"`
+	LoginChallengeHash     string `json:"lc,omitempty"`
+	TotpSetup              bool   `json:"t,omitempty"`
+	WebauthnSetup          bool   `json:"w,omitempty"`
+	BackupCodeUsed         bool   `json:"bc,omitempty"`
+	BackupCodesRemindLater bool   `json:"bcr,omitempty"`
+	TenantID               string `json:"tid,omitempty"`
+	TenantSwitch           bool   `json:"ts,omitempty"`
+	TenantHintError        string `json:"-"`
+}
+
+// RememberedTenant is the last tenant a user chose to remember.
+type RememberedTenant struct {
+	SubjectHash string `json:"s"`
+	TenantID    string `json:"t"`
+}
+
+// TenantPreferenceCookie holds the remembered tenants of the users who logged
+// in from this browser, most recently remembered first. It outlives the login
+// flows and is persisted in an encrypted browser cookie.
+type TenantPreferenceCookie struct {
+	Tenants []RememberedTenant `json:"t,omitempty"`
+}
+
+// TenantID returns the tenant remembered for the email, or "" if none.
+func (c TenantPreferenceCookie) TenantID(email string) string {
+	if email == "" {
+		return ""
+	}
+
+	h := SubjectHash(email)
+	for _, t := range c.Tenants {
+		if t.SubjectHash == h {
+			return t.TenantID
+		}
+	}
+	return ""
+}
+
+// Remember returns a copy of the cookie with tenantID remembered for email.
+func (c TenantPreferenceCookie) Remember(email, tenantID string) TenantPreferenceCookie {
+	next := c.Forget(email)
+	next.Tenants = append([]RememberedTenant{{SubjectHash: SubjectHash(email), TenantID: tenantID}}, next.Tenants...)
+	if len(next.Tenants) > maxRememberedTenants {
+		next.Tenants = next.Tenants[:maxRememberedTenants]
+	}
+	return next
+}
+
+// Forget returns a copy of the cookie without the tenant remembered for email.
+func (c TenantPreferenceCookie) Forget(email string) TenantPreferenceCookie {
+	h := SubjectHash(email)
+	next := TenantPreferenceCookie{Tenants: make([]RememberedTenant, 0, len(c.Tenants)+1)}
+	for _, t := range c.Tenants {
+		if t.SubjectHash != h {
+			next.Tenants = append(next.Tenants, t)
+		}
+	}
+	return next
 }
 
 // AuthCookieManager is the production implementation of AuthCookieManagerInterface.
@@ -55,14 +130,15 @@ type AuthCookieManager struct {
 }
 
 // RenewForChallenge returns a new FlowStateCookie with LoginChallengeHash set
-// for the given loginChallenge. TenantID is carried forward only when the
-// existing cookie was stored for the same challenge, preventing state
-// pollution across different flows.
+// for the given loginChallenge. TenantID and BackupCodesRemindLater are
+// carried forward only when the existing cookie was stored for the same
+// challenge, preventing state pollution across different flows.
 func (c FlowStateCookie) RenewForChallenge(loginChallenge string) FlowStateCookie {
 	lcHash := ChallengeHash(loginChallenge)
 	next := FlowStateCookie{LoginChallengeHash: lcHash}
 	if c.LoginChallengeHash == lcHash {
 		next.TenantID = c.TenantID
+		next.BackupCodesRemindLater = c.BackupCodesRemindLater
 	}
 	return next
 }
@@ -89,6 +165,29 @@ func (a *AuthCookieManager) ClearStateCookie(w http.ResponseWriter) {
 	a.clearCookie(w, stateCookieName, defaultCookiePath)
 }
 
+func (a *AuthCookieManager) SetTenantPreferenceCookie(w http.ResponseWriter, preference TenantPreferenceCookie) error {
+	if len(preference.Tenants) == 0 {
+		a.clearCookie(w, tenantPreferenceCookieName, defaultCookiePath)
+		return nil
+	}
+
+	rawPreference, err := json.Marshal(preference)
+	if err != nil {
+		return err
+	}
+	return a.setCookie(w, tenantPreferenceCookieName, string(rawPreference), defaultCookiePath, tenantPreferenceTTL, http.SameSiteLaxMode)
+}
+
+func (a *AuthCookieManager) GetTenantPreferenceCookie(r *http.Request) (TenantPreferenceCookie, error) {
+	var ret TenantPreferenceCookie
+	c, err := a.getCookie(r, tenantPreferenceCookieName)
+	if c == "" || err != nil {
+		return TenantPreferenceCookie{}, err
+	}
+	err = json.Unmarshal([]byte(c), &ret)
+	return ret, err
+}
+
 func (a *AuthCookieManager) setCookie(w http.ResponseWriter, name, value string, path string, ttl time.Duration, sameSitePolicy http.SameSite) error {
 	if value == "" {
 		return nil
@@ -168,3 +168,135 @@ func TestAuthCookieManager_SetStateCookieFailure(t *testing.T) {
 		t.Fatalf("expected error to be not nil")
 	}
 }
+
+func TestFlowStateCookie_RenewForChallenge(t *testing.T) {
+	c := FlowStateCookie{
+		LoginChallengeHash:     ChallengeHash("challenge"),
+		BackupCodeUsed:         true,
+		BackupCodesRemindLater: true,
+		TenantID:               "tenant",
+	}
+
+	same := c.RenewForChallenge("challenge")
+	if !same.BackupCodesRemindLater || same.TenantID != "tenant" {
+		t.Fatalf("expected flow state to be carried forward, got %+v", same)
+	}
+	if same.BackupCodeUsed {
+		t.Fatal("expected BackupCodeUsed to be reset")
+	}
+
+	other := c.RenewForChallenge("other")
+	if other.BackupCodesRemindLater || other.TenantID != "" {
+		t.Fatalf("expected flow state to be reset for a different challenge, got %+v", other)
+	}
+	if other.LoginChallengeHash != ChallengeHash("other") {
+		t.Fatalf("expected login challenge hash to be updated, got %s", other.LoginChallengeHash)
+	}
+}
+
+func TestAuthCookieManager_SetTenantPreferenceCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockEncrypt := NewMockEncryptInterface(ctrl)
+
+	preference := TenantPreferenceCookie{}.Remember("user@example.com", "tenant")
+	js, _ := json.Marshal(preference)
+
+	mockEncrypt.EXPECT().Encrypt(string(js)).Return("mock-preference", nil)
+
+	mockResponse := httptest.NewRecorder()
+
+	manager := NewAuthCookieManager(5, mockEncrypt, mockLogger)
+	err := manager.SetTenantPreferenceCookie(mockResponse, preference)
+
+	c, found := findCookie("login_ui_tenant", mockResponse.Result().Cookies())
+	if !found {
+		t.Fatal("did not set tenant preference cookie")
+	}
+
+	if c.Value != "mock-preference" {
+		t.Fatal("tenant preference cookie value does not match expected")
+	}
+
+	if c.MaxAge != int(tenantPreferenceTTL.Seconds()) {
+		t.Fatalf("expected tenant preference cookie to outlive the state cookie, got max age %d", c.MaxAge)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestAuthCookieManager_SetTenantPreferenceCookieEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockEncrypt := NewMockEncryptInterface(ctrl)
+
+	mockResponse := httptest.NewRecorder()
+
+	manager := NewAuthCookieManager(5, mockEncrypt, mockLogger)
+	if err := manager.SetTenantPreferenceCookie(mockResponse, TenantPreferenceCookie{}); err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	c, found := findCookie("login_ui_tenant", mockResponse.Result().Cookies())
+	if !found || c.Expires != time.Unix(0, 0).UTC() {
+		t.Fatal("did not clear tenant preference cookie")
+	}
+}
+
+func TestAuthCookieManager_GetTenantPreferenceCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockEncrypt := NewMockEncryptInterface(ctrl)
+
+	preference := TenantPreferenceCookie{}.Remember("user@example.com", "tenant")
+	js, _ := json.Marshal(preference)
+
+	mockEncrypt.EXPECT().Decrypt("mock-preference").Return(string(js), nil)
+
+	mockRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+	mockRequest.AddCookie(&http.Cookie{Name: "login_ui_tenant", Value: "mock-preference"})
+
+	manager := NewAuthCookieManager(5, mockEncrypt, mockLogger)
+	cookie, err := manager.GetTenantPreferenceCookie(mockRequest)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	if cookie.TenantID("User@Example.com") != "tenant" {
+		t.Fatalf("expected remembered tenant, got %+v", cookie)
+	}
+}
+
+func TestTenantPreferenceCookie_Remember(t *testing.T) {
+	c := TenantPreferenceCookie{}.Remember("a@example.com", "t1").Remember("b@example.com", "t2")
+
+	c = c.Remember("a@example.com", "t3")
+	if c.TenantID("a@example.com") != "t3" || c.TenantID("b@example.com") != "t2" {
+		t.Fatalf("unexpected remembered tenants %+v", c)
+	}
+	if len(c.Tenants) != 2 || c.Tenants[0].TenantID != "t3" {
+		t.Fatalf("expected the latest choice first, got %+v", c)
+	}
+
+	for i := 0; i < maxRememberedTenants; i++ {
+		c = c.Remember(string(rune('c'+i))+"@example.com", "t")
+	}
+	if len(c.Tenants) != maxRememberedTenants || c.TenantID("a@example.com") != "" {
+		t.Fatalf("expected the oldest choices to be dropped, got %+v", c)
+	}
+
+	c = c.Forget("c@example.com")
+	if c.TenantID("c@example.com") != "" || len(c.Tenants) != maxRememberedTenants-1 {
+		t.Fatalf("expected the choice to be forgotten, got %+v", c)
+	}
+
+	if (TenantPreferenceCookie{}).TenantID("") != "" {
+		t.Fatal("expected no tenant for an empty email")
+	}
+}
@@ -13,7 +13,7 @@ type EncryptInterface interface {
 	Decrypt(string) (string, error)
 }
 
-// AuthCookieManagerInterface describes operations on the encrypted state cookie.
+// AuthCookieManagerInterface describes operations on the encrypted cookies.
 type AuthCookieManagerInterface interface {
 	// SetStateCookie sets the nonce cookie on the response with the specified duration as MaxAge
 	SetStateCookie(http.ResponseWriter, FlowStateCookie) error
@@ -21,4 +21,8 @@ type AuthCookieManagerInterface interface {
 	GetStateCookie(*http.Request) (FlowStateCookie, error)
 	// ClearStateCookie sets the expiration of the cookie to epoch
 	ClearStateCookie(http.ResponseWriter)
+	// SetTenantPreferenceCookie sets the long-lived remembered tenants cookie, it is cleared when empty
+	SetTenantPreferenceCookie(http.ResponseWriter, TenantPreferenceCookie) error
+	// GetTenantPreferenceCookie returns the remembered tenants, or an empty value if the cookie is not present
+	GetTenantPreferenceCookie(*http.Request) (TenantPreferenceCookie, error)
 }
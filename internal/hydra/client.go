@@ -7,7 +7,9 @@ import (
 	"net/http"
 
 	hClient "github.com/ory/hydra-client-go/v2"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 )
 
 type Client struct {
@@ -23,7 +25,7 @@ func (c *Client) MetadataAPI() hClient.MetadataAPI {
 	return c.c.MetadataAPI
 }
 
-func NewClient(url string, debug bool) *Client {
+func NewClient(url string, debug bool, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Client {
 	c := new(Client)
 
 	configuration := hClient.NewConfiguration()
@@ -34,7 +36,7 @@ func NewClient(url string, debug bool) *Client {
 		},
 	}
 
-	configuration.HTTPClient = &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	configuration.HTTPClient = &http.Client{Transport: monitoring.NewTransport("hydra", http.DefaultTransport, monitor, logger)}
 
 	c.c = hClient.NewAPIClient(configuration)
 	c.deviceApi = newDeviceApiService(c.c)
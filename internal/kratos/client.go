@@ -11,7 +11,9 @@ import (
 	"strings"
 
 	client "github.com/ory/kratos-client-go/v25"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 )
 
 type Client struct {
@@ -79,7 +81,7 @@ func (c *Client) ExecuteIdentifierFirstUpdateLoginRequest(
 	return client.Do(req)
 }
 
-func NewClient(baseURL string, debug bool) *Client {
+func NewClient(baseURL string, debug bool, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Client {
 	configuration := client.NewConfiguration()
 	configuration.Debug = debug
 	configuration.Servers = []client.ServerConfiguration{
@@ -88,7 +90,7 @@ func NewClient(baseURL string, debug bool) *Client {
 		},
 	}
 
-	httpClient := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	httpClient := &http.Client{Transport: monitoring.NewTransport("kratos", http.DefaultTransport, monitor, logger)}
 	configuration.HTTPClient = httpClient
 
 	loginURL, err := url.Parse(baseURL + "/self-service/login")
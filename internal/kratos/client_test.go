@@ -8,6 +8,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 )
 
 func TestExecuteIdentifierFirstUpdateLoginRequest(t *testing.T) {
@@ -24,7 +27,7 @@ func TestExecuteIdentifierFirstUpdateLoginRequest(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewClient(server.URL, false)
+	client := NewClient(server.URL, false, monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
 	ctx := context.Background()
 
 	resp, err := client.ExecuteIdentifierFirstUpdateLoginRequest(ctx, "flow123", "csrf_token_1234", "test@example.com", cookies)
@@ -23,6 +23,7 @@ type SecurityLoggerInterface interface {
 	AccountLockout(string, ...Option)
 	PasswordChange(string, ...Option)
 	PasswordChangeFail(string, ...Option)
+	BackupCodeUsed(string, int, ...Option)
 	TokenCreate(...Option)
 	TokenRevoke(...Option)
 	TokenReuse(string, ...Option)
@@ -127,6 +127,15 @@ func (a *SecurityLogger) PasswordChangeFail(user string, options ...Option) {
 	a.l.DPanic(msg, fields...)
 }
 
+func (a *SecurityLogger) BackupCodeUsed(user string, remaining int, options ...Option) {
+	msg := fmt.Sprintf("User %s has logged in with a backup code, %d left", user, remaining)
+	fields := []Field{zap.String("event", fmt.Sprintf("authn_backup_code_use:%s,%d", user, remaining))}
+	for _, opt := range options {
+		fields = append(fields, opt...)
+	}
+	a.l.Info(msg, fields...)
+}
+
 func (a *SecurityLogger) TokenCreate(options ...Option) {
 	fields := []Field{zap.String("event", "authn_token_created:"+APP_ID)}
 	for _, opt := range options {
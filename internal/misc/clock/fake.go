@@ -0,0 +1,92 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package clock provides a fake clock for the tests of time based code, such
+// as caches and retries, which take their clock as a now or sleep function,
+// and file reloaders, which compare modification times.
+package clock
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Fake is a clock that only moves when told to, Sleep advances it instead of
+// blocking and records the requested durations.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// Now returns the current time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// Sleep advances the clock by d, it returns the context error if the context
+// is done.
+func (f *Fake) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.sleeps = append(f.sleeps, d)
+
+	return nil
+}
+
+// Sleeps returns the durations passed to Sleep so far.
+func (f *Fake) Sleeps() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]time.Duration(nil), f.sleeps...)
+}
+
+// WriteFile writes content to path modified at the current time of the clock,
+// then advances the clock by a second so that the next write is seen as a
+// change.
+func (f *Fake) WriteFile(t testing.TB, path string, content []byte) {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatalf("cannot write %s: %v", path, err)
+	}
+
+	if err := os.Chtimes(path, f.now, f.now); err != nil {
+		t.Fatalf("cannot set times of %s: %v", path, err)
+	}
+
+	f.now = f.now.Add(time.Second)
+}
+
+// NewFake returns a Fake clock set to a fixed time.
+func NewFake() *Fake {
+	f := new(Fake)
+
+	f.now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return f
+}
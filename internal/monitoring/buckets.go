@@ -0,0 +1,63 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"fmt"
+	"slices"
+)
+
+var (
+	// DefaultLatencyBuckets are the Prometheus default buckets, in seconds
+	DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets go from 100 bytes to 10 MB
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// Buckets holds the bucket boundaries of the latency and size histograms
+type Buckets struct {
+	Latency []float64
+	Size    []float64
+}
+
+// NewBuckets returns the given bucket boundaries, empty ones are replaced by
+// the defaults, boundaries must be positive and strictly increasing
+func NewBuckets(latency, size []float64) (*Buckets, error) {
+	b := new(Buckets)
+
+	b.Latency = DefaultLatencyBuckets
+	if len(latency) > 0 {
+		b.Latency = latency
+	}
+
+	b.Size = DefaultSizeBuckets
+	if len(size) > 0 {
+		b.Size = size
+	}
+
+	for _, buckets := range [][]float64{b.Latency, b.Size} {
+		if err := validateBuckets(buckets); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// DefaultBuckets returns the default bucket boundaries
+func DefaultBuckets() *Buckets {
+	return &Buckets{Latency: DefaultLatencyBuckets, Size: DefaultSizeBuckets}
+}
+
+func validateBuckets(buckets []float64) error {
+	if buckets[0] <= 0 {
+		return fmt.Errorf("invalid buckets %v, boundaries must be positive", buckets)
+	}
+
+	if !slices.IsSorted(buckets) || len(slices.Compact(slices.Clone(buckets))) != len(buckets) {
+		return fmt.Errorf("invalid buckets %v, boundaries must be strictly increasing", buckets)
+	}
+
+	return nil
+}
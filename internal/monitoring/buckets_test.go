@@ -0,0 +1,26 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNewBuckets(t *testing.T) {
+	buckets, err := NewBuckets(nil, []float64{10, 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(buckets.Latency, DefaultLatencyBuckets) || !slices.Equal(buckets.Size, []float64{10, 100}) {
+		t.Fatalf("unexpected buckets %v", buckets)
+	}
+
+	for _, invalid := range [][]float64{{0, 1}, {1, 1}, {2, 1}} {
+		if _, err := NewBuckets(invalid, nil); err == nil {
+			t.Fatalf("expected an error for %v", invalid)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Authentication funnel events, recorded with SetAuthEventMetric.
+const (
+	EventLogin                = "login"
+	EventMFARedirect          = "mfa_redirect"
+	EventVerificationRedirect = "verification_redirect"
+	EventTenantSelection      = "tenant_selection"
+	EventConsent              = "consent"
+	EventRegistration         = "registration"
+	EventRecovery             = "recovery"
+	EventDeviceCode           = "device_code"
+)
+
+// Outcomes of an authentication funnel event. Redirect events have no
+// outcome.
+const (
+	OutcomeSuccess  = "success"
+	OutcomeFailure  = "failure"
+	OutcomeRejected = "rejected"
+	OutcomeSkipped  = "skipped"
+)
+
+const (
+	// LabelNone is reported for an empty label value
+	LabelNone = "none"
+	// LabelOther is reported for a label value outside of the allowed set
+	LabelOther = "other"
+
+	// MaxClientLabelValues caps the number of distinct OAuth2 clients
+	// reported in the client label
+	MaxClientLabelValues = 100
+)
+
+var (
+	authEvents = []string{
+		EventLogin,
+		EventMFARedirect,
+		EventVerificationRedirect,
+		EventTenantSelection,
+		EventConsent,
+		EventRegistration,
+		EventRecovery,
+		EventDeviceCode,
+	}
+	authOutcomes = []string{OutcomeSuccess, OutcomeFailure, OutcomeRejected, OutcomeSkipped}
+	// authMethods are the kratos self-service methods
+	authMethods = []string{
+		"password",
+		"oidc",
+		"saml",
+		"code",
+		"totp",
+		"webauthn",
+		"passkey",
+		"lookup_secret",
+		"identifier_first",
+		"profile",
+	}
+)
+
+// AuthEventTags returns the labels of an authentication funnel event. Label
+// values are bounded: unknown events, methods and outcomes are reported as
+// LabelOther. The client label holds an OAuth2 client ID, it is capped by the
+// monitor with a LabelLimiter.
+func AuthEventTags(event, method, clientID, outcome string) map[string]string {
+	return map[string]string{
+		"event":   boundedLabel(event, authEvents),
+		"method":  boundedLabel(method, authMethods),
+		"client":  clientLabel(clientID),
+		"outcome": boundedLabel(outcome, authOutcomes),
+	}
+}
+
+// LoginDurationTags returns the labels of the duration of a successful login.
+func LoginDurationTags(method, clientID string) map[string]string {
+	return map[string]string{
+		"method": boundedLabel(method, authMethods),
+		"client": clientLabel(clientID),
+	}
+}
+
+func boundedLabel(value string, allowed []string) string {
+	if value == "" {
+		return LabelNone
+	}
+	if slices.Contains(allowed, value) {
+		return value
+	}
+	return LabelOther
+}
+
+func clientLabel(clientID string) string {
+	if clientID == "" {
+		return LabelNone
+	}
+	return clientID
+}
+
+// LabelLimiter caps the number of distinct values of a label, values first
+// seen after the limit is reached are reported as LabelOther.
+type LabelLimiter struct {
+	mu   sync.Mutex
+	max  int
+	seen map[string]struct{}
+}
+
+// Value returns value if it was seen before or the limit is not reached yet,
+// LabelOther otherwise.
+func (l *LabelLimiter) Value(value string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[value]; ok {
+		return value
+	}
+
+	if len(l.seen) >= l.max {
+		return LabelOther
+	}
+
+	l.seen[value] = struct{}{}
+	return value
+}
+
+// Bound returns a copy of tags with the value of label capped, tags are
+// returned untouched when they do not carry label.
+func (l *LabelLimiter) Bound(tags map[string]string, label string) map[string]string {
+	value, ok := tags[label]
+	if !ok {
+		return tags
+	}
+
+	bounded := maps.Clone(tags)
+	bounded[label] = l.Value(value)
+	return bounded
+}
+
+func NewLabelLimiter(max int) *LabelLimiter {
+	return &LabelLimiter{
+		max:  max,
+		seen: make(map[string]struct{}),
+	}
+}
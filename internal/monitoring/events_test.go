@@ -0,0 +1,80 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestAuthEventTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		method   string
+		client   string
+		outcome  string
+		expected map[string]string
+	}{
+		{
+			name:     "known values",
+			event:    EventLogin,
+			method:   "password",
+			client:   "client-1",
+			outcome:  OutcomeSuccess,
+			expected: map[string]string{"event": "login", "method": "password", "client": "client-1", "outcome": "success"},
+		},
+		{
+			name:     "empty values",
+			event:    EventVerificationRedirect,
+			expected: map[string]string{"event": "verification_redirect", "method": "none", "client": "none", "outcome": "none"},
+		},
+		{
+			name:     "unknown values",
+			event:    "logout",
+			method:   "magic_link",
+			client:   "client-1",
+			outcome:  "maybe",
+			expected: map[string]string{"event": "other", "method": "other", "client": "client-1", "outcome": "other"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tags := AuthEventTags(test.event, test.method, test.client, test.outcome)
+			if !reflect.DeepEqual(tags, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, tags)
+			}
+		})
+	}
+}
+
+func TestLoginDurationTags(t *testing.T) {
+	tags := LoginDurationTags("webauthn", "")
+	expected := map[string]string{"method": "webauthn", "client": "none"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Fatalf("expected %v, got %v", expected, tags)
+	}
+}
+
+func TestLabelLimiter(t *testing.T) {
+	l := NewLabelLimiter(2)
+
+	for i := 0; i < 2; i++ {
+		value := fmt.Sprintf("client-%d", i)
+		if v := l.Value(value); v != value {
+			t.Fatalf("expected %s, got %s", value, v)
+		}
+	}
+
+	if v := l.Value("client-2"); v != LabelOther {
+		t.Fatalf("expected %s, got %s", LabelOther, v)
+	}
+
+	// values seen before the limit was reached are kept
+	if v := l.Value("client-0"); v != "client-0" {
+		t.Fatalf("expected client-0, got %s", v)
+	}
+}
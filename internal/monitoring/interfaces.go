@@ -3,8 +3,30 @@
 
 package monitoring
 
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
 type MonitorInterface interface {
 	GetService() string
-	SetResponseTimeMetric(map[string]string, float64) error
+	SetResponseTimeMetric(context.Context, map[string]string, float64) error
+	SetRequestSizeMetric(map[string]string, float64) error
+	SetResponseSizeMetric(map[string]string, float64) error
+	SetInFlightRequestsMetric(map[string]string, float64) error
 	SetDependencyAvailability(map[string]string, float64) error
+	SetCacheLookupMetric(map[string]string, float64) error
+	SetOutboundRequestMetric(map[string]string, float64) error
+	SetOutboundStatusMetric(map[string]string, float64) error
+	SetOutboundRetryMetric(map[string]string, float64) error
+	SetOutboundInFlightMetric(map[string]string, float64) error
+	SetAuthEventMetric(map[string]string, float64) error
+	SetLoginDurationMetric(map[string]string, float64) error
+}
+
+// TracerInterface is tracing.TracingInterface, which cannot be imported here
+// as the tracing package depends on this one.
+type TracerInterface interface {
+	Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span)
 }
@@ -5,29 +5,32 @@ package monitoring
 
 import (
 	"fmt"
+	"io"
 	"net/http"
-	"regexp"
 	"time"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
-	// IDPathRegex regexp used to swap the {id*} parameters in the path with simply id
-	// supports alphabetic characters and underscores, no dashes
-	IDPathRegex string = "{[a-zA-Z_]*}"
+	// UnmatchedRoute is the route label of the requests not matching any
+	// route, it keeps unknown paths and methods from creating new series
+	UnmatchedRoute string = "unmatched"
 )
 
 // Middleware is the monitoring middleware object implementing Prometheus monitoring
 type Middleware struct {
 	service string
-	regex   *regexp.Regexp
 
 	monitor MonitorInterface
 	logger  logging.LoggerInterface
 }
 
+// ResponseTime records the response time and the request and response sizes
+// by route and status, and the number of requests being served. It must be
+// used on a chi router, the route label is the pattern of the matched route
 func (mdw *Middleware) ResponseTime() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(
@@ -35,19 +38,85 @@ func (mdw *Middleware) ResponseTime() func(http.Handler) http.Handler {
 				ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 				startTime := time.Now()
 
+				mdw.inFlight(1)
+				defer mdw.inFlight(-1)
+
+				var body *countingReader
+				if r.Body != nil && r.Body != http.NoBody {
+					body = &countingReader{ReadCloser: r.Body}
+					r.Body = body
+				}
+
 				next.ServeHTTP(ww, r)
 
 				tags := map[string]string{
-					"route":  fmt.Sprintf("%s%s", r.Method, mdw.regex.ReplaceAll([]byte(r.URL.Path), []byte("id"))),
+					"route":  routeLabel(r),
 					"status": fmt.Sprint(ww.Status()),
 				}
 
-				mdw.monitor.SetResponseTimeMetric(tags, time.Since(startTime).Seconds())
+				mdw.monitor.SetResponseTimeMetric(r.Context(), tags, time.Since(startTime).Seconds())
+
+				if err := mdw.monitor.SetRequestSizeMetric(tags, float64(requestSize(r, body))); err != nil {
+					mdw.logger.Debugf("cannot record request size metric: %v", err)
+				}
+
+				if err := mdw.monitor.SetResponseSizeMetric(tags, float64(ww.BytesWritten())); err != nil {
+					mdw.logger.Debugf("cannot record response size metric: %v", err)
+				}
 			},
 		)
 	}
 }
 
+func (mdw *Middleware) inFlight(delta float64) {
+	if err := mdw.monitor.SetInFlightRequestsMetric(map[string]string{}, delta); err != nil {
+		mdw.logger.Debugf("cannot record in-flight requests metric: %v", err)
+	}
+}
+
+// routeLabel returns the method and pattern of the route chi matched, it is
+// only known once the request has been routed
+func routeLabel(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return UnmatchedRoute
+	}
+
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		return UnmatchedRoute
+	}
+
+	return fmt.Sprintf("%s%s", r.Method, pattern)
+}
+
+// requestSize returns the announced length of the body, or the bytes read by
+// the handler when the body is chunked
+func requestSize(r *http.Request, body *countingReader) int64 {
+	if r.ContentLength >= 0 {
+		return r.ContentLength
+	}
+
+	if body == nil {
+		return 0
+	}
+
+	return body.n
+}
+
+type countingReader struct {
+	io.ReadCloser
+
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
 // NewMiddleware returns a Middleware based on the type of monitor
 func NewMiddleware(monitor MonitorInterface, logger logging.LoggerInterface) *Middleware {
 	mdw := new(Middleware)
@@ -56,7 +125,6 @@ func NewMiddleware(monitor MonitorInterface, logger logging.LoggerInterface) *Mi
 
 	mdw.service = monitor.GetService()
 	mdw.logger = logger
-	mdw.regex = regexp.MustCompile(IDPathRegex)
 
 	return mdw
 }
@@ -4,8 +4,10 @@
 package monitoring
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -21,6 +23,7 @@ type API struct{}
 func (a *API) RegisterEndpoints(router *chi.Mux) {
 	router.Get("/api/v1/metrics", a.prometheusHTTP)
 	router.Get("/api/test", a.test)
+	router.Post("/api/items/{id}", a.echo)
 }
 
 func (a *API) prometheusHTTP(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +35,10 @@ func (a *API) test(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (a *API) echo(w http.ResponseWriter, r *http.Request) {
+	io.Copy(w, r.Body)
+}
+
 func TestMiddlewareResponseTime(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -44,7 +51,11 @@ func TestMiddlewareResponseTime(t *testing.T) {
 	mockMonitor := NewMockMonitorInterface(ctrl)
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockMonitor.EXPECT().GetService().Times(1)
-	mockMonitor.EXPECT().SetResponseTimeMetric(tags, gomock.Any()).Times(1).Return(nil)
+	mockMonitor.EXPECT().SetResponseTimeMetric(gomock.Any(), tags, gomock.Any()).Times(1).Return(nil)
+	mockMonitor.EXPECT().SetRequestSizeMetric(tags, float64(0)).Times(1).Return(nil)
+	mockMonitor.EXPECT().SetResponseSizeMetric(tags, float64(0)).Times(1).Return(nil)
+	mockMonitor.EXPECT().SetInFlightRequestsMetric(map[string]string{}, float64(1)).Times(1).Return(nil)
+	mockMonitor.EXPECT().SetInFlightRequestsMetric(map[string]string{}, float64(-1)).Times(1).Return(nil)
 
 	router := chi.NewMux()
 
@@ -63,3 +74,53 @@ func TestMiddlewareResponseTime(t *testing.T) {
 
 	router.ServeHTTP(rr, req)
 }
+
+func TestMiddlewareRoutePattern(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		chunked      bool
+		route        string
+		status       string
+		requestSize  float64
+		responseSize float64
+	}{
+		{name: "route parameter", method: http.MethodPost, path: "/api/items/42", body: "hello", route: "POST/api/items/{id}", status: "200", requestSize: 5, responseSize: 5},
+		{name: "chunked body", method: http.MethodPost, path: "/api/items/42", body: "hello world", chunked: true, route: "POST/api/items/{id}", status: "200", requestSize: 11, responseSize: 11},
+		{name: "unknown path", method: http.MethodGet, path: "/api/items/42/unknown", route: UnmatchedRoute, status: "404", responseSize: 19},
+		{name: "unknown method", method: "FOO", path: "/api/test", route: UnmatchedRoute, status: "405"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tags := map[string]string{
+				"route":  test.route,
+				"status": test.status,
+			}
+
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockMonitor.EXPECT().GetService().Times(1)
+			mockMonitor.EXPECT().SetResponseTimeMetric(gomock.Any(), tags, gomock.Any()).Times(1).Return(nil)
+			mockMonitor.EXPECT().SetRequestSizeMetric(tags, test.requestSize).Times(1).Return(nil)
+			mockMonitor.EXPECT().SetResponseSizeMetric(tags, test.responseSize).Times(1).Return(nil)
+			mockMonitor.EXPECT().SetInFlightRequestsMetric(map[string]string{}, gomock.Any()).Times(2).Return(nil)
+
+			router := chi.NewMux()
+			router.Use(NewMiddleware(mockMonitor, mockLogger).ResponseTime())
+			new(API).RegisterEndpoints(router)
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+)
+
+// MultiMonitor fans out every measurement to a list of monitors, it lets the
+// Prometheus scrape endpoint live alongside an OTLP push exporter.
+type MultiMonitor struct {
+	service string
+
+	monitors []MonitorInterface
+}
+
+func (m *MultiMonitor) GetService() string {
+	return m.service
+}
+
+func (m *MultiMonitor) SetResponseTimeMetric(ctx context.Context, tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetResponseTimeMetric(ctx, tags, value) })
+}
+
+func (m *MultiMonitor) SetRequestSizeMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetRequestSizeMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetResponseSizeMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetResponseSizeMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetInFlightRequestsMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetInFlightRequestsMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetDependencyAvailability(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetDependencyAvailability(tags, value) })
+}
+
+func (m *MultiMonitor) SetCacheLookupMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetCacheLookupMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetOutboundRequestMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetOutboundRequestMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetOutboundStatusMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetOutboundStatusMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetOutboundRetryMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetOutboundRetryMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetOutboundInFlightMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetOutboundInFlightMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetAuthEventMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetAuthEventMetric(tags, value) })
+}
+
+func (m *MultiMonitor) SetLoginDurationMetric(tags map[string]string, value float64) error {
+	return m.each(func(monitor MonitorInterface) error { return monitor.SetLoginDurationMetric(tags, value) })
+}
+
+func (m *MultiMonitor) each(set func(MonitorInterface) error) error {
+	var errs []error
+
+	for _, monitor := range m.monitors {
+		if err := set(monitor); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func NewMultiMonitor(service string, monitors ...MonitorInterface) *MultiMonitor {
+	m := new(MultiMonitor)
+	m.service = service
+	m.monitors = monitors
+	return m
+}
@@ -0,0 +1,36 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestMultiMonitorFansOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tags := map[string]string{"route": "GET/api/test", "status": "200"}
+	ctx := context.Background()
+
+	first := NewMockMonitorInterface(ctrl)
+	second := NewMockMonitorInterface(ctrl)
+
+	first.EXPECT().SetResponseTimeMetric(ctx, tags, 0.5).Times(1).Return(errors.New("boom"))
+	second.EXPECT().SetResponseTimeMetric(ctx, tags, 0.5).Times(1).Return(nil)
+
+	monitor := NewMultiMonitor("test", first, second)
+
+	if monitor.GetService() != "test" {
+		t.Fatalf("expected service test, got %s", monitor.GetService())
+	}
+
+	if err := monitor.SetResponseTimeMetric(ctx, tags, 0.5); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected the first monitor error, got %v", err)
+	}
+}
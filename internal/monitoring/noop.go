@@ -4,6 +4,8 @@
 package monitoring
 
 import (
+	"context"
+
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
 )
 
@@ -23,9 +25,39 @@ func NewNoopMonitor(service string, logger logging.LoggerInterface) *NoopMonitor
 func (m *NoopMonitor) GetService() string {
 	return m.service
 }
-func (m *NoopMonitor) SetResponseTimeMetric(map[string]string, float64) error {
+func (m *NoopMonitor) SetResponseTimeMetric(context.Context, map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetRequestSizeMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetResponseSizeMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetInFlightRequestsMetric(map[string]string, float64) error {
 	return nil
 }
 func (m *NoopMonitor) SetDependencyAvailability(map[string]string, float64) error {
 	return nil
 }
+func (m *NoopMonitor) SetCacheLookupMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetOutboundRequestMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetOutboundStatusMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetOutboundRetryMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetOutboundInFlightMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetAuthEventMetric(map[string]string, float64) error {
+	return nil
+}
+func (m *NoopMonitor) SetLoginDurationMetric(map[string]string, float64) error {
+	return nil
+}
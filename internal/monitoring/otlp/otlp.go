@@ -0,0 +1,364 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"google.golang.org/grpc/credentials"
+)
+
+type Config struct {
+	OtelGRPCEndpoint string
+	OtelHTTPEndpoint string
+	Exporter         tracing.ExporterConfig
+	Resource         tracing.ResourceConfig
+	Interval         time.Duration
+}
+
+func NewConfig(otelGRPCEndpoint, otelHTTPEndpoint string, exporter tracing.ExporterConfig, res tracing.ResourceConfig, interval time.Duration) *Config {
+	c := new(Config)
+
+	c.OtelGRPCEndpoint = otelGRPCEndpoint
+	c.OtelHTTPEndpoint = otelHTTPEndpoint
+	c.Exporter = exporter
+	c.Resource = res
+	c.Interval = interval
+
+	return c
+}
+
+// Monitor pushes the same instruments as the Prometheus monitor to an OTLP
+// collector, the response time histogram carries trace IDs as exemplars
+type Monitor struct {
+	service string
+
+	provider *sdkmetric.MeterProvider
+
+	responseTime           metric.Float64Histogram
+	requestSize            metric.Float64Histogram
+	responseSize           metric.Float64Histogram
+	inFlightRequests       metric.Float64UpDownCounter
+	dependencyAvailability metric.Float64Gauge
+	cacheLookups           metric.Float64Counter
+	outboundRequests       metric.Float64Histogram
+	outboundStatuses       metric.Float64Counter
+	outboundRetries        metric.Float64Counter
+	outboundInFlight       metric.Float64UpDownCounter
+	authEvents             metric.Float64Counter
+	loginDuration          metric.Float64Histogram
+
+	// clients caps the distinct values of the client label
+	clients *monitoring.LabelLimiter
+	// buckets replace the OTel default buckets, tailored to milliseconds
+	buckets *monitoring.Buckets
+
+	logger logging.LoggerInterface
+}
+
+func (m *Monitor) GetService() string {
+	return m.service
+}
+
+// SetResponseTimeMetric records the response time, the SDK attaches the trace
+// ID of the sampled request span found in ctx as an exemplar
+func (m *Monitor) SetResponseTimeMetric(ctx context.Context, tags map[string]string, value float64) error {
+	if m.responseTime == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.responseTime.Record(ctx, value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetRequestSizeMetric(tags map[string]string, value float64) error {
+	if m.requestSize == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.requestSize.Record(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetResponseSizeMetric(tags map[string]string, value float64) error {
+	if m.responseSize == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.responseSize.Record(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+// SetInFlightRequestsMetric adds value to the number of requests being
+// served, it is called with 1 when a request starts and -1 when it ends.
+func (m *Monitor) SetInFlightRequestsMetric(tags map[string]string, value float64) error {
+	if m.inFlightRequests == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.inFlightRequests.Add(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetDependencyAvailability(tags map[string]string, value float64) error {
+	if m.dependencyAvailability == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.dependencyAvailability.Record(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetCacheLookupMetric(tags map[string]string, value float64) error {
+	if m.cacheLookups == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.cacheLookups.Add(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundRequestMetric(tags map[string]string, value float64) error {
+	if m.outboundRequests == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundRequests.Record(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundStatusMetric(tags map[string]string, value float64) error {
+	if m.outboundStatuses == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundStatuses.Add(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundRetryMetric(tags map[string]string, value float64) error {
+	if m.outboundRetries == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundRetries.Add(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+// SetOutboundInFlightMetric adds value to the number of in-flight calls, it
+// is called with 1 when a call starts and -1 when it ends.
+func (m *Monitor) SetOutboundInFlightMetric(tags map[string]string, value float64) error {
+	if m.outboundInFlight == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundInFlight.Add(context.Background(), value, attributes(tags))
+
+	return nil
+}
+
+func (m *Monitor) SetAuthEventMetric(tags map[string]string, value float64) error {
+	if m.authEvents == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.authEvents.Add(context.Background(), value, attributes(m.clients.Bound(tags, "client")))
+
+	return nil
+}
+
+func (m *Monitor) SetLoginDurationMetric(tags map[string]string, value float64) error {
+	if m.loginDuration == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.loginDuration.Record(context.Background(), value, attributes(m.clients.Bound(tags, "client")))
+
+	return nil
+}
+
+// Shutdown pushes the pending data points and stops the exporter
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	return m.provider.Shutdown(ctx)
+}
+
+func (m *Monitor) registerInstruments(meter metric.Meter) error {
+	var err error
+
+	if m.responseTime, err = meter.Float64Histogram(
+		"http_response_time_seconds",
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(m.buckets.Latency...),
+	); err != nil {
+		return err
+	}
+
+	if m.requestSize, err = meter.Float64Histogram(
+		"http_request_size_bytes",
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(m.buckets.Size...),
+	); err != nil {
+		return err
+	}
+
+	if m.responseSize, err = meter.Float64Histogram(
+		"http_response_size_bytes",
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(m.buckets.Size...),
+	); err != nil {
+		return err
+	}
+
+	if m.outboundRequests, err = meter.Float64Histogram(
+		"outbound_request_duration_seconds",
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(m.buckets.Latency...),
+	); err != nil {
+		return err
+	}
+
+	if m.loginDuration, err = meter.Float64Histogram(
+		"auth_login_duration_seconds",
+		metric.WithUnit("s"),
+		// from the creation of the login flow to its completion
+		metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 20, 30, 60, 120, 300, 600),
+	); err != nil {
+		return err
+	}
+
+	if m.dependencyAvailability, err = meter.Float64Gauge("dependency_available"); err != nil {
+		return err
+	}
+
+	if m.inFlightRequests, err = meter.Float64UpDownCounter("http_requests_in_flight"); err != nil {
+		return err
+	}
+
+	if m.outboundInFlight, err = meter.Float64UpDownCounter("outbound_requests_in_flight"); err != nil {
+		return err
+	}
+
+	if m.cacheLookups, err = meter.Float64Counter("cache_lookups_total"); err != nil {
+		return err
+	}
+
+	if m.authEvents, err = meter.Float64Counter("auth_events_total"); err != nil {
+		return err
+	}
+
+	if m.outboundStatuses, err = meter.Float64Counter("outbound_requests_total"); err != nil {
+		return err
+	}
+
+	if m.outboundRetries, err = meter.Float64Counter("outbound_retries_total"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func attributes(tags map[string]string) metric.MeasurementOption {
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return metric.WithAttributes(attrs...)
+}
+
+func newExporter(cfg *Config) (sdkmetric.Exporter, error) {
+	switch {
+	case cfg.OtelGRPCEndpoint != "":
+		opts := []otlpmetricgrpc.Option{
+			otlpmetricgrpc.WithEndpoint(cfg.OtelGRPCEndpoint),
+			otlpmetricgrpc.WithHeaders(cfg.Exporter.Headers),
+		}
+
+		if cfg.Exporter.TLSEnabled {
+			tlsCfg, err := cfg.Exporter.TLSConfig()
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		}
+
+		return otlpmetricgrpc.New(context.Background(), opts...)
+	case cfg.OtelHTTPEndpoint != "":
+		opts := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(cfg.OtelHTTPEndpoint),
+			otlpmetrichttp.WithHeaders(cfg.Exporter.Headers),
+		}
+
+		if cfg.Exporter.TLSEnabled {
+			tlsCfg, err := cfg.Exporter.TLSConfig()
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+		} else {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+
+		return otlpmetrichttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("no OTLP endpoint configured")
+	}
+}
+
+// NewMonitor builds an OTLP monitor exporting through the configured endpoint
+func NewMonitor(service string, cfg *Config, buckets *monitoring.Buckets, logger logging.LoggerInterface) (*Monitor, error) {
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize metrics exporter: %w", err)
+	}
+
+	return NewMonitorWithReader(service, sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.Interval)), cfg.Resource, buckets, logger)
+}
+
+// NewMonitorWithReader builds an OTLP monitor collected by reader
+func NewMonitorWithReader(service string, reader sdkmetric.Reader, res tracing.ResourceConfig, buckets *monitoring.Buckets, logger logging.LoggerInterface) (*Monitor, error) {
+	m := new(Monitor)
+
+	m.service = service
+	m.logger = logger
+	m.clients = monitoring.NewLabelLimiter(monitoring.MaxClientLabelValues)
+	m.buckets = buckets
+
+	m.provider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(tracing.NewResource(tracing.ServiceName, res)),
+		sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
+	)
+
+	if err := m.registerInstruments(m.provider.Meter(tracing.ServiceName)); err != nil {
+		return nil, fmt.Errorf("unable to register metrics: %w", err)
+	}
+
+	return m, nil
+}
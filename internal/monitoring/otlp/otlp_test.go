@@ -0,0 +1,130 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader, name string) metricdata.Aggregation {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("unexpected collect error: %v", err)
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data
+			}
+		}
+	}
+
+	t.Fatalf("metric %s not found", name)
+	return nil
+}
+
+func TestSetResponseTimeMetricExemplar(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	monitor, err := NewMonitorWithReader("test", reader, tracing.ResourceConfig{}, monitoring.DefaultBuckets(), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	tags := map[string]string{"route": "GET/api/test", "status": "200"}
+	if err := monitor.SetResponseTimeMetric(ctx, tags, 0.2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	histogram, ok := collect(t, reader, "http_response_time_seconds").(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 1 {
+		t.Fatalf("expected a single histogram data point, got %v", histogram)
+	}
+
+	dp := histogram.DataPoints[0]
+	if dp.Count != 1 {
+		t.Fatalf("expected count 1, got %d", dp.Count)
+	}
+
+	if route, _ := dp.Attributes.Value("route"); route.AsString() != "GET/api/test" {
+		t.Fatalf("expected route attribute, got %v", dp.Attributes)
+	}
+
+	traceID := span.SpanContext().TraceID()
+	if len(dp.Exemplars) != 1 || string(dp.Exemplars[0].TraceID) != string(traceID[:]) {
+		t.Fatalf("expected an exemplar for trace %s, got %v", traceID, dp.Exemplars)
+	}
+}
+
+func TestSetAuthEventMetricBoundsClient(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	monitor, err := NewMonitorWithReader("test", reader, tracing.ResourceConfig{}, monitoring.DefaultBuckets(), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < monitoring.MaxClientLabelValues; i++ {
+		monitor.clients.Value(fmt.Sprintf("client-%d", i))
+	}
+
+	if err := monitor.SetAuthEventMetric(map[string]string{"event": "login", "client": "unseen"}, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sum, ok := collect(t, reader, "auth_events_total").(metricdata.Sum[float64])
+	if !ok || len(sum.DataPoints) != 1 {
+		t.Fatalf("expected a single sum data point, got %v", sum)
+	}
+
+	if client, _ := sum.DataPoints[0].Attributes.Value("client"); client.AsString() != "other" {
+		t.Fatalf("expected client label to be capped, got %s", client.AsString())
+	}
+}
+
+func TestNewMonitorWithoutEndpoint(t *testing.T) {
+	if _, err := NewMonitor("test", NewConfig("", "", tracing.ExporterConfig{}, tracing.ResourceConfig{}, 0), monitoring.DefaultBuckets(), logging.NewNoopLogger()); err == nil {
+		t.Fatal("expected an error without an OTLP endpoint")
+	}
+}
+
+func TestSetRequestSizeMetricBuckets(t *testing.T) {
+	buckets, err := monitoring.NewBuckets(nil, []float64{10, 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader := sdkmetric.NewManualReader()
+	monitor, err := NewMonitorWithReader("test", reader, tracing.ResourceConfig{}, buckets, logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := monitor.SetRequestSizeMetric(map[string]string{"route": "POST/api/test", "status": "200"}, 50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	histogram, ok := collect(t, reader, "http_request_size_bytes").(metricdata.Histogram[float64])
+	if !ok || len(histogram.DataPoints) != 1 {
+		t.Fatalf("expected a single histogram data point, got %v", histogram)
+	}
+
+	dp := histogram.DataPoints[0]
+	if len(dp.Bounds) != 2 || dp.BucketCounts[1] != 1 {
+		t.Fatalf("expected the value in the second of the configured buckets, got %v %v", dp.Bounds, dp.BucketCounts)
+	}
+}
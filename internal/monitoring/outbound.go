@@ -0,0 +1,240 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+)
+
+const (
+	// StatusClassError is the status class of a call that got no response
+	StatusClassError = "error"
+	// StatusClassCircuitOpen is the status class of a call rejected by a
+	// circuit breaker before being sent
+	StatusClassCircuitOpen = "circuit_open"
+
+	// maxEndpointLabelValues caps the distinct endpoints reported per client
+	maxEndpointLabelValues = 50
+)
+
+// outboundObserver records the metrics of the calls sent to a dependency.
+type outboundObserver struct {
+	client string
+
+	monitor MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (o *outboundObserver) inFlight(delta float64) {
+	if err := o.monitor.SetOutboundInFlightMetric(map[string]string{"client": o.client}, delta); err != nil {
+		o.logger.Debugf("cannot record %s in-flight metric: %v", o.client, err)
+	}
+}
+
+func (o *outboundObserver) observe(method, code, class string, start time.Time) {
+	if err := o.monitor.SetOutboundRequestMetric(map[string]string{"client": o.client, "method": method, "code": code}, time.Since(start).Seconds()); err != nil {
+		o.logger.Debugf("cannot record %s call metric: %v", o.client, err)
+	}
+
+	if err := o.monitor.SetOutboundStatusMetric(map[string]string{"client": o.client, "method": method, "class": class}, 1); err != nil {
+		o.logger.Debugf("cannot record %s status metric: %v", o.client, err)
+	}
+}
+
+// Transport is an http.RoundTripper recording the latency, the status class
+// and the number of in-flight requests sent to a dependency, per endpoint.
+type Transport struct {
+	next      http.RoundTripper
+	endpoints *LabelLimiter
+	observer  *outboundObserver
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	endpoint := t.endpoints.Value(endpointLabel(r))
+
+	t.observer.inFlight(1)
+	defer t.observer.inFlight(-1)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(r)
+	if err != nil {
+		t.observer.observe(endpoint, StatusClassError, StatusClassError, start)
+		return nil, err
+	}
+
+	t.observer.observe(endpoint, strconv.Itoa(resp.StatusCode), httpStatusClass(resp.StatusCode), start)
+	return resp, nil
+}
+
+// NewTransport instruments next for the calls sent to the dependency named
+// client. The returned transport also traces the requests and propagates the
+// trace context in their headers.
+func NewTransport(client string, next http.RoundTripper, monitor MonitorInterface, logger logging.LoggerInterface) http.RoundTripper {
+	t := &Transport{
+		next:      next,
+		endpoints: NewLabelLimiter(maxEndpointLabelValues),
+		observer:  &outboundObserver{client: client, monitor: monitor, logger: logger},
+	}
+
+	return otelhttp.NewTransport(t)
+}
+
+// endpointLabel returns the method and the path of r, path segments holding
+// an ID are replaced to keep the number of endpoints bounded.
+func endpointLabel(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if isIDSegment(segment) {
+			segments[i] = "{id}"
+		}
+	}
+
+	return r.Method + " " + strings.Join(segments, "/")
+}
+
+// isIDSegment reports whether a path segment looks like an ID, a number, an
+// UUID, an ULID or a long hex string, rather than a name such as oauth2 or v1.
+func isIDSegment(segment string) bool {
+	switch {
+	case segment == "":
+		return false
+	case isAll(segment, unicode.IsDigit):
+		return true
+	case len(segment) == 36:
+		for i, c := range segment {
+			if i == 8 || i == 13 || i == 18 || i == 23 {
+				if c != '-' {
+					return false
+				}
+			} else if !isHexDigit(c) {
+				return false
+			}
+		}
+		return true
+	case len(segment) == 26 && segment[0] >= '0' && segment[0] <= '7':
+		return isAll(strings.ToUpper(segment), isCrockfordDigit)
+	case len(segment) >= 16:
+		return isAll(segment, isHexDigit)
+	}
+
+	return false
+}
+
+func isAll(s string, f func(rune) bool) bool {
+	return strings.IndexFunc(s, func(c rune) bool { return !f(c) }) < 0
+}
+
+func isHexDigit(c rune) bool {
+	return unicode.Is(unicode.ASCII_Hex_Digit, c)
+}
+
+// isCrockfordDigit reports whether c is in the base32 alphabet of ULIDs.
+func isCrockfordDigit(c rune) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z' && !strings.ContainsRune("ILOU", c))
+}
+
+func httpStatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return StatusClassError
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// UnaryClientInterceptor instruments the unary calls of a gRPC connection to
+// the dependency named client, like Transport does for HTTP. Every call is
+// traced and its trace context is propagated in the call metadata.
+func UnaryClientInterceptor(client string, tracer TracerInterface, monitor MonitorInterface, logger logging.LoggerInterface) grpc.UnaryClientInterceptor {
+	o := &outboundObserver{client: client, monitor: monitor, logger: logger}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		o.inFlight(1)
+		defer o.inFlight(-1)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		code := status.Code(err)
+		o.observe(grpcMethodName(method), code.String(), grpcStatusClass(code), start)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+			return err
+		}
+
+		span.SetStatus(otelcodes.Ok, "")
+		return nil
+	}
+}
+
+// grpcMethodName returns the method of a full gRPC method name, e.g.
+// LookupTenants for /tenant.v0.TenantService/LookupTenants.
+func grpcMethodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// grpcStatusClass maps a gRPC status code to the HTTP status class of the
+// same meaning, so that HTTP and gRPC dependencies share their error classes.
+func grpcStatusClass(code codes.Code) string {
+	switch code {
+	case codes.OK:
+		return "2xx"
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.FailedPrecondition, codes.OutOfRange, codes.Canceled:
+		return "4xx"
+	default:
+		return "5xx"
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to an OpenTelemetry TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// withTraceContext sets the W3C propagator for the duration of the test and
+// returns a context holding a sampled span.
+func withTraceContext(t *testing.T) (context.Context, trace.SpanContext) {
+	t.Helper()
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05, 0x06},
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc), sc
+}
+
+func expectInFlight(monitor *MockMonitorInterface, client string) {
+	monitor.EXPECT().SetOutboundInFlightMetric(map[string]string{"client": client}, 1.0).Return(nil)
+	monitor.EXPECT().SetOutboundInFlightMetric(map[string]string{"client": client}, -1.0).Return(nil)
+}
+
+func TestTransport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, sc := withTraceContext(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	expectInFlight(mockMonitor, "kratos")
+	mockMonitor.EXPECT().SetOutboundRequestMetric(map[string]string{"client": "kratos", "method": "GET /admin/identities/{id}", "code": "404"}, gomock.Any()).Return(nil)
+	mockMonitor.EXPECT().SetOutboundStatusMetric(map[string]string{"client": "kratos", "method": "GET /admin/identities/{id}", "class": "4xx"}, 1.0).Return(nil)
+
+	client := &http.Client{Transport: NewTransport("kratos", http.DefaultTransport, mockMonitor, NewMockLoggerInterface(ctrl))}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/admin/identities/0b0c6a6e-5c1f-4a53-a0a5-14a0b2b5e1a0", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(traceparent, sc.TraceID().String()) {
+		t.Fatalf("expected the trace context to be propagated, got %q", traceparent)
+	}
+}
+
+func TestTransportConnectionError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	expectInFlight(mockMonitor, "hydra")
+	mockMonitor.EXPECT().SetOutboundRequestMetric(map[string]string{"client": "hydra", "method": "GET /health/ready", "code": StatusClassError}, gomock.Any()).Return(nil)
+	mockMonitor.EXPECT().SetOutboundStatusMetric(map[string]string{"client": "hydra", "method": "GET /health/ready", "class": StatusClassError}, 1.0).Return(nil)
+
+	client := &http.Client{Transport: NewTransport("hydra", http.DefaultTransport, mockMonitor, NewMockLoggerInterface(ctrl))}
+
+	if _, err := client.Get(server.URL + "/health/ready"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{method: http.MethodGet, path: "/sessions/whoami", expected: "GET /sessions/whoami"},
+		{method: http.MethodPost, path: "/stores/01HVMMBCMGZNT3SED4Z17ECXCA/check", expected: "POST /stores/{id}/check"},
+		{method: http.MethodGet, path: "/self-service/login/flows", expected: "GET /self-service/login/flows"},
+		{method: http.MethodGet, path: "/admin/identities/0b0c6a6e-5c1f-4a53-a0a5-14a0b2b5e1a0", expected: "GET /admin/identities/{id}"},
+		{method: http.MethodGet, path: "/admin/oauth2/auth/requests/login", expected: "GET /admin/oauth2/auth/requests/login"},
+		{method: http.MethodPut, path: "/admin/oauth2/auth/requests/login/accept", expected: "PUT /admin/oauth2/auth/requests/login/accept"},
+		{method: http.MethodGet, path: "/admin/clients/0b0c6a6e-5c1f-4a53-a0a5-14a0b2b5e1a0", expected: "GET /admin/clients/{id}"},
+		{method: http.MethodGet, path: "/api/v1/items/42", expected: "GET /api/v1/items/{id}"},
+		{method: http.MethodGet, path: "/admin/sessions/3f2a9c1b7d4e8f60a1b2", expected: "GET /admin/sessions/{id}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "http://example.com"+test.path+"?id=123", nil)
+			if label := endpointLabel(r); label != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, label)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, sc := withTraceContext(t)
+
+	mockTracer := NewMockTracerInterface(ctrl)
+	mockTracer.EXPECT().Start(gomock.Any(), "/tenant.v0.TenantService/LookupTenants", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+			return ctx, trace.SpanFromContext(ctx)
+		},
+	)
+
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	expectInFlight(mockMonitor, "tenant-service")
+	mockMonitor.EXPECT().SetOutboundRequestMetric(map[string]string{"client": "tenant-service", "method": "LookupTenants", "code": "Unavailable"}, gomock.Any()).Return(nil)
+	mockMonitor.EXPECT().SetOutboundStatusMetric(map[string]string{"client": "tenant-service", "method": "LookupTenants", "class": "5xx"}, 1.0).Return(nil)
+
+	interceptor := UnaryClientInterceptor("tenant-service", mockTracer, mockMonitor, NewMockLoggerInterface(ctrl))
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer token")
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	if err := interceptor(ctx, "/tenant.v0.TenantService/LookupTenants", nil, nil, nil, invoker); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the call error, got %v", err)
+	}
+
+	if values := md.Get("traceparent"); len(values) != 1 || !strings.Contains(values[0], sc.TraceID().String()) {
+		t.Fatalf("expected the trace context to be propagated, got %v", values)
+	}
+	if values := md.Get("authorization"); len(values) != 1 {
+		t.Fatalf("expected the existing metadata to be kept, got %v", md)
+	}
+}
+
+func TestGRPCStatusClass(t *testing.T) {
+	tests := map[codes.Code]string{
+		codes.OK:               "2xx",
+		codes.NotFound:         "4xx",
+		codes.Unauthenticated:  "4xx",
+		codes.Unavailable:      "5xx",
+		codes.DeadlineExceeded: "5xx",
+		codes.Internal:         "5xx",
+	}
+
+	for code, expected := range tests {
+		if class := grpcStatusClass(code); class != expected {
+			t.Errorf("expected %s for %s, got %s", expected, code, class)
+		}
+	}
+}
@@ -4,17 +4,34 @@
 package prometheus
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Monitor struct {
 	service string
 
 	responseTime           *prometheus.HistogramVec
+	requestSize            *prometheus.HistogramVec
+	responseSize           *prometheus.HistogramVec
+	inFlightRequests       *prometheus.GaugeVec
 	dependencyAvailability *prometheus.GaugeVec
+	cacheLookups           *prometheus.CounterVec
+	outboundRequests       *prometheus.HistogramVec
+	outboundStatuses       *prometheus.CounterVec
+	outboundRetries        *prometheus.CounterVec
+	outboundInFlight       *prometheus.GaugeVec
+	authEvents             *prometheus.CounterVec
+	loginDuration          *prometheus.HistogramVec
+
+	// clients caps the distinct values of the client label
+	clients *monitoring.LabelLimiter
+	buckets *monitoring.Buckets
 
 	logger logging.LoggerInterface
 }
@@ -23,12 +40,55 @@ func (m *Monitor) GetService() string {
 	return m.service
 }
 
-func (m *Monitor) SetResponseTimeMetric(tags map[string]string, value float64) error {
+// SetResponseTimeMetric observes the response time, attaching the trace ID of
+// the sampled request span as an exemplar when there is one.
+func (m *Monitor) SetResponseTimeMetric(ctx context.Context, tags map[string]string, value float64) error {
 	if m.responseTime == nil {
 		return fmt.Errorf("metric not instantiated")
 	}
 
-	m.responseTime.With(tags).Observe(value)
+	observer := m.responseTime.With(tags)
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanCtx.IsSampled() {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{"trace_id": spanCtx.TraceID().String()})
+
+		return nil
+	}
+
+	observer.Observe(value)
+
+	return nil
+}
+
+func (m *Monitor) SetRequestSizeMetric(tags map[string]string, value float64) error {
+	if m.requestSize == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.requestSize.With(tags).Observe(value)
+
+	return nil
+}
+
+func (m *Monitor) SetResponseSizeMetric(tags map[string]string, value float64) error {
+	if m.responseSize == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.responseSize.With(tags).Observe(value)
+
+	return nil
+}
+
+// SetInFlightRequestsMetric adds value to the number of requests being
+// served, it is called with 1 when a request starts and -1 when it ends.
+func (m *Monitor) SetInFlightRequestsMetric(tags map[string]string, value float64) error {
+	if m.inFlightRequests == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.inFlightRequests.With(tags).Add(value)
 
 	return nil
 }
@@ -43,6 +103,83 @@ func (m *Monitor) SetDependencyAvailability(tags map[string]string, value float6
 	return nil
 }
 
+func (m *Monitor) SetCacheLookupMetric(tags map[string]string, value float64) error {
+	if m.cacheLookups == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.cacheLookups.With(tags).Add(value)
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundRequestMetric(tags map[string]string, value float64) error {
+	if m.outboundRequests == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundRequests.With(tags).Observe(value)
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundStatusMetric(tags map[string]string, value float64) error {
+	if m.outboundStatuses == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundStatuses.With(tags).Add(value)
+
+	return nil
+}
+
+func (m *Monitor) SetOutboundRetryMetric(tags map[string]string, value float64) error {
+	if m.outboundRetries == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundRetries.With(tags).Add(value)
+
+	return nil
+}
+
+// SetOutboundInFlightMetric adds value to the number of in-flight calls, it
+// is called with 1 when a call starts and -1 when it ends.
+func (m *Monitor) SetOutboundInFlightMetric(tags map[string]string, value float64) error {
+	if m.outboundInFlight == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.outboundInFlight.With(tags).Add(value)
+
+	return nil
+}
+
+func (m *Monitor) SetAuthEventMetric(tags map[string]string, value float64) error {
+	if m.authEvents == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.authEvents.With(m.boundClient(tags)).Add(value)
+
+	return nil
+}
+
+func (m *Monitor) SetLoginDurationMetric(tags map[string]string, value float64) error {
+	if m.loginDuration == nil {
+		return fmt.Errorf("metric not instantiated")
+	}
+
+	m.loginDuration.With(m.boundClient(tags)).Observe(value)
+
+	return nil
+}
+
+// boundClient returns a copy of tags with the client label capped.
+func (m *Monitor) boundClient(tags map[string]string) map[string]string {
+	return m.clients.Bound(tags, "client")
+}
+
 func (m *Monitor) registerHistograms() {
 	histograms := make([]*prometheus.HistogramVec, 0)
 
@@ -55,18 +192,60 @@ func (m *Monitor) registerHistograms() {
 			Name:        "http_response_time_seconds",
 			Help:        "http_response_time_seconds",
 			ConstLabels: labels,
+			Buckets:     m.buckets.Latency,
+		},
+		[]string{"route", "status"},
+	)
+
+	m.requestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "http_request_size_bytes",
+			Help:        "http_request_size_bytes",
+			ConstLabels: labels,
+			Buckets:     m.buckets.Size,
 		},
 		[]string{"route", "status"},
 	)
 
-	histograms = append(histograms, m.responseTime)
+	m.responseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "http_response_size_bytes",
+			Help:        "http_response_size_bytes",
+			ConstLabels: labels,
+			Buckets:     m.buckets.Size,
+		},
+		[]string{"route", "status"},
+	)
+
+	m.outboundRequests = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "outbound_request_duration_seconds",
+			Help:        "outbound_request_duration_seconds",
+			ConstLabels: labels,
+			Buckets:     m.buckets.Latency,
+		},
+		[]string{"client", "method", "code"},
+	)
+
+	m.loginDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "auth_login_duration_seconds",
+			Help:        "auth_login_duration_seconds",
+			ConstLabels: labels,
+			// from the creation of the login flow to its completion
+			Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+		},
+		[]string{"method", "client"},
+	)
+
+	histograms = append(histograms, m.responseTime, m.requestSize, m.responseSize, m.outboundRequests, m.loginDuration)
 
 	for _, histogram := range histograms {
 		err := prometheus.Register(histogram)
 
 		switch err.(type) {
 		case nil:
-			return
+			continue
 		case prometheus.AlreadyRegisteredError:
 			m.logger.Debugf("metric %v already registered", histogram)
 		default:
@@ -91,14 +270,32 @@ func (m *Monitor) registerGauges() {
 		[]string{"component"},
 	)
 
-	gauges = append(gauges, m.dependencyAvailability)
+	m.inFlightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "http_requests_in_flight",
+			Help:        "http_requests_in_flight",
+			ConstLabels: labels,
+		},
+		[]string{},
+	)
+
+	m.outboundInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        "outbound_requests_in_flight",
+			Help:        "outbound_requests_in_flight",
+			ConstLabels: labels,
+		},
+		[]string{"client"},
+	)
+
+	gauges = append(gauges, m.dependencyAvailability, m.inFlightRequests, m.outboundInFlight)
 
 	for _, gauge := range gauges {
 		err := prometheus.Register(gauge)
 
 		switch err.(type) {
 		case nil:
-			return
+			continue
 		case prometheus.AlreadyRegisteredError:
 			m.logger.Debugf("metric %v already registered", gauge)
 		default:
@@ -106,14 +303,77 @@ func (m *Monitor) registerGauges() {
 		}
 	}
 }
-func NewMonitor(service string, logger logging.LoggerInterface) *Monitor {
+
+func (m *Monitor) registerCounters() {
+	counters := make([]*prometheus.CounterVec, 0)
+
+	labels := map[string]string{
+		"service": m.service,
+	}
+
+	m.cacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "cache_lookups_total",
+			Help:        "cache_lookups_total",
+			ConstLabels: labels,
+		},
+		[]string{"cache", "result"},
+	)
+
+	m.authEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "auth_events_total",
+			Help:        "auth_events_total",
+			ConstLabels: labels,
+		},
+		[]string{"event", "method", "client", "outcome"},
+	)
+
+	m.outboundStatuses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "outbound_requests_total",
+			Help:        "outbound_requests_total",
+			ConstLabels: labels,
+		},
+		[]string{"client", "method", "class"},
+	)
+
+	m.outboundRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "outbound_retries_total",
+			Help:        "outbound_retries_total",
+			ConstLabels: labels,
+		},
+		[]string{"client", "method"},
+	)
+
+	counters = append(counters, m.cacheLookups, m.authEvents, m.outboundStatuses, m.outboundRetries)
+
+	for _, counter := range counters {
+		err := prometheus.Register(counter)
+
+		switch err.(type) {
+		case nil:
+			continue
+		case prometheus.AlreadyRegisteredError:
+			m.logger.Debugf("metric %v already registered", counter)
+		default:
+			m.logger.Errorf("metric %v could not be registered", counter)
+		}
+	}
+}
+
+func NewMonitor(service string, buckets *monitoring.Buckets, logger logging.LoggerInterface) *Monitor {
 	m := new(Monitor)
 
 	m.service = service
 	m.logger = logger
+	m.clients = monitoring.NewLabelLimiter(monitoring.MaxClientLabelValues)
+	m.buckets = buckets
 
 	m.registerHistograms()
 	m.registerGauges()
+	m.registerCounters()
 
 	return m
 }
@@ -8,7 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"reflect"
+	"slices"
 	"time"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
@@ -17,10 +17,12 @@ import (
 	openfga "github.com/openfga/go-sdk"
 	"github.com/openfga/go-sdk/client"
 	"github.com/openfga/go-sdk/credentials"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/codes"
 )
 
+// maxTuplesPerWrite is the number of tuples OpenFGA accepts in a single write
+const maxTuplesPerWrite = 100
+
 type Config struct {
 	ApiScheme   string
 	ApiHost     string
@@ -72,7 +74,15 @@ func (c *Client) ReadModel(ctx context.Context) (*openfga.AuthorizationModel, er
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	authModel, err := c.c.ReadAuthorizationModelExecute(c.c.ReadAuthorizationModel(ctx))
+	var authModel *client.ClientReadAuthorizationModelResponse
+	var err error
+
+	// without a configured model ID OpenFGA evaluates the latest model
+	if modelID, _ := c.c.GetAuthorizationModelId(); modelID == "" {
+		authModel, err = c.c.ReadLatestAuthorizationModelExecute(c.c.ReadLatestAuthorizationModel(ctx))
+	} else {
+		authModel, err = c.c.ReadAuthorizationModelExecute(c.c.ReadAuthorizationModel(ctx))
+	}
 
 	if err != nil {
 		span.RecordError(err)
@@ -168,33 +178,105 @@ func (c *Client) Check(ctx context.Context, user string, relation string, object
 	return check.GetAllowed(), nil
 }
 
-func (c *Client) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (bool, error) {
-	ctx, span := c.tracer.Start(ctx, "openfga.Client.ReadModel")
+// WriteTuples writes tuples to the store, tuples already present are ignored,
+// they are sent in batches of the size OpenFGA accepts in a single write
+func (c *Client) WriteTuples(ctx context.Context, tuples []openfga.TupleKey) error {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.WriteTuples")
 	defer span.End()
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	for batch := range slices.Chunk(tuples, maxTuplesPerWrite) {
+		_, err := c.c.Write(ctx).
+			Body(client.ClientWriteRequest{Writes: batch}).
+			Options(client.ClientWriteOptions{
+				Conflict: client.ClientWriteConflictOptions{
+					OnDuplicateWrites: client.CLIENT_WRITE_REQUEST_ON_DUPLICATE_WRITES_IGNORE,
+				},
+			}).
+			Execute()
+
+		if err != nil {
+			c.logger.Errorf("issues performing write operation: %s", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// ReadTuples returns the tuples matching the filter, empty values match any,
+// it follows the pagination until every tuple is read
+func (c *Client) ReadTuples(ctx context.Context, user, relation, object string) ([]openfga.TupleKey, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.ReadTuples")
+	defer span.End()
+
+	body := client.ClientReadRequest{}
+	if user != "" {
+		body.User = openfga.PtrString(user)
+	}
+	if relation != "" {
+		body.Relation = openfga.PtrString(relation)
+	}
+	if object != "" {
+		body.Object = openfga.PtrString(object)
+	}
+
+	tuples := make([]openfga.TupleKey, 0)
+	options := client.ClientReadOptions{PageSize: openfga.PtrInt32(100)}
+
+	for {
+		res, err := c.c.Read(ctx).Body(body).Options(options).Execute()
+		if err != nil {
+			c.logger.Errorf("issues performing read operation: %s", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+
+		for _, tuple := range res.GetTuples() {
+			tuples = append(tuples, tuple.GetKey())
+		}
+
+		if res.GetContinuationToken() == "" {
+			break
+		}
+
+		options.ContinuationToken = openfga.PtrString(res.GetContinuationToken())
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return tuples, nil
+}
+
+// CompareModel reads the model of the store and diffs it against model
+func (c *Client) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (*ModelDiff, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.CompareModel")
+	defer span.End()
 
 	authModel, err := c.ReadModel(ctx)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return false, err
+		return nil, err
 	}
 
-	if authModel.SchemaVersion != model.SchemaVersion {
-		c.logger.Errorf("invalid authorization model schema version")
-		span.SetStatus(codes.Error, "invalid authorization model schema version")
-		return false, nil
+	if authModel == nil {
+		err := fmt.Errorf("no authorization model found in store %s", c.storeID)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
-	if reflect.DeepEqual(authModel.TypeDefinitions, model.TypeDefinitions) {
-		c.logger.Errorf("invalid authorization model type definitions")
-		span.SetStatus(codes.Error, "invalid authorization model type definitions")
-		return false, nil
+
+	diff := DiffModels(model, *authModel)
+
+	if !diff.Equal() {
+		c.logger.Debugf("authorization model %s differs from the built-in one:\n%s", authModel.Id, diff)
 	}
 
 	span.SetStatus(codes.Ok, "")
-	return true, nil
+	return diff, nil
 }
 
 func NewClient(cfg *Config) *Client {
@@ -217,7 +299,7 @@ func NewClient(cfg *Config) *Client {
 			},
 			AuthorizationModelId: cfg.AuthModelID,
 			Debug:                cfg.Debug,
-			HTTPClient:           &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+			HTTPClient:           &http.Client{Transport: monitoring.NewTransport("openfga", http.DefaultTransport, cfg.Monitor, cfg.Logger)},
 		},
 	)
 	if err != nil {
@@ -0,0 +1,273 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	openfga "github.com/openfga/go-sdk"
+)
+
+// ModelDiff is the semantic difference between the authorization model the
+// application needs and the one found in the store
+type ModelDiff struct {
+	// SchemaVersion holds the required and actual schema versions when they differ
+	SchemaVersion []string
+
+	// MissingTypes are required types not defined in the store
+	MissingTypes []string
+	// MissingRelations are required relations, as type#relation, not defined in the store
+	MissingRelations []string
+	// ChangedRelations are relations whose rewrite differs from the required one
+	ChangedRelations []string
+	// MissingUserTypes are directly related user types, as type#relation: user type,
+	// the store does not accept
+	MissingUserTypes []string
+
+	// ExtraTypes and ExtraRelations are defined in the store but not required,
+	// they do not break compatibility
+	ExtraTypes     []string
+	ExtraRelations []string
+	// ExtraUserTypes are directly related user types accepted by the store
+	// on top of the required ones
+	ExtraUserTypes []string
+}
+
+// Compatible reports whether the store model is a superset of the required one
+func (d *ModelDiff) Compatible() bool {
+	return len(d.SchemaVersion) == 0 &&
+		len(d.MissingTypes) == 0 &&
+		len(d.MissingRelations) == 0 &&
+		len(d.ChangedRelations) == 0 &&
+		len(d.MissingUserTypes) == 0
+}
+
+// Equal reports whether the store model matches the required one exactly
+func (d *ModelDiff) Equal() bool {
+	return d.Compatible() &&
+		len(d.ExtraTypes) == 0 &&
+		len(d.ExtraRelations) == 0 &&
+		len(d.ExtraUserTypes) == 0
+}
+
+// String renders the diff, "-" marks what the store is missing, "~" what it
+// defines differently and "+" what it defines on top of the required model
+func (d *ModelDiff) String() string {
+	lines := make([]string, 0)
+
+	if len(d.SchemaVersion) == 2 {
+		lines = append(lines, fmt.Sprintf("~ schema version %s, found %s", d.SchemaVersion[0], d.SchemaVersion[1]))
+	}
+
+	for _, t := range d.MissingTypes {
+		lines = append(lines, fmt.Sprintf("- type %s", t))
+	}
+	for _, r := range d.MissingRelations {
+		lines = append(lines, fmt.Sprintf("- relation %s", r))
+	}
+	for _, u := range d.MissingUserTypes {
+		lines = append(lines, fmt.Sprintf("- user type %s", u))
+	}
+	for _, r := range d.ChangedRelations {
+		lines = append(lines, fmt.Sprintf("~ relation %s", r))
+	}
+	for _, t := range d.ExtraTypes {
+		lines = append(lines, fmt.Sprintf("+ type %s", t))
+	}
+	for _, r := range d.ExtraRelations {
+		lines = append(lines, fmt.Sprintf("+ relation %s", r))
+	}
+	for _, u := range d.ExtraUserTypes {
+		lines = append(lines, fmt.Sprintf("+ user type %s", u))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// DiffModels compares the store model with the required one, relations are
+// compared on their rewrite regardless of the order of union and intersection
+// operands, directly related user types are compared as sets
+func DiffModels(required, actual openfga.AuthorizationModel) *ModelDiff {
+	d := new(ModelDiff)
+
+	if required.SchemaVersion != actual.SchemaVersion {
+		d.SchemaVersion = []string{required.SchemaVersion, actual.SchemaVersion}
+	}
+
+	actualTypes := typeDefinitions(actual)
+	requiredTypes := typeDefinitions(required)
+
+	for _, name := range sortedKeys(requiredTypes) {
+		requiredType := requiredTypes[name]
+
+		actualType, ok := actualTypes[name]
+		if !ok {
+			d.MissingTypes = append(d.MissingTypes, name)
+			continue
+		}
+
+		requiredRelations := requiredType.GetRelations()
+		actualRelations := actualType.GetRelations()
+
+		for _, relation := range sortedKeys(requiredRelations) {
+			key := fmt.Sprintf("%s#%s", name, relation)
+
+			actualRewrite, ok := actualRelations[relation]
+			if !ok {
+				d.MissingRelations = append(d.MissingRelations, key)
+				continue
+			}
+
+			if canonical(requiredRelations[relation]) != canonical(actualRewrite) {
+				d.ChangedRelations = append(d.ChangedRelations, key)
+			}
+
+			requiredUserTypes := userTypes(requiredType, relation)
+			actualUserTypes := userTypes(actualType, relation)
+
+			for _, userType := range requiredUserTypes {
+				if !slices.Contains(actualUserTypes, userType) {
+					d.MissingUserTypes = append(d.MissingUserTypes, fmt.Sprintf("%s: %s", key, userType))
+				}
+			}
+
+			for _, userType := range actualUserTypes {
+				if !slices.Contains(requiredUserTypes, userType) {
+					d.ExtraUserTypes = append(d.ExtraUserTypes, fmt.Sprintf("%s: %s", key, userType))
+				}
+			}
+		}
+
+		for _, relation := range sortedKeys(actualRelations) {
+			if _, ok := requiredRelations[relation]; !ok {
+				d.ExtraRelations = append(d.ExtraRelations, fmt.Sprintf("%s#%s", name, relation))
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(actualTypes) {
+		if _, ok := requiredTypes[name]; !ok {
+			d.ExtraTypes = append(d.ExtraTypes, name)
+		}
+	}
+
+	return d
+}
+
+func typeDefinitions(model openfga.AuthorizationModel) map[string]openfga.TypeDefinition {
+	types := make(map[string]openfga.TypeDefinition, len(model.TypeDefinitions))
+
+	for _, t := range model.TypeDefinitions {
+		types[t.Type] = t
+	}
+
+	return types
+}
+
+// userTypes returns the directly related user types of a relation, rendered
+// as in the DSL, e.g. app_group#member or user:*
+func userTypes(t openfga.TypeDefinition, relation string) []string {
+	meta := t.GetMetadata()
+
+	metadata, ok := meta.GetRelations()[relation]
+	if !ok {
+		return nil
+	}
+
+	refs := metadata.GetDirectlyRelatedUserTypes()
+	userTypes := make([]string, 0, len(refs))
+
+	for _, ref := range refs {
+		userType := ref.Type
+
+		if ref.Relation != nil {
+			userType = fmt.Sprintf("%s#%s", userType, ref.GetRelation())
+		}
+		if ref.Wildcard != nil {
+			userType = fmt.Sprintf("%s:*", userType)
+		}
+		if ref.Condition != nil {
+			userType = fmt.Sprintf("%s with %s", userType, ref.GetCondition())
+		}
+
+		userTypes = append(userTypes, userType)
+	}
+
+	slices.Sort(userTypes)
+
+	return userTypes
+}
+
+// canonical renders a userset rewrite as JSON with the operands of unions and
+// intersections sorted, so that equivalent rewrites render the same
+func canonical(userset openfga.Userset) string {
+	raw, err := json.Marshal(userset)
+	if err != nil {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return ""
+	}
+
+	out, err := json.Marshal(normalize(v))
+	if err != nil {
+		return ""
+	}
+
+	return string(out)
+}
+
+func normalize(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, child := range value {
+			value[k] = normalize(child)
+		}
+
+		for _, op := range []string{"union", "intersection"} {
+			operands, ok := value[op].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			children, ok := operands["child"].([]any)
+			if !ok {
+				continue
+			}
+
+			slices.SortFunc(children, func(a, b any) int {
+				ja, _ := json.Marshal(a)
+				jb, _ := json.Marshal(b)
+				return strings.Compare(string(ja), string(jb))
+			})
+		}
+
+		return value
+	case []any:
+		for i, child := range value {
+			value[i] = normalize(child)
+		}
+
+		return value
+	default:
+		return v
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
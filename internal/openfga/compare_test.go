@@ -0,0 +1,88 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"encoding/json"
+	"testing"
+
+	openfga "github.com/openfga/go-sdk"
+)
+
+const requiredModel = `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"metadata":{"relations":{"child":{"directly_related_user_types":[{"type":"group"}]},"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"child":{"this":{}},"member":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"relation":"member"},"tupleset":{"relation":"child"}}}]}}},"type":"group"}]}`
+
+func parseModel(t *testing.T, raw string) openfga.AuthorizationModel {
+	t.Helper()
+
+	var model openfga.AuthorizationModel
+	if err := json.Unmarshal([]byte(raw), &model); err != nil {
+		t.Fatalf("invalid model: %v", err)
+	}
+
+	return model
+}
+
+func TestDiffModels(t *testing.T) {
+	tests := []struct {
+		name       string
+		actual     string
+		compatible bool
+		equal      bool
+		expected   string
+	}{
+		{
+			name:       "same model",
+			actual:     requiredModel,
+			compatible: true,
+			equal:      true,
+			expected:   "",
+		},
+		{
+			name:       "union operands in a different order",
+			actual:     `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"metadata":{"relations":{"child":{"directly_related_user_types":[{"type":"group"}]},"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"child":{"this":{}},"member":{"union":{"child":[{"tupleToUserset":{"computedUserset":{"relation":"member"},"tupleset":{"relation":"child"}}},{"this":{}}]}}},"type":"group"}]}`,
+			compatible: true,
+			equal:      true,
+			expected:   "",
+		},
+		{
+			name:       "superset",
+			actual:     `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"team"},{"metadata":{"relations":{"admin":{"directly_related_user_types":[{"type":"user"}]},"child":{"directly_related_user_types":[{"type":"group"}]},"member":{"directly_related_user_types":[{"type":"user"},{"type":"user","wildcard":{}}]}}},"relations":{"admin":{"this":{}},"child":{"this":{}},"member":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"relation":"member"},"tupleset":{"relation":"child"}}}]}}},"type":"group"}]}`,
+			compatible: true,
+			equal:      false,
+			expected:   "+ type team\n+ relation group#admin\n+ user type group#member: user:*",
+		},
+		{
+			name:       "missing relation and user type",
+			actual:     `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"metadata":{"relations":{"member":{"directly_related_user_types":[{"type":"group","relation":"member"}]}}},"relations":{"member":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"relation":"member"},"tupleset":{"relation":"child"}}}]}}},"type":"group"}]}`,
+			compatible: false,
+			equal:      false,
+			expected:   "- relation group#child\n- user type group#member: user\n+ user type group#member: group#member",
+		},
+		{
+			name:       "changed rewrite and missing type",
+			actual:     `{"schema_version":"1.2","type_definitions":[{"metadata":{"relations":{"child":{"directly_related_user_types":[{"type":"group"}]},"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"child":{"this":{}},"member":{"this":{}}},"type":"group"}]}`,
+			compatible: false,
+			equal:      false,
+			expected:   "~ schema version 1.1, found 1.2\n- type user\n~ relation group#member",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := DiffModels(parseModel(t, requiredModel), parseModel(t, test.actual))
+
+			if diff.Compatible() != test.compatible {
+				t.Fatalf("expected compatible %v, got %v", test.compatible, diff.Compatible())
+			}
+
+			if diff.Equal() != test.equal {
+				t.Fatalf("expected equal %v, got %v", test.equal, diff.Equal())
+			}
+
+			if diff.String() != test.expected {
+				t.Fatalf("expected diff\n%s\ngot\n%s", test.expected, diff.String())
+			}
+		})
+	}
+}
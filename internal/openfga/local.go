@@ -0,0 +1,508 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	openfga "github.com/openfga/go-sdk"
+	"go.opentelemetry.io/otel/codes"
+	"go.yaml.in/yaml/v2"
+)
+
+// maxResolutionDepth is the number of nested relations OpenFGA resolves
+// before failing a request
+const maxResolutionDepth = 25
+
+var ErrResolutionDepth = errors.New("resolution depth exceeded")
+
+// tupleFile is the content of a local tuple file, in YAML or JSON. Tuples are
+// listed one by one or as assignments, the format exported by the fga command
+//
+//	tuples:
+//	  - user: user:joe
+//	    relation: member
+//	    object: group:admins
+//	providers:
+//	  github:
+//	    apps: [console]
+type tupleFile struct {
+	Tuples      []fileTuple `yaml:"tuples,omitempty"`
+	Assignments `yaml:",inline"`
+}
+
+type fileTuple struct {
+	User     string `yaml:"user"`
+	Relation string `yaml:"relation"`
+	Object   string `yaml:"object"`
+}
+
+// tupleIndex holds the users of every object#relation
+type tupleIndex struct {
+	users   map[string][]string
+	objects map[string][]string
+}
+
+// LocalClient evaluates an authorization model in-process against the tuples
+// of a file, as an alternative to an OpenFGA server. Direct, computed,
+// tupleToUserset, union, intersection and difference relations are resolved
+// the way OpenFGA does, conditions are not supported.
+//
+// The file is checked on every call and read again when its modification time
+// changed. A file that cannot be read or holds invalid tuples keeps the
+// previously loaded tuples in use, it is not read again until it changes.
+type LocalClient struct {
+	path  string
+	model openfga.AuthorizationModel
+	types map[string]openfga.TypeDefinition
+
+	mu      sync.RWMutex
+	index   *tupleIndex
+	modTime time.Time
+	// failedModTime is the modification time of the file that failed to load
+	failedModTime time.Time
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (c *LocalClient) ListObjects(ctx context.Context, user string, relation string, objectType string) ([]string, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.LocalClient.ListObjects")
+	defer span.End()
+
+	if err := c.validateRelation(objectType, relation); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	index := c.tuples()
+
+	candidates := slices.Clone(index.objects[objectType])
+	// a userset is related to its own object
+	if object, _, ok := strings.Cut(user, "#"); ok && strings.HasPrefix(object, objectType+":") {
+		candidates = append(candidates, strings.TrimPrefix(object, objectType+":"))
+	}
+
+	slices.Sort(candidates)
+
+	allowedObjs := make([]string, 0)
+	for _, id := range slices.Compact(candidates) {
+		allowed, err := c.check(index, user, relation, objectType+":"+id, 0, make(map[string]bool))
+		if err != nil {
+			c.logger.Errorf("issues performing list operation: %s", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+
+		if allowed {
+			allowedObjs = append(allowedObjs, id)
+		}
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return allowedObjs, nil
+}
+
+func (c *LocalClient) Check(ctx context.Context, user string, relation string, object string) (bool, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.LocalClient.Check")
+	defer span.End()
+
+	objectType, _, _ := strings.Cut(object, ":")
+	if err := c.validateRelation(objectType, relation); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	allowed, err := c.check(c.tuples(), user, relation, object, 0, make(map[string]bool))
+	if err != nil {
+		c.logger.Errorf("issues performing check operation: %s", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return allowed, nil
+}
+
+func (c *LocalClient) ReadModel(ctx context.Context) (*openfga.AuthorizationModel, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.LocalClient.ReadModel")
+	defer span.End()
+
+	model := c.model
+
+	span.SetStatus(codes.Ok, "")
+	return &model, nil
+}
+
+// CompareModel diffs the evaluated model against model
+func (c *LocalClient) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (*ModelDiff, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.LocalClient.CompareModel")
+	defer span.End()
+
+	span.SetStatus(codes.Ok, "")
+	return DiffModels(model, c.model), nil
+}
+
+// check resolves relation of object for user, visited holds the checks of the
+// current path so that cyclic relations resolve to false
+func (c *LocalClient) check(index *tupleIndex, user, relation, object string, depth int, visited map[string]bool) (bool, error) {
+	if depth >= maxResolutionDepth {
+		return false, ErrResolutionDepth
+	}
+
+	if user == object+"#"+relation {
+		return true, nil
+	}
+
+	key := user + "|" + object + "#" + relation
+	if visited[key] {
+		return false, nil
+	}
+
+	visited[key] = true
+	defer delete(visited, key)
+
+	objectType, _, _ := strings.Cut(object, ":")
+	rewrite, ok := c.relation(objectType, relation)
+	if !ok {
+		return false, nil
+	}
+
+	return c.rewrite(index, rewrite, user, relation, object, depth, visited)
+}
+
+func (c *LocalClient) rewrite(index *tupleIndex, userset openfga.Userset, user, relation, object string, depth int, visited map[string]bool) (bool, error) {
+	switch {
+	case userset.This != nil:
+		return c.direct(index, user, relation, object, depth, visited)
+	case userset.ComputedUserset != nil:
+		return c.check(index, user, userset.ComputedUserset.GetRelation(), object, depth+1, visited)
+	case userset.TupleToUserset != nil:
+		ttu := userset.TupleToUserset
+
+		var errs []error
+		for _, parent := range index.users[object+"#"+ttu.Tupleset.GetRelation()] {
+			// only objects are followed, the type of the parent may not
+			// define the computed relation
+			if strings.Contains(parent, "#") || strings.HasSuffix(parent, ":*") {
+				continue
+			}
+
+			parentType, _, _ := strings.Cut(parent, ":")
+			if _, ok := c.relation(parentType, ttu.ComputedUserset.GetRelation()); !ok {
+				continue
+			}
+
+			allowed, err := c.check(index, user, ttu.ComputedUserset.GetRelation(), parent, depth+1, visited)
+			if allowed {
+				return true, nil
+			}
+			errs = append(errs, err)
+		}
+
+		return false, errors.Join(errs...)
+	case userset.Union != nil:
+		var errs []error
+		for _, child := range userset.Union.Child {
+			allowed, err := c.rewrite(index, child, user, relation, object, depth, visited)
+			if allowed {
+				return true, nil
+			}
+			errs = append(errs, err)
+		}
+
+		return false, errors.Join(errs...)
+	case userset.Intersection != nil:
+		for _, child := range userset.Intersection.Child {
+			allowed, err := c.rewrite(index, child, user, relation, object, depth, visited)
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+
+		return len(userset.Intersection.Child) > 0, nil
+	case userset.Difference != nil:
+		allowed, err := c.rewrite(index, userset.Difference.Base, user, relation, object, depth, visited)
+		if err != nil || !allowed {
+			return false, err
+		}
+
+		excluded, err := c.rewrite(index, userset.Difference.Subtract, user, relation, object, depth, visited)
+		if err != nil {
+			return false, err
+		}
+
+		return !excluded, nil
+	default:
+		return false, nil
+	}
+}
+
+// direct resolves the tuples of object#relation, a user matches itself, a
+// wildcard of its type or a userset it belongs to
+func (c *LocalClient) direct(index *tupleIndex, user, relation, object string, depth int, visited map[string]bool) (bool, error) {
+	userType, _, _ := strings.Cut(user, ":")
+	isUserset := strings.Contains(user, "#")
+
+	var errs []error
+	for _, u := range index.users[object+"#"+relation] {
+		if u == user || (!isUserset && u == userType+":*") {
+			return true, nil
+		}
+
+		usersetObject, usersetRelation, ok := strings.Cut(u, "#")
+		if !ok {
+			continue
+		}
+
+		allowed, err := c.check(index, user, usersetRelation, usersetObject, depth+1, visited)
+		if allowed {
+			return true, nil
+		}
+		errs = append(errs, err)
+	}
+
+	return false, errors.Join(errs...)
+}
+
+func (c *LocalClient) relation(objectType, relation string) (openfga.Userset, bool) {
+	t, ok := c.types[objectType]
+	if !ok {
+		return openfga.Userset{}, false
+	}
+
+	userset, ok := t.GetRelations()[relation]
+	return userset, ok
+}
+
+func (c *LocalClient) validateRelation(objectType, relation string) error {
+	if _, ok := c.types[objectType]; !ok {
+		return fmt.Errorf("type %s not found in the authorization model", objectType)
+	}
+
+	if _, ok := c.relation(objectType, relation); !ok {
+		return fmt.Errorf("relation %s not found on type %s", relation, objectType)
+	}
+
+	return nil
+}
+
+// validateTuple checks the tuple against the types the model allows on the
+// relation, as OpenFGA does on writes
+func (c *LocalClient) validateTuple(tuple openfga.TupleKey) error {
+	objectType, objectID, _ := strings.Cut(tuple.Object, ":")
+	if objectID == "" || objectID == "*" {
+		return fmt.Errorf("invalid object %q", tuple.Object)
+	}
+
+	if err := c.validateRelation(objectType, tuple.Relation); err != nil {
+		return err
+	}
+
+	user, userRelation, isUserset := strings.Cut(tuple.User, "#")
+	userType, userID, _ := strings.Cut(user, ":")
+	if userID == "" {
+		return fmt.Errorf("invalid user %q", tuple.User)
+	}
+
+	t := c.types[objectType]
+	meta := t.GetMetadata()
+	relationMeta := meta.GetRelations()[tuple.Relation]
+	for _, ref := range relationMeta.GetDirectlyRelatedUserTypes() {
+		if ref.Type != userType {
+			continue
+		}
+
+		switch {
+		case isUserset && ref.GetRelation() == userRelation:
+			return nil
+		case !isUserset && userID == "*" && ref.Wildcard != nil:
+			return nil
+		case !isUserset && userID != "*" && ref.Relation == nil && ref.Wildcard == nil:
+			return nil
+		}
+	}
+
+	return fmt.Errorf("user %s is not allowed on %s#%s", tuple.User, objectType, tuple.Relation)
+}
+
+func (c *LocalClient) tuples() *tupleIndex {
+	c.reload()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.index
+}
+
+// reload is called on every request, failures are logged and the previous
+// tuples stay in use
+func (c *LocalClient) reload() {
+	if err := c.load(); err != nil {
+		c.logger.Errorf("failed to reload tuples from %s, using the previous ones: %v", c.path, err)
+	}
+}
+
+// load reads the tuple file when it changed since the last call
+func (c *LocalClient) load() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	unchanged := c.unchanged(info.ModTime())
+	c.mu.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// loaded by a concurrent call
+	if c.unchanged(info.ModTime()) {
+		return nil
+	}
+
+	index, count, err := c.readIndex()
+	if err != nil {
+		// the failure is reported once, until the file changes again
+		c.failedModTime = info.ModTime()
+		return err
+	}
+
+	if c.index != nil {
+		c.logger.Infof("reloaded %d tuples from %s", count, c.path)
+	}
+
+	c.index = index
+	c.modTime = info.ModTime()
+	c.failedModTime = time.Time{}
+
+	return nil
+}
+
+// unchanged reports whether the file with modTime was already loaded or
+// failed to load, it is called with the lock held
+func (c *LocalClient) unchanged(modTime time.Time) bool {
+	return c.index != nil && (modTime.Equal(c.modTime) || modTime.Equal(c.failedModTime))
+}
+
+// readIndex reads the tuple file and indexes its tuples, it returns the
+// number of tuples read
+func (c *LocalClient) readIndex() (*tupleIndex, int, error) {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	tuples, err := readTupleFile(f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	index, err := c.buildIndex(tuples)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return index, len(tuples), nil
+}
+
+func (c *LocalClient) buildIndex(tuples []openfga.TupleKey) (*tupleIndex, error) {
+	index := &tupleIndex{
+		users:   make(map[string][]string),
+		objects: make(map[string][]string),
+	}
+
+	var errs []error
+	for _, tuple := range tuples {
+		if err := c.validateTuple(tuple); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		key := tuple.Object + "#" + tuple.Relation
+		if !slices.Contains(index.users[key], tuple.User) {
+			index.users[key] = append(index.users[key], tuple.User)
+		}
+
+		// every object a tuple mentions is a candidate of ListObjects
+		user, _, _ := strings.Cut(tuple.User, "#")
+		for _, object := range []string{tuple.Object, user} {
+			objectType, id, _ := strings.Cut(object, ":")
+			if id != "*" && !slices.Contains(index.objects[objectType], id) {
+				index.objects[objectType] = append(index.objects[objectType], id)
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// readTupleFile decodes the tuples of a local tuple file
+func readTupleFile(r io.Reader) ([]openfga.TupleKey, error) {
+	file := new(tupleFile)
+
+	if err := yaml.NewDecoder(r).Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse tuples: %w", err)
+	}
+
+	tuples := make([]openfga.TupleKey, 0, len(file.Tuples))
+	for _, tuple := range file.Tuples {
+		tuples = append(tuples, openfga.TupleKey{User: tuple.User, Relation: tuple.Relation, Object: tuple.Object})
+	}
+
+	return append(tuples, file.Assignments.tuples()...), nil
+}
+
+// NewLocalClient evaluates model, the JSON authorization model, against the
+// tuples of the file at path
+func NewLocalClient(path string, model []byte, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) (*LocalClient, error) {
+	c := new(LocalClient)
+
+	if err := json.Unmarshal(model, &c.model); err != nil {
+		return nil, fmt.Errorf("invalid authorization model: %w", err)
+	}
+
+	c.types = make(map[string]openfga.TypeDefinition)
+	for _, t := range c.model.TypeDefinitions {
+		c.types[t.Type] = t
+	}
+
+	c.path = path
+	c.tracer = tracer
+	c.monitor = monitor
+	c.logger = logger
+
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("failed to load tuples from %s: %w", path, err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/misc/clock"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+// builtinModel is the model of internal/authorization/schema.openfga
+const builtinModel = `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"app"},{"type":"tenant"},{"metadata":{"relations":{"child":{"directly_related_user_types":[{"type":"group"}]},"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"child":{"this":{}},"member":{"union":{"child":[{"this":{}},{"tupleToUserset":{"computedUserset":{"relation":"member"},"tupleset":{"relation":"child"}}}]}}},"type":"group"},{"metadata":{"relations":{"member":{"directly_related_user_types":[{"type":"app"},{"relation":"member","type":"app_group"}]}}},"relations":{"member":{"this":{}}},"type":"app_group"},{"metadata":{"relations":{"allowed_access":{"directly_related_user_types":[{"type":"app"},{"relation":"member","type":"app_group"},{"type":"tenant"}]},"denied_access":{"directly_related_user_types":[{"type":"app"},{"relation":"member","type":"app_group"},{"type":"tenant"}]},"member":{"directly_related_user_types":[{"type":"user"}]}}},"relations":{"allowed_access":{"this":{}},"denied_access":{"this":{}},"member":{"this":{}}},"type":"provider"}]}`
+
+// docModel covers wildcards, intersections and exclusions
+//
+//	type user
+//	type doc
+//	  relations
+//	    define blocked: [user]
+//	    define viewer: [user, user:*] but not blocked
+//	    define editor: [user] and viewer
+const docModel = `{"schema_version":"1.1","type_definitions":[{"type":"user"},{"type":"doc","relations":{"blocked":{"this":{}},"viewer":{"difference":{"base":{"this":{}},"subtract":{"computedUserset":{"relation":"blocked"}}}},"editor":{"intersection":{"child":[{"this":{}},{"computedUserset":{"relation":"viewer"}}]}}},"metadata":{"relations":{"blocked":{"directly_related_user_types":[{"type":"user"}]},"viewer":{"directly_related_user_types":[{"type":"user"},{"type":"user","wildcard":{}}]},"editor":{"directly_related_user_types":[{"type":"user"}]}}}}]}`
+
+const localTuples = `tuples:
+  - user: user:joe
+    relation: member
+    object: group:engineering
+  - user: group:engineering
+    relation: child
+    object: group:staff
+  - user: app_group:internal#member
+    relation: member
+    object: app_group:trusted
+  - user: app_group:loop#member
+    relation: member
+    object: app_group:cycle
+  - user: app_group:cycle#member
+    relation: member
+    object: app_group:loop
+providers:
+  github:
+    apps: [console]
+    app_groups: [trusted]
+  okta:
+    tenants: [acme]
+app_groups:
+  internal:
+    apps: [grafana]
+`
+
+func TestLocalClientCheck(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(localTuples))
+
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		relation string
+		object   string
+		expected bool
+	}{
+		{user: "app:console", relation: "allowed_access", object: "provider:github", expected: true},
+		{user: "app:console", relation: "allowed_access", object: "provider:okta", expected: false},
+		{user: "app:grafana", relation: "allowed_access", object: "provider:github", expected: true},
+		{user: "app:grafana", relation: "member", object: "app_group:trusted", expected: true},
+		{user: "app:other", relation: "allowed_access", object: "provider:github", expected: false},
+		{user: "tenant:acme", relation: "allowed_access", object: "provider:okta", expected: true},
+		{user: "app_group:internal#member", relation: "allowed_access", object: "provider:github", expected: true},
+		{user: "user:joe", relation: "member", object: "group:staff", expected: true},
+		{user: "user:ann", relation: "member", object: "group:staff", expected: false},
+		{user: "app:grafana", relation: "member", object: "app_group:cycle", expected: false},
+		{user: "app_group:cycle#member", relation: "member", object: "app_group:cycle", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.user+" "+test.relation+" "+test.object, func(t *testing.T) {
+			allowed, err := c.Check(context.Background(), test.user, test.relation, test.object)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if allowed != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestLocalClientCheckRewrites(t *testing.T) {
+	tuples := `tuples:
+  - {user: "user:*", relation: viewer, object: "doc:readme"}
+  - {user: "user:mallory", relation: blocked, object: "doc:readme"}
+  - {user: "user:joe", relation: editor, object: "doc:readme"}
+  - {user: "user:mallory", relation: editor, object: "doc:readme"}
+`
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(tuples))
+
+	c, err := NewLocalClient(path, []byte(docModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		user     string
+		relation string
+		expected bool
+	}{
+		{user: "user:joe", relation: "viewer", expected: true},
+		{user: "user:mallory", relation: "viewer", expected: false},
+		{user: "user:joe", relation: "editor", expected: true},
+		{user: "user:mallory", relation: "editor", expected: false},
+		{user: "user:ann", relation: "editor", expected: false},
+	}
+
+	for _, test := range tests {
+		allowed, err := c.Check(context.Background(), test.user, test.relation, "doc:readme")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if allowed != test.expected {
+			t.Fatalf("%s %s: expected %v, got %v", test.user, test.relation, test.expected, allowed)
+		}
+	}
+}
+
+func TestLocalClientListObjects(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(localTuples))
+
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	objects, err := c.ListObjects(context.Background(), "app:grafana", "allowed_access", "provider")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !slices.Equal(objects, []string{"github"}) {
+		t.Fatalf("expected [github], got %v", objects)
+	}
+
+	objects, err = c.ListObjects(context.Background(), "app:other", "allowed_access", "provider")
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("expected no objects, got %v, %v", objects, err)
+	}
+}
+
+func TestLocalClientUnknownRelation(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(localTuples))
+
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := c.Check(context.Background(), "app:console", "owner", "provider:github"); err == nil {
+		t.Fatal("expected an error for an unknown relation")
+	}
+
+	if _, err := c.ListObjects(context.Background(), "app:console", "allowed_access", "client"); err == nil {
+		t.Fatal("expected an error for an unknown type")
+	}
+}
+
+func TestNewLocalClientInvalidTuples(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuples string
+	}{
+		{name: "user type not allowed", tuples: "tuples: [{user: 'user:joe', relation: allowed_access, object: 'provider:github'}]"},
+		{name: "unknown relation", tuples: "tuples: [{user: 'app:console', relation: owner, object: 'provider:github'}]"},
+		{name: "userset relation not allowed", tuples: "tuples: [{user: 'group:staff#member', relation: member, object: 'app_group:trusted'}]"},
+		{name: "wildcard not allowed", tuples: "tuples: [{user: 'app:*', relation: allowed_access, object: 'provider:github'}]"},
+		{name: "invalid yaml", tuples: "tuples: ["},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tuples.yaml")
+			clock.NewFake().WriteFile(t, path, []byte(test.tuples))
+
+			if _, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger()); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestLocalClientReload(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"apps": ["console"]}}}`))
+
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	check := func() bool {
+		allowed, err := c.Check(context.Background(), "app:grafana", "allowed_access", "provider:github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return allowed
+	}
+
+	if check() {
+		t.Fatal("expected grafana to be denied before the reload")
+	}
+
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"apps": ["console", "grafana"]}}}`))
+	if !check() {
+		t.Fatal("expected grafana to be allowed after the reload")
+	}
+
+	// an invalid file keeps the previous tuples
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"tenants": [""]}}}`))
+	if !check() {
+		t.Fatal("expected the previous tuples to stay in use")
+	}
+}
+
+// errorCountingLogger counts the errors logged
+type errorCountingLogger struct {
+	logging.LoggerInterface
+
+	errors int
+}
+
+func (l *errorCountingLogger) Errorf(format string, args ...interface{}) {
+	l.errors++
+}
+
+func TestLocalClientReportsInvalidFileOnce(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"apps": ["console"]}}}`))
+
+	logger := &errorCountingLogger{LoggerInterface: logging.NewNoopLogger()}
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logger)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	check := func() bool {
+		allowed, err := c.Check(context.Background(), "app:grafana", "allowed_access", "provider:github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return allowed
+	}
+
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"tenants": [""]}}}`))
+	for range 3 {
+		check()
+	}
+
+	if logger.errors != 1 {
+		t.Fatalf("expected the invalid file to be reported once, got %d errors", logger.errors)
+	}
+
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"apps": [""]}}}`))
+	check()
+
+	if logger.errors != 2 {
+		t.Fatalf("expected the changed invalid file to be reported, got %d errors", logger.errors)
+	}
+
+	fakeClock.WriteFile(t, path, []byte(`{"providers": {"github": {"apps": ["console", "grafana"]}}}`))
+	if !check() {
+		t.Fatal("expected the fixed file to be loaded")
+	}
+
+	if logger.errors != 2 {
+		t.Fatalf("expected no error for the fixed file, got %d errors", logger.errors)
+	}
+}
+
+func TestLocalClientCompareModel(t *testing.T) {
+	fakeClock := clock.NewFake()
+	path := filepath.Join(t.TempDir(), "tuples.yaml")
+	fakeClock.WriteFile(t, path, []byte(localTuples))
+
+	c, err := NewLocalClient(path, []byte(builtinModel), tracing.NewNoopTracer(), monitoring.NewNoopMonitor("", nil), logging.NewNoopLogger())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	diff, err := c.CompareModel(context.Background(), parseModel(t, builtinModel))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !diff.Equal() {
+		t.Fatalf("expected no difference, got %s", diff)
+	}
+}
@@ -54,9 +54,23 @@ func (c *NoopClient) WriteModel(ctx context.Context, model []byte) (string, erro
 	return "", nil
 }
 
-func (c *NoopClient) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (bool, error) {
+func (c *NoopClient) WriteTuples(ctx context.Context, tuples []openfga.TupleKey) error {
+	ctx, span := c.tracer.Start(ctx, "openfga.NoopClient.WriteTuples")
+	defer span.End()
+
+	return nil
+}
+
+func (c *NoopClient) ReadTuples(ctx context.Context, user, relation, object string) ([]openfga.TupleKey, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.NoopClient.ReadTuples")
+	defer span.End()
+
+	return make([]openfga.TupleKey, 0), nil
+}
+
+func (c *NoopClient) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (*ModelDiff, error) {
 	ctx, span := c.tracer.Start(ctx, "openfga.NoopClient.CompareModel")
 	defer span.End()
 
-	return true, nil
+	return new(ModelDiff), nil
 }
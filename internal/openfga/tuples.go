@@ -0,0 +1,251 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	openfga "github.com/openfga/go-sdk"
+	"go.yaml.in/yaml/v2"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatCSV  = "csv"
+)
+
+var csvHeader = []string{"user", "relation", "object"}
+
+// Access lists the subjects of an assignment, apps and tenants by ID and app
+// groups by name, the members of an app group are granted the assignment
+type Access struct {
+	Apps      []string `yaml:"apps,omitempty"`
+	AppGroups []string `yaml:"app_groups,omitempty"`
+	Tenants   []string `yaml:"tenants,omitempty"`
+}
+
+// Assignments is the YAML representation of the tuples managing which apps
+// and tenants may or may not use an identity provider and which apps belong
+// to app groups
+//
+//	providers:
+//	  github:
+//	    apps: [console]
+//	    app_groups: [internal]
+//	denied_providers:
+//	  password:
+//	    apps: [grafana]
+//	app_groups:
+//	  internal:
+//	    apps: [grafana]
+type Assignments struct {
+	Providers       map[string]Access `yaml:"providers,omitempty"`
+	DeniedProviders map[string]Access `yaml:"denied_providers,omitempty"`
+	AppGroups       map[string]Access `yaml:"app_groups,omitempty"`
+}
+
+func (a *Assignments) tuples() []openfga.TupleKey {
+	tuples := make([]openfga.TupleKey, 0)
+	tuples = append(tuples, accessTuples("provider", "allowed_access", a.Providers)...)
+	tuples = append(tuples, accessTuples("provider", "denied_access", a.DeniedProviders)...)
+	tuples = append(tuples, accessTuples("app_group", "member", a.AppGroups)...)
+
+	return tuples
+}
+
+// IsAssignment reports whether the tuple is one the assignment files manage,
+// provider#allowed_access, provider#denied_access or app_group#member
+func IsAssignment(tuple openfga.TupleKey) bool {
+	return validateTuple(tuple) == nil
+}
+
+func validateTuple(tuple openfga.TupleKey) error {
+	objectType, _, _ := strings.Cut(tuple.Object, ":")
+	userType, _, _ := strings.Cut(tuple.User, ":")
+
+	var allowed []string
+
+	switch {
+	case objectType == "provider" && (tuple.Relation == "allowed_access" || tuple.Relation == "denied_access"):
+		allowed = []string{"app", "app_group", "tenant"}
+	case objectType == "app_group" && tuple.Relation == "member":
+		allowed = []string{"app", "app_group"}
+	default:
+		return fmt.Errorf("unsupported tuple %s %s %s, only provider#allowed_access, provider#denied_access and app_group#member are managed", tuple.User, tuple.Relation, tuple.Object)
+	}
+
+	if !slices.Contains(allowed, userType) {
+		return fmt.Errorf("unsupported user %s for %s#%s", tuple.User, objectType, tuple.Relation)
+	}
+
+	if userType == "app_group" && !strings.HasSuffix(tuple.User, "#member") {
+		return fmt.Errorf("app group %s must be referenced as app_group:<name>#member", tuple.User)
+	}
+
+	if strings.HasSuffix(tuple.Object, ":") || strings.HasSuffix(tuple.User, ":") || strings.HasSuffix(tuple.User, ":#member") {
+		return fmt.Errorf("empty ID in tuple %s %s %s", tuple.User, tuple.Relation, tuple.Object)
+	}
+
+	return nil
+}
+
+// DecodeTuples decodes assignment tuples in the given format
+func DecodeTuples(r io.Reader, format string) ([]openfga.TupleKey, error) {
+	var tuples []openfga.TupleKey
+	var err error
+
+	switch format {
+	case FormatYAML:
+		tuples, err = readYAML(r)
+	case FormatCSV:
+		tuples, err = readCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected %s or %s", format, FormatYAML, FormatCSV)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, tuple := range tuples {
+		errs = append(errs, validateTuple(tuple))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return tuples, nil
+}
+
+// EncodeTuples encodes assignment tuples in the given format, tuples that are
+// not assignments are skipped
+func EncodeTuples(w io.Writer, tuples []openfga.TupleKey, format string) error {
+	assignments := slices.DeleteFunc(slices.Clone(tuples), func(tuple openfga.TupleKey) bool {
+		return !IsAssignment(tuple)
+	})
+
+	slices.SortFunc(assignments, func(a, b openfga.TupleKey) int {
+		return strings.Compare(a.Object+a.User, b.Object+b.User)
+	})
+
+	switch format {
+	case FormatYAML:
+		return writeYAML(w, assignments)
+	case FormatCSV:
+		return writeCSV(w, assignments)
+	default:
+		return fmt.Errorf("unsupported format %q, expected %s or %s", format, FormatYAML, FormatCSV)
+	}
+}
+
+func readYAML(r io.Reader) ([]openfga.TupleKey, error) {
+	assignments := new(Assignments)
+
+	if err := yaml.NewDecoder(r).Decode(assignments); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse assignments: %w", err)
+	}
+
+	return assignments.tuples(), nil
+}
+
+func accessTuples(objectType, relation string, assignments map[string]Access) []openfga.TupleKey {
+	tuples := make([]openfga.TupleKey, 0)
+
+	for _, name := range sortedKeys(assignments) {
+		access := assignments[name]
+		object := fmt.Sprintf("%s:%s", objectType, name)
+
+		for _, app := range access.Apps {
+			tuples = append(tuples, openfga.TupleKey{User: "app:" + app, Relation: relation, Object: object})
+		}
+		for _, group := range access.AppGroups {
+			tuples = append(tuples, openfga.TupleKey{User: "app_group:" + group + "#member", Relation: relation, Object: object})
+		}
+		for _, tenant := range access.Tenants {
+			tuples = append(tuples, openfga.TupleKey{User: "tenant:" + tenant, Relation: relation, Object: object})
+		}
+	}
+
+	return tuples
+}
+
+func writeYAML(w io.Writer, tuples []openfga.TupleKey) error {
+	assignments := Assignments{
+		Providers:       make(map[string]Access),
+		DeniedProviders: make(map[string]Access),
+		AppGroups:       make(map[string]Access),
+	}
+
+	for _, tuple := range tuples {
+		objectType, name, _ := strings.Cut(tuple.Object, ":")
+		userType, id, _ := strings.Cut(strings.TrimSuffix(tuple.User, "#member"), ":")
+
+		target := assignments.Providers
+		switch {
+		case objectType == "app_group":
+			target = assignments.AppGroups
+		case tuple.Relation == "denied_access":
+			target = assignments.DeniedProviders
+		}
+
+		access := target[name]
+		switch userType {
+		case "app":
+			access.Apps = append(access.Apps, id)
+		case "app_group":
+			access.AppGroups = append(access.AppGroups, id)
+		case "tenant":
+			access.Tenants = append(access.Tenants, id)
+		}
+		target[name] = access
+	}
+
+	return yaml.NewEncoder(w).Encode(assignments)
+}
+
+func readCSV(r io.Reader) ([]openfga.TupleKey, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tuples: %w", err)
+	}
+
+	if len(records) > 0 && slices.Equal(records[0], csvHeader) {
+		records = records[1:]
+	}
+
+	tuples := make([]openfga.TupleKey, 0, len(records))
+	for _, record := range records {
+		tuples = append(tuples, openfga.TupleKey{User: record[0], Relation: record[1], Object: record[2]})
+	}
+
+	return tuples, nil
+}
+
+func writeCSV(w io.Writer, tuples []openfga.TupleKey) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, tuple := range tuples {
+		if err := writer.Write([]string{tuple.User, tuple.Relation, tuple.Object}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
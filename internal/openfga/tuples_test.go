@@ -0,0 +1,99 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package openfga
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	openfga "github.com/openfga/go-sdk"
+)
+
+const assignmentsYAML = `providers:
+  github:
+    apps: [console]
+    app_groups: [internal]
+    tenants: [acme]
+denied_providers:
+  password:
+    apps: [grafana]
+app_groups:
+  internal:
+    apps: [grafana]
+`
+
+var assignmentTuples = []openfga.TupleKey{
+	{User: "app:console", Relation: "allowed_access", Object: "provider:github"},
+	{User: "app_group:internal#member", Relation: "allowed_access", Object: "provider:github"},
+	{User: "tenant:acme", Relation: "allowed_access", Object: "provider:github"},
+	{User: "app:grafana", Relation: "denied_access", Object: "provider:password"},
+	{User: "app:grafana", Relation: "member", Object: "app_group:internal"},
+}
+
+func TestDecodeTuplesYAML(t *testing.T) {
+	tuples, err := DecodeTuples(strings.NewReader(assignmentsYAML), FormatYAML)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(tuples, assignmentTuples) {
+		t.Fatalf("expected %v, got %v", assignmentTuples, tuples)
+	}
+}
+
+func TestTuplesRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatYAML, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			// tuples outside of the assignments are not exported
+			tuples := append(slices.Clone(assignmentTuples), openfga.TupleKey{User: "user:joe", Relation: "member", Object: "group:admins"})
+
+			buf := new(bytes.Buffer)
+			if err := EncodeTuples(buf, tuples, format); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			decoded, err := DecodeTuples(buf, format)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(decoded) != len(assignmentTuples) {
+				t.Fatalf("expected %d tuples, got %v", len(assignmentTuples), decoded)
+			}
+
+			for _, tuple := range assignmentTuples {
+				if !slices.Contains(decoded, tuple) {
+					t.Fatalf("expected %v in %v", tuple, decoded)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeTuplesInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{name: "unsupported format", input: "", format: "json"},
+		{name: "unsupported relation", input: "user:joe,member,group:admins\n", format: FormatCSV},
+		{name: "unsupported user type", input: "user:joe,allowed_access,provider:github\n", format: FormatCSV},
+		{name: "app group without member", input: "app_group:internal,allowed_access,provider:github\n", format: FormatCSV},
+		{name: "empty ID", input: "app:,allowed_access,provider:github\n", format: FormatCSV},
+		{name: "wrong column count", input: "app:console,allowed_access\n", format: FormatCSV},
+		{name: "invalid yaml", input: "providers: [", format: FormatYAML},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeTuples(strings.NewReader(test.input), test.format); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
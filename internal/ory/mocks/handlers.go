@@ -113,27 +113,33 @@ func SelfServiceGetLoginHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func Oauth2AuthRequestLoginAcceptHandler(w http.ResponseWriter, r *http.Request) {
-	login := hydra_client.NewAcceptOAuth2LoginRequestWithDefaults()
-	data, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
-	}
-	if err = json.Unmarshal(data, login); err != nil {
-		log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
-	}
-	if login.Subject != OAUTH2_SUBJECT {
-		w.WriteHeader(TEST_ERROR_CODE)
-		return
-	}
-	response := hydra_client.NewOAuth2RedirectTo(AUTHORIZATION_REDIRECT)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
-	jsonResp, err := json.Marshal(response)
-	if err != nil {
-		log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
+	Oauth2AuthRequestLoginAcceptRecorder(hydra_client.NewAcceptOAuth2LoginRequestWithDefaults())(w, r)
+}
+
+// Oauth2AuthRequestLoginAcceptRecorder behaves as Oauth2AuthRequestLoginAcceptHandler
+// and stores the accepted login request in login, so tests can inspect it
+func Oauth2AuthRequestLoginAcceptRecorder(login *hydra_client.AcceptOAuth2LoginRequest) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
+		}
+		if err = json.Unmarshal(data, login); err != nil {
+			log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
+		}
+		if login.Subject != OAUTH2_SUBJECT {
+			w.WriteHeader(TEST_ERROR_CODE)
+			return
+		}
+		response := hydra_client.NewOAuth2RedirectTo(AUTHORIZATION_REDIRECT)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		jsonResp, err := json.Marshal(response)
+		if err != nil {
+			log.Printf("Bug in test: Oauth2AuthRequestLoginAcceptHandler\nerror: %s", err.Error())
+		}
+		w.Write(jsonResp)
 	}
-	w.Write(jsonResp)
-	return
 }
 
 func SessionWhoAmIHandler(w http.ResponseWriter, r *http.Request) {
@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	hydra_client "github.com/ory/hydra-client-go/v2"
 )
 
 const DEFAULT_SCHEMA_SERVER_URL = "test_default.json"
@@ -34,8 +35,18 @@ func NewKratosServerStub() *httptest.Server {
 }
 
 func NewHydraServerStub() *httptest.Server {
+	return newHydraServerStub(Oauth2AuthRequestLoginAcceptHandler)
+}
+
+// NewHydraServerStubWithLoginRecorder returns a hydra stub storing the last
+// accepted login request in login
+func NewHydraServerStubWithLoginRecorder(login *hydra_client.AcceptOAuth2LoginRequest) *httptest.Server {
+	return newHydraServerStub(Oauth2AuthRequestLoginAcceptRecorder(login))
+}
+
+func newHydraServerStub(loginAcceptHandler http.HandlerFunc) *httptest.Server {
 	m := chi.NewMux()
-	m.Put("/admin/oauth2/auth/requests/login/accept", Oauth2AuthRequestLoginAcceptHandler)
+	m.Put("/admin/oauth2/auth/requests/login/accept", loginAcceptHandler)
 	m.Get("/admin/oauth2/auth/requests/consent", Oauth2AuthRequestConsentHandler)
 	m.Put("/admin/oauth2/auth/requests/consent/accept", Oauth2AuthRequestConsentAcceptHandler)
 	m.Get("/health/alive", GetOKStatus)
@@ -4,22 +4,85 @@
 package tracing
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
 )
 
+const (
+	PropagatorTraceContext = "tracecontext"
+	PropagatorBaggage      = "baggage"
+	PropagatorJaeger       = "jaeger"
+	PropagatorNone         = "none"
+)
+
+// ExporterConfig holds the transport settings shared by the OTLP exporters
+type ExporterConfig struct {
+	// TLSEnabled switches the OTLP exporters from plaintext to TLS
+	TLSEnabled bool
+	// CAFile is an optional PEM bundle used to verify the collector, system roots are used if empty
+	CAFile string
+	// Headers are sent with every export request, typically used for authentication
+	Headers map[string]string
+	// Stdout enables the pretty printed stdout exporter when no OTLP endpoint is set
+	Stdout bool
+}
+
+// TLSConfig builds the client TLS configuration used to reach the collector
+func (c ExporterConfig) TLSConfig() (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CAFile == "" {
+		return tlsCfg, nil
+	}
+
+	pem, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OTLP CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid certificates found in OTLP CA file %s", c.CAFile)
+	}
+
+	tlsCfg.RootCAs = pool
+
+	return tlsCfg, nil
+}
+
+// ResourceConfig holds the extra attributes attached to every span
+type ResourceConfig struct {
+	Environment string
+	InstanceID  string
+}
+
 type Config struct {
 	OtelHTTPEndpoint string
 	OtelGRPCEndpoint string
+	Exporter         ExporterConfig
+	Resource         ResourceConfig
+	SampleRatio      float64
+	Propagators      []string
 	Logger           logging.LoggerInterface
 
 	Enabled bool
 }
 
-func NewConfig(enabled bool, otelGRPCEndpoint, otelHTTPEndpoint string, logger logging.LoggerInterface) *Config {
+func NewConfig(enabled bool, otelGRPCEndpoint, otelHTTPEndpoint string, exporter ExporterConfig, sampleRatio float64, propagators []string, res ResourceConfig, logger logging.LoggerInterface) *Config {
 	c := new(Config)
 
 	c.OtelGRPCEndpoint = otelGRPCEndpoint
 	c.OtelHTTPEndpoint = otelHTTPEndpoint
+	c.Exporter = exporter
+	c.SampleRatio = sampleRatio
+	c.Propagators = propagators
+	c.Resource = res
 	c.Logger = logger
 	c.Enabled = enabled
 
@@ -31,3 +94,31 @@ func NewNoopConfig() *Config {
 	c.Enabled = false
 	return c
 }
+
+// ParseHeaders parses a comma separated list of key=value pairs, values are url decoded
+// following the format of OTEL_EXPORTER_OTLP_HEADERS
+func ParseHeaders(raw string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", pair)
+		}
+
+		decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for header %q: %w", key, err)
+		}
+
+		headers[key] = decoded
+	}
+
+	return headers, nil
+}
@@ -5,7 +5,9 @@ package tracing
 
 import (
 	"context"
+	"os"
 	"runtime/debug"
+	"strings"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
 	"go.opentelemetry.io/contrib/propagators/jaeger"
@@ -21,30 +23,119 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/credentials"
 )
 
+// ServiceName identifies the application in the exported telemetry
+const ServiceName = "github.com/canonical/identity-platform-login-ui"
+
 type Tracer struct {
-	tracer trace.Tracer
+	tracer   trace.Tracer
+	provider *sdktrace.TracerProvider
 
 	logger logging.LoggerInterface
 }
 
-func (t *Tracer) init(service string, e sdktrace.SpanExporter) {
+func (t *Tracer) init(service string, e sdktrace.SpanExporter, cfg *Config) {
 	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
 		sdktrace.WithBatcher(e),
 		sdktrace.WithResource(
-			t.buildResource(service),
+			NewResource(service, cfg.Resource),
 		),
 	)
 
 	otel.SetTracerProvider(traceProvider)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}, jaeger.Jaeger{}))
+	otel.SetTextMapPropagator(t.buildPropagator(cfg.Propagators))
+
+	t.provider = traceProvider
+	t.tracer = traceProvider.Tracer(service)
+}
+
+func (t *Tracer) noop() {
+	t.provider = nil
+	t.tracer = noop.NewTracerProvider().Tracer(ServiceName)
+}
+
+func (t *Tracer) buildPropagator(names []string) propagation.TextMapPropagator {
+	if len(names) == 0 {
+		names = []string{PropagatorTraceContext, PropagatorBaggage, PropagatorJaeger}
+	}
+
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case PropagatorJaeger:
+			propagators = append(propagators, jaeger.Jaeger{})
+		case PropagatorNone, "":
+			continue
+		default:
+			t.logger.Warnf("unknown trace propagator %q, ignoring it", name)
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...)
+}
+
+func (t *Tracer) grpcExporter(endpoint string, cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithHeaders(cfg.Headers),
+	}
+
+	if cfg.TLSEnabled {
+		tlsCfg, err := cfg.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+	} else {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	return otlptrace.New(context.Background(), otlptracegrpc.NewClient(opts...))
+}
+
+func (t *Tracer) httpExporter(endpoint string, cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	}
+
+	if cfg.TLSEnabled {
+		tlsCfg, err := cfg.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+	} else {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
 
-	t.tracer = otel.Tracer(service)
+	return otlptrace.New(context.Background(), otlptracehttp.NewClient(opts...))
 }
 
-func (t *Tracer) gitRevision(settings []debug.BuildSetting) string {
+func (t *Tracer) exporter(cfg *Config) (sdktrace.SpanExporter, error) {
+	switch {
+	case cfg.OtelGRPCEndpoint != "":
+		return t.grpcExporter(cfg.OtelGRPCEndpoint, cfg.Exporter)
+	case cfg.OtelHTTPEndpoint != "":
+		return t.httpExporter(cfg.OtelHTTPEndpoint, cfg.Exporter)
+	case cfg.Exporter.Stdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, nil
+	}
+}
+
+func gitRevision(settings []debug.BuildSetting) string {
 	for _, setting := range settings {
 		if setting.Key == "vcs.revision" {
 			return setting.Value
@@ -54,36 +145,56 @@ func (t *Tracer) gitRevision(settings []debug.BuildSetting) string {
 	return "n/a"
 }
 
-func (t *Tracer) buildResource(service string) *resource.Resource {
-	var res *resource.Resource
-
-	res = resource.NewWithAttributes(
-		semconv.SchemaURL,
-		semconv.ServiceName(service),
+// NewResource describes the running service, it is shared by the traces and metrics exporters
+func NewResource(service string, cfg ResourceConfig) *resource.Resource {
+	attrs := []attribute.KeyValue{
 		semconv.ServiceVersion("n/a"),
-	)
+	}
 
 	if info, ok := debug.ReadBuildInfo(); ok {
 		if service == "" {
 			service = info.Path
 		}
 
-		res = resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(service),
-			attribute.String("git_sha", t.gitRevision(info.Settings)),
+		attrs = []attribute.KeyValue{
+			attribute.String("git_sha", gitRevision(info.Settings)),
 			attribute.String("app", info.Main.Path),
-		)
+		}
+	}
+
+	attrs = append(attrs, semconv.ServiceName(service))
+
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(cfg.Environment))
+	}
+
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+
+	if instanceID != "" {
+		attrs = append(attrs, semconv.ServiceInstanceID(instanceID))
 	}
 
-	return res
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
 }
 
 func (t *Tracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
 	return t.tracer.Start(ctx, spanName, opts...)
 }
 
+// Shutdown flushes any buffered spans and stops the exporter, it is a no-op when tracing is disabled
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t.provider == nil {
+		return nil
+	}
+
+	return t.provider.Shutdown(ctx)
+}
+
 // basic tracer implementation of trace.Tracer, just adding some extra configuration
+// any initialization failure falls back to a noop tracer so callers never deal with a nil tracer
 func NewTracer(cfg *Config) *Tracer {
 	t := new(Tracer)
 
@@ -91,44 +202,30 @@ func NewTracer(cfg *Config) *Tracer {
 
 	// if tracing disabled skip the config
 	if !cfg.Enabled {
-		t.tracer = noop.NewTracerProvider().Tracer("github.com/canonical/identity-platform-login-ui")
+		t.noop()
 
 		return t
 	}
 
-	var err error
-	var exporter sdktrace.SpanExporter
-
-	if cfg.OtelGRPCEndpoint != "" {
-		exporter, err = otlptrace.New(
-			context.TODO(),
-			otlptracegrpc.NewClient(
-				otlptracegrpc.WithEndpoint(cfg.OtelGRPCEndpoint),
-				otlptracegrpc.WithInsecure(),
-			),
-		)
-	} else if cfg.OtelHTTPEndpoint != "" {
-		exporter, err = otlptrace.New(
-			context.TODO(),
-			otlptracehttp.NewClient(
-				otlptracehttp.WithEndpoint(cfg.OtelHTTPEndpoint),
-				otlptracehttp.WithInsecure(),
-			),
-		)
-	} else {
-		exporter, err = stdouttrace.New(
-			stdouttrace.WithPrettyPrint(),
-		)
-	}
+	exporter, err := t.exporter(cfg)
 
 	if err != nil {
-		t.logger.Errorf("unable to initialize tracing exporter due: %w", err)
-		return nil
+		t.logger.Errorf("unable to initialize tracing exporter, falling back to noop tracer: %v", err)
+		t.noop()
+
+		return t
+	}
+
+	if exporter == nil {
+		t.logger.Warn("tracing enabled but no exporter configured, falling back to noop tracer")
+		t.noop()
+
+		return t
 	}
 
 	// set tracer provider and propagator properly, this is to ensure all
 	// instrumentation library could run well
-	t.init("github.com/canonical/identity-platform-login-ui", exporter)
+	t.init(ServiceName, exporter, cfg)
 
 	return t
 }
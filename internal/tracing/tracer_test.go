@@ -0,0 +1,131 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+)
+
+func TestParseHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:     "empty",
+			raw:      "",
+			expected: map[string]string{},
+		},
+		{
+			name:     "multiple headers",
+			raw:      "Authorization=Bearer%20token, x-tenant=abc:def",
+			expected: map[string]string{"Authorization": "Bearer token", "x-tenant": "abc:def"},
+		},
+		{
+			name: "missing value separator",
+			raw:  "Authorization",
+			err:  true,
+		},
+		{
+			name: "invalid encoding",
+			raw:  "Authorization=%zz",
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers, err := ParseHeaders(test.raw)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error, got %v", headers)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(headers, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, headers)
+			}
+		})
+	}
+}
+
+func TestNewTracerFallsBackToNoop(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  *Config
+	}{
+		{
+			name: "disabled",
+			cfg:  NewNoopConfig(),
+		},
+		{
+			name: "no exporter configured",
+			cfg:  NewConfig(true, "", "", ExporterConfig{}, 1, nil, ResourceConfig{}, logging.NewNoopLogger()),
+		},
+		{
+			name: "invalid CA file",
+			cfg:  NewConfig(true, "localhost:4317", "", ExporterConfig{TLSEnabled: true, CAFile: caFile}, 1, nil, ResourceConfig{}, logging.NewNoopLogger()),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracer := NewTracer(test.cfg)
+
+			if tracer == nil {
+				t.Fatal("expected a tracer, got nil")
+			}
+
+			_, span := tracer.Start(context.Background(), "test")
+			defer span.End()
+
+			if span.SpanContext().IsValid() {
+				t.Fatal("expected a noop span")
+			}
+
+			if err := tracer.Shutdown(context.Background()); err != nil {
+				t.Fatalf("unexpected shutdown error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewTracerSamplesByRatio(t *testing.T) {
+	cfg := NewConfig(true, "", "", ExporterConfig{Stdout: true}, 0, []string{PropagatorTraceContext}, ResourceConfig{Environment: "test", InstanceID: "instance-1"}, logging.NewNoopLogger())
+	tracer := NewTracer(cfg)
+
+	_, span := tracer.Start(context.Background(), "test")
+	span.End()
+
+	if !span.SpanContext().IsValid() {
+		t.Fatal("expected a recording tracer")
+	}
+
+	if span.SpanContext().IsSampled() {
+		t.Fatal("expected span to be dropped with a zero sample ratio")
+	}
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+}
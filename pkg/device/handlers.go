@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
 	"github.com/go-chi/chi/v5"
 )
@@ -15,8 +16,9 @@ import (
 type API struct {
 	service ServiceInterface
 
-	tracer tracing.TracingInterface
-	logger logging.LoggerInterface
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
 }
 
 const NOT_FOUND_ERROR_DESC = "The user_code provided is either invalid, expired or already used."
@@ -39,12 +41,16 @@ func (a *API) handleDevice(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		a.logger.Errorf("Failed to accept user code: %v\n", err)
 		if e := err.Error(); e == "404 Not Found" {
+			a.observeDeviceCode(monitoring.OutcomeRejected)
 			http.Error(w, NOT_FOUND_ERROR_DESC, http.StatusBadRequest)
 		} else {
+			a.observeDeviceCode(monitoring.OutcomeFailure)
 			http.Error(w, "Failed to accept user code", http.StatusInternalServerError)
 		}
 		return
 	}
+
+	a.observeDeviceCode(monitoring.OutcomeSuccess)
 	resp, err := json.Marshal(deviceResp)
 	if err != nil {
 		a.logger.Errorf("Error when marshalling Json: %v\n", err)
@@ -56,12 +62,20 @@ func (a *API) handleDevice(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, logger logging.LoggerInterface) *API {
+// observeDeviceCode records the outcome of a user code submission.
+func (a *API) observeDeviceCode(outcome string) {
+	if err := a.monitor.SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventDeviceCode, "", "", outcome), 1); err != nil {
+		a.logger.Debugf("cannot record auth event metric: %v", err)
+	}
+}
+
+func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
 	a := new(API)
 
 	a.service = service
 
 	a.tracer = tracer
+	a.monitor = monitor
 	a.logger = logger
 
 	return a
@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/canonical/identity-platform-login-ui/internal/hydra"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/go-chi/chi/v5"
 	hClient "github.com/ory/hydra-client-go/v2"
 	"go.uber.org/mock/gomock"
@@ -30,6 +31,7 @@ func TestHandleDeviceUserCodeAcceptSuccess(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockService := NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
 
 	accept := hClient.NewOAuth2RedirectTo("test")
 
@@ -48,8 +50,10 @@ func TestHandleDeviceUserCodeAcceptSuccess(t *testing.T) {
 	mockService.EXPECT().ParseUserCodeBody(gomock.Any()).Return(userCodeRequest, nil)
 	mockService.EXPECT().AcceptUserCode(gomock.Any(), challenge, userCodeRequest).Return(accept, nil)
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventDeviceCode, "", "", monitoring.OutcomeSuccess), 1.0).Return(nil)
+
 	mux := chi.NewMux()
-	NewAPI(mockService, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
@@ -96,7 +100,7 @@ func TestHandleDeviceUserCodeAcceptParseUserCodeBodyFailure(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
@@ -132,7 +136,7 @@ func TestHandleDeviceUserCodeAcceptAcceptUserCodeFailure(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
@@ -150,6 +154,7 @@ func TestHandleDeviceUserCodeInvalidCode(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockService := NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
 
 	code := "ABCDEFGH"
 	challenge := "7bb518c4eec2454dbb289f5fdb4c0ee2"
@@ -170,8 +175,10 @@ func TestHandleDeviceUserCodeInvalidCode(t *testing.T) {
 	mockService.EXPECT().AcceptUserCode(gomock.Any(), challenge, userCodeRequest).Return(nil, err)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventDeviceCode, "", "", monitoring.OutcomeRejected), 1.0).Return(nil)
+
 	mux := chi.NewMux()
-	NewAPI(mockService, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
@@ -218,7 +225,7 @@ func TestHandleDeviceUserCodeUnexpectedError(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 	w := httptest.NewRecorder()
 	mux.ServeHTTP(w, req)
 
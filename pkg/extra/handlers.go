@@ -11,8 +11,10 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
 	"github.com/canonical/identity-platform-login-ui/pkg/kratos"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 )
@@ -21,12 +23,13 @@ type API struct {
 	service ServiceInterface
 	kratos  kratos.ServiceInterface
 
-	baseURL                       string
-	oidcWebAuthnSequencingEnabled bool
-	mfaEnabled                    bool
-	contextPath                   string
-	tracer                        tracing.TracingInterface
-	logger                        logging.LoggerInterface
+	mfaPolicy MFAPolicyInterface
+
+	baseURL     string
+	contextPath string
+	tracer      tracing.TracingInterface
+	monitor     monitoring.MonitorInterface
+	logger      logging.LoggerInterface
 }
 
 func (a *API) RegisterEndpoints(mux *chi.Mux) {
@@ -45,12 +48,6 @@ func (a *API) handleConsent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if session.GetAuthenticatorAssuranceLevel() < a.sessionRequiredAAL(session) {
-		a.logger.Errorf("insufficient session aal, this indicates a misconfiguration in kratos")
-		http.Error(w, "insufficient session aal", http.StatusForbidden)
-		return
-	}
-
 	consentChallenge := r.URL.Query().Get("consent_challenge")
 	if consentChallenge == "" {
 		err = fmt.Errorf("no consent challenge present")
@@ -71,14 +68,34 @@ func (a *API) handleConsent(w http.ResponseWriter, r *http.Request) {
 
 	tenantID := a.resolveTenantID(consent)
 
+	// the policy is evaluated again to catch sessions that skipped the
+	// MFA checks at login, e.g. when hydra remembered the login, the tenant
+	// is the one the login was accepted with, as at login
+	requirement, err := a.mfaPolicy.Evaluate(r.Context(), a.mfaSubject(session, consent, tenantID))
+	if err != nil {
+		a.logger.Errorf("error when evaluating mfa policy: %s", err)
+		http.Error(w, "failed to evaluate mfa policy", http.StatusInternalServerError)
+		return
+	}
+
+	if !requirement.SatisfiedBy(session) {
+		a.logger.Errorf("insufficient session aal, this indicates a misconfiguration in kratos")
+		a.observeConsent(consent, monitoring.OutcomeRejected)
+		http.Error(w, "insufficient session aal", http.StatusForbidden)
+		return
+	}
+
 	accept, err := a.service.AcceptConsent(r.Context(), *session.Identity, consent, tenantID)
 	if err != nil {
 		a.logger.Errorf("error when calling hydra: %s", err)
+		a.observeConsent(consent, monitoring.OutcomeFailure)
 		// TODO @shipperizer evaluate return status
 		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
+	a.observeConsent(consent, monitoring.OutcomeSuccess)
+
 	rr, err := accept.MarshalJSON()
 	if err != nil {
 		a.logger.Errorf("error when marshalling json: %s", err)
@@ -104,40 +121,51 @@ func (a *API) resolveTenantID(consent *hClient.OAuth2ConsentRequest) string {
 	return ""
 }
 
-// sessionRequiredAAL returns the required aal, based on the session's authentication methods.
-func (a *API) sessionRequiredAAL(session *kClient.Session) kClient.AuthenticatorAssuranceLevel {
-	var authMethod string
-	ret := kClient.AUTHENTICATORASSURANCELEVEL_AAL1
+// mfaSubject collects the attributes the MFA policy is evaluated against.
+func (a *API) mfaSubject(session *kClient.Session, consent *hClient.OAuth2ConsentRequest, tenantID string) mfa.Subject {
+	subject := mfa.Subject{
+		TenantID: tenantID,
+	}
 
-	if methods, ok := session.GetAuthenticationMethodsOk(); ok {
-		authMethod = methods[0].GetMethod()
+	if c, ok := consent.GetClientOk(); ok {
+		subject.ClientID = c.GetClientId()
+		subject.ClientName = c.GetClientName()
 	}
 
-	switch authMethod {
-	case "oidc":
-		if a.oidcWebAuthnSequencingEnabled {
-			ret = kClient.AUTHENTICATORASSURANCELEVEL_AAL2
-		}
-	case "password", "webauthn":
-		if a.mfaEnabled {
-			ret = kClient.AUTHENTICATORASSURANCELEVEL_AAL2
-		}
+	if oidcContext, ok := consent.GetOidcContextOk(); ok {
+		subject.RequestedACR = oidcContext.AcrValues
 	}
 
-	return ret
+	if session.Identity != nil {
+		subject.IdentityID = session.Identity.GetId()
+		subject.SchemaID = session.Identity.GetSchemaId()
+	}
+
+	if methods := session.GetAuthenticationMethods(); len(methods) > 0 {
+		subject.FirstFactor = methods[0].GetMethod()
+	}
+
+	return subject
+}
+
+// observeConsent records the outcome of a consent request.
+func (a *API) observeConsent(consent *hClient.OAuth2ConsentRequest, outcome string) {
+	tags := monitoring.AuthEventTags(monitoring.EventConsent, "", consent.Client.GetClientId(), outcome)
+	if err := a.monitor.SetAuthEventMetric(tags, 1); err != nil {
+		a.logger.Debugf("cannot record auth event metric: %v", err)
+	}
 }
 
-func NewAPI(service ServiceInterface, kratos kratos.ServiceInterface, baseURL string, mfaEnabled, oidcWebAuthnSequencingEnabled bool, tracer tracing.TracingInterface, logger logging.LoggerInterface) *API {
+func NewAPI(service ServiceInterface, kratos kratos.ServiceInterface, mfaPolicy MFAPolicyInterface, baseURL string, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
 	a := new(API)
 
 	a.service = service
 	a.kratos = kratos
+	a.mfaPolicy = mfaPolicy
 
 	a.logger = logger
 
 	a.baseURL = baseURL
-	a.oidcWebAuthnSequencingEnabled = oidcWebAuthnSequencingEnabled
-	a.mfaEnabled = mfaEnabled
 
 	fullBaseURL, err := url.Parse(baseURL)
 	if err != nil {
@@ -146,6 +174,7 @@ func NewAPI(service ServiceInterface, kratos kratos.ServiceInterface, baseURL st
 	}
 	a.contextPath = fullBaseURL.Path
 	a.tracer = tracer
+	a.monitor = monitor
 
 	return a
 }
@@ -17,7 +17,9 @@ import (
 	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/pkg/kratos"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 )
 
 const BASE_URL = "https://example.com"
@@ -34,11 +36,15 @@ func TestHandleConsentSuccess(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
 
 	session := kClient.NewSession("test")
 	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
 	session.SetAuthenticatorAssuranceLevel(kClient.AUTHENTICATORASSURANCELEVEL_AAL1)
 	consent := hClient.NewOAuth2ConsentRequest("challenge")
+	consent.Client = hClient.NewOAuth2Client()
+	consent.Client.SetClientId("client-1")
 	accept := hClient.NewOAuth2RedirectTo("test")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/consent", nil)
@@ -51,10 +57,13 @@ func TestHandleConsentSuccess(t *testing.T) {
 
 	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
 	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(consent, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil)
 	mockService.EXPECT().AcceptConsent(gomock.Any(), *session.Identity, consent, gomock.Any()).Return(accept, nil)
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventConsent, "", "client-1", monitoring.OutcomeSuccess), 1.0).Return(nil)
+
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, false, false, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -89,6 +98,7 @@ func TestHandleConsentWhenOIDCSequencingEnabled(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	session := kClient.NewSessionWithDefaults()
 	session.SetId("test")
@@ -96,8 +106,10 @@ func TestHandleConsentWhenOIDCSequencingEnabled(t *testing.T) {
 	session.SetAuthenticatorAssuranceLevel(kClient.AUTHENTICATORASSURANCELEVEL_AAL2)
 
 	method := "oidc"
+	secondMethod := "webauthn"
 	var authnMethods []kClient.SessionAuthenticationMethod
 	authnMethods = append(authnMethods, kClient.SessionAuthenticationMethod{Method: &method})
+	authnMethods = append(authnMethods, kClient.SessionAuthenticationMethod{Method: &secondMethod})
 	session.AuthenticationMethods = authnMethods
 
 	consent := hClient.NewOAuth2ConsentRequest("challenge")
@@ -113,10 +125,11 @@ func TestHandleConsentWhenOIDCSequencingEnabled(t *testing.T) {
 
 	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
 	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(consent, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{mfa.MethodWebAuthn}}, nil)
 	mockService.EXPECT().AcceptConsent(gomock.Any(), *session.Identity, consent, gomock.Any()).Return(accept, nil)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, true, true, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -151,6 +164,8 @@ func TestHandleConsentInvalidPasswordAAL(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
 
 	session := kClient.NewSessionWithDefaults()
 	session.SetId("test")
@@ -171,10 +186,14 @@ func TestHandleConsentInvalidPasswordAAL(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(hClient.NewOAuth2ConsentRequest("challenge"), nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2}, nil)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventConsent, "", "", monitoring.OutcomeRejected), 1.0).Return(nil)
+
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, true, true, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -193,6 +212,7 @@ func TestHandleConsentInvalidOIDCAAL(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	session := kClient.NewSessionWithDefaults()
 	session.SetId("test")
@@ -213,10 +233,12 @@ func TestHandleConsentInvalidOIDCAAL(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(hClient.NewOAuth2ConsentRequest("challenge"), nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{mfa.MethodWebAuthn}}, nil)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, true, true, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -227,6 +249,70 @@ func TestHandleConsentInvalidOIDCAAL(t *testing.T) {
 	}
 }
 
+func TestHandleConsentSecondFactorNotAllowedForClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockKratosService := kratos.NewMockServiceInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	session := kClient.NewSessionWithDefaults()
+	session.SetId("test")
+	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	session.SetAuthenticatorAssuranceLevel(kClient.AUTHENTICATORASSURANCELEVEL_AAL2)
+
+	firstMethod := "password"
+	secondMethod := "totp"
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &firstMethod}, {Method: &secondMethod}}
+
+	client := hClient.NewOAuth2Client()
+	client.SetClientId("admin-console-id")
+	client.SetClientName("admin-console")
+	consent := hClient.NewOAuth2ConsentRequest("challenge")
+	consent.SetClient(*client)
+	consent.SetContext(map[string]interface{}{"tenant_id": "tenant-1"})
+	oidcContext := hClient.NewOAuth2ConsentRequestOpenIDConnectContext()
+	oidcContext.SetAcrValues([]string{"phr"})
+	consent.SetOidcContext(*oidcContext)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/consent", nil)
+
+	values := req.URL.Query()
+	values.Add("consent_challenge", "7bb518c4eec2454dbb289f5fdb4c0ee2")
+	req.URL.RawQuery = values.Encode()
+
+	w := httptest.NewRecorder()
+
+	expectedSubject := mfa.Subject{
+		ClientID:     "admin-console-id",
+		ClientName:   "admin-console",
+		TenantID:     "tenant-1",
+		IdentityID:   "test",
+		SchemaID:     "test.json",
+		FirstFactor:  "password",
+		RequestedACR: []string{"phr"},
+	}
+
+	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(consent, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), expectedSubject).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{mfa.MethodWebAuthn}}, nil)
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected HTTP status code 403 got %v", res.StatusCode)
+	}
+}
+
 func TestHandleConsentFailOnAcceptConsent(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -235,6 +321,7 @@ func TestHandleConsentFailOnAcceptConsent(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	session := kClient.NewSession("test")
 	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
@@ -251,11 +338,12 @@ func TestHandleConsentFailOnAcceptConsent(t *testing.T) {
 
 	mockKratosService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
 	mockService.EXPECT().GetConsent(gomock.Any(), "7bb518c4eec2454dbb289f5fdb4c0ee2").Return(consent, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil)
 	mockService.EXPECT().AcceptConsent(gomock.Any(), *session.Identity, consent, gomock.Any()).Return(nil, fmt.Errorf("error"))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, false, false, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -274,6 +362,7 @@ func TestHandleConsentFailOnGetConsent(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	session := kClient.NewSession("test")
 	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
@@ -292,7 +381,7 @@ func TestHandleConsentFailOnGetConsent(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, false, false, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -311,6 +400,7 @@ func TestHandleConsentFailOnCheckSession(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockKratosService := kratos.NewMockServiceInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/consent", nil)
 
@@ -324,7 +414,7 @@ func TestHandleConsentFailOnCheckSession(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, mockKratosService, BASE_URL, false, false, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockKratosService, mockMFAPolicy, BASE_URL, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -10,6 +10,7 @@ import (
 	kClient "github.com/ory/kratos-client-go/v25"
 
 	"github.com/canonical/identity-platform-login-ui/internal/hydra"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 )
 
 type HydraClientInterface interface {
@@ -20,3 +21,8 @@ type ServiceInterface interface {
 	GetConsent(context.Context, string) (*hClient.OAuth2ConsentRequest, error)
 	AcceptConsent(context.Context, kClient.Identity, *hClient.OAuth2ConsentRequest, string) (*hClient.OAuth2RedirectTo, error)
 }
+
+// MFAPolicyInterface decides which second factors a session must have gone through.
+type MFAPolicyInterface interface {
+	Evaluate(context.Context, mfa.Subject) (*mfa.Requirement, error)
+}
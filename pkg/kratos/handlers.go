@@ -14,13 +14,16 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	hClient "github.com/ory/hydra-client-go/v2"
 	client "github.com/ory/kratos-client-go/v25"
 
 	httpHelpers "github.com/canonical/identity-platform-login-ui/internal/misc/http"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 	"github.com/canonical/identity-platform-login-ui/pkg/ui"
 )
@@ -35,17 +38,19 @@ const LOGIN_UI_STATE_COOKIE = "login_ui_state"
 const SECURITY_CSRF_VIOLATION_ERROR = "security_csrf_violation"
 
 type API struct {
-	verificationEnabled           bool
-	mfaEnabled                    bool
-	oidcWebAuthnSequencingEnabled bool
-	service                       ServiceInterface
-	baseURL                       string
-	contextPath                   string
-	cookieManager                 AuthCookieManagerInterface
-	tenantMgr                     TenantResolverInterface
-
-	tracer tracing.TracingInterface
-	logger logging.LoggerInterface
+	verificationEnabled  bool
+	passkeyEnabled       bool
+	authorizationEnabled bool
+	mfaPolicy            MFAPolicyInterface
+	service              ServiceInterface
+	baseURL              string
+	contextPath          string
+	cookieManager        AuthCookieManagerInterface
+	tenantMgr            TenantResolverInterface
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
 }
 
 type KratosErrorResponse struct {
@@ -59,6 +64,9 @@ func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Post("/api/kratos/self-service/login/id-first", a.handleUpdateIdentifierFirstFlow)
 	mux.Get("/api/kratos/self-service/login/browser", a.handleCreateFlow)
 	mux.Get("/api/kratos/self-service/login/flows", a.handleGetLoginFlow)
+	if a.passkeyEnabled {
+		mux.Get("/api/kratos/self-service/login/passkey", a.handleGetPasskeyLoginOptions)
+	}
 	mux.Post("/api/kratos/self-service/registration", a.handleUpdateRegistrationFlow)
 	mux.Get("/api/kratos/self-service/registration/browser", a.handleCreateRegistrationFlow)
 	mux.Get("/api/kratos/self-service/registration/flows", a.handleGetRegistrationFlow)
@@ -72,16 +80,16 @@ func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Post("/api/kratos/self-service/settings", a.handleUpdateSettingsFlow)
 	mux.Get("/api/kratos/self-service/settings/browser", a.handleCreateSettingsFlow)
 	mux.Get("/api/kratos/self-service/settings/flows", a.handleGetSettingsFlow)
+	mux.Get("/api/kratos/self-service/settings/backup-codes", a.handleGetBackupCodes)
+	mux.Post("/api/kratos/self-service/settings/backup-codes/remind-later", a.handleBackupCodesRemindLater)
 }
 
 // TODO: Validate response when server error handling is implemented
 func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 	var (
-		response              any
-		shouldEnforceMfa      = false
-		shouldEnforceWebAuthn = false
-		httpCookies           []*http.Cookie
-		err                   error
+		response    any
+		httpCookies []*http.Cookie
+		err         error
 	)
 
 	q := r.URL.Query()
@@ -121,17 +129,36 @@ func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 	// TODO: We need to send a different content-type to CreateBrowserLoginFlow in order to avoid this bug.
 	session, _, _ := a.service.CheckSession(r.Context(), r.Cookies())
 
+	// the login request is fetched once for the tenant hint and the MFA
+	// policy, both only look at it for an existing session
+	var loginRequest *hClient.OAuth2LoginRequest
+	if session != nil && loginChallenge != "" {
+		loginRequest, _, err = a.service.GetLoginRequest(r.Context(), loginChallenge)
+		if err != nil {
+			a.logger.Errorf("failed to fetch login request: %v", err)
+			http.Error(w, "failed to fetch login request", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Ask the tenant resolver plugin whether the login flow needs special
 	// handling (MFA deferral, tenant selection, or immediate accept).
 	// When multi-tenancy is disabled the plugin returns zero-value fields,
 	// so the handler proceeds unchanged.
-	intercept, err := a.tenantMgr.InterceptLogin(r.Context(), session, c, loginChallenge)
+	intercept, err := a.tenantMgr.InterceptLogin(r.Context(), session, c, loginChallenge, loginRequest)
 	if err != nil {
 		a.logger.Errorf("failed to evaluate login interception: %v", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
+	// stepUp is set when the session lacks the second factor required for
+	// this login, acr is the requested ACR value the session achieves
+	var (
+		stepUp bool
+		acr    string
+	)
+
 	if session != nil {
 		flowCookie := c
 		if loginChallenge != "" {
@@ -150,42 +177,52 @@ func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if !intercept.DeferMFAChecks {
-			shouldEnforceMfa, err = a.shouldEnforceMFAWithSession(r.Context(), session)
+			// the tenant is the one the login is accepted with
+			requirement, mfaSetup, err := a.loginMFARequirement(r.Context(), session, intercept.Cookie, loginChallenge, loginRequest)
 			if err != nil {
 				a.logger.Errorf("failed to check MFA status: %v", err)
 				http.Error(w, "failed to check MFA status", http.StatusInternalServerError)
 				return
 			}
-			if shouldEnforceMfa {
-				a.mfaSettingsRedirect(w, r, returnTo, flowCookie)
-				return
-			}
 
-			shouldEnforceWebAuthn, err = a.shouldEnforceWebAuthnWithSession(r.Context(), session)
-			if err != nil {
-				a.logger.Errorf("failed to check WebAuthn status: %v", err)
-				http.Error(w, "failed to check WebAuthn status", http.StatusInternalServerError)
+			switch mfaSetup {
+			case mfa.MethodTOTP:
+				a.mfaSettingsRedirect(w, r, returnTo, flowCookie)
 				return
-			}
-			if shouldEnforceWebAuthn {
+			case mfa.MethodWebAuthn:
 				a.webAuthnSettingsRedirect(w, r, returnTo, flowCookie)
 				return
 			}
+
+			if loginChallenge != "" && !requirement.SatisfiedBy(session) {
+				stepUp = true
+				aal = string(requirement.AAL)
+				// kratos only prompts again for a level the session already has on refresh
+				refresh = refresh || session.GetAuthenticatorAssuranceLevel() >= requirement.AAL
+			}
+
+			acr = requirement.AchievedACR(session)
 		}
 	}
 
+	// The session does not meet the MFA policy for this login, ask for the
+	// second factor before accepting it.
+	if stepUp {
+		response, httpCookies, err = a.handleCreateFlowNewSession(r, aal, returnTo, loginChallenge, refresh, session, c)
+	}
+
 	// When the tenant resolver has confirmed the user is authenticated for
 	// this specific challenge (cookie hash matches), honor the decision
 	// immediately — MustReAuthenticate is unnecessary because the user
 	// already completed auth for this challenge.
-	if !intercept.DeferMFAChecks {
+	if !intercept.DeferMFAChecks && !stepUp {
 		if intercept.SelectTenant {
-			a.tenantSelectionRedirect(w, r, loginChallenge)
+			a.tenantSelectionRedirect(w, r, loginChallenge, intercept.Cookie.TenantHintError)
 			return
 		}
 		if intercept.AcceptLogin {
 			c = intercept.Cookie
-			response, httpCookies, err = a.handleCreateFlowWithSession(w, r, session, loginChallenge, c)
+			response, httpCookies, err = a.handleCreateFlowWithSession(w, r, session, loginChallenge, c, acr)
 		}
 	}
 
@@ -193,7 +230,7 @@ func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 	// flow (cookie doesn't match this challenge). We must still consult
 	// MustReAuthenticate because Hydra may demand re-auth (max_age=0).
 	// Only if Hydra says skip=true do we honour SelectTenant/AcceptLogin.
-	if intercept.DeferMFAChecks || (!intercept.AcceptLogin && !intercept.SelectTenant) {
+	if !stepUp && (intercept.DeferMFAChecks || (!intercept.AcceptLogin && !intercept.SelectTenant)) {
 		var forceLogin bool
 		forceLogin, err = a.service.MustReAuthenticate(r.Context(), loginChallenge, session, c)
 		if err != nil {
@@ -204,15 +241,48 @@ func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 
 		if !forceLogin {
 			if intercept.SelectTenant {
-				a.tenantSelectionRedirect(w, r, loginChallenge)
+				a.tenantSelectionRedirect(w, r, loginChallenge, intercept.Cookie.TenantHintError)
 				return
 			}
 			if intercept.AcceptLogin {
 				c = intercept.Cookie
 			}
-			response, httpCookies, err = a.handleCreateFlowWithSession(w, r, session, loginChallenge, c)
+
+			// The MFA checks were deferred until the tenant of the login was
+			// known, the session has to meet the policy of that tenant as
+			// consent evaluates it again.
+			if intercept.DeferMFAChecks && session != nil {
+				requirement, mfaSetup, err := a.loginMFARequirement(r.Context(), session, c, loginChallenge, loginRequest)
+				if err != nil {
+					a.logger.Errorf("failed to check MFA status: %v", err)
+					http.Error(w, "failed to check MFA status", http.StatusInternalServerError)
+					return
+				}
+
+				switch mfaSetup {
+				case mfa.MethodTOTP:
+					a.mfaSettingsRedirect(w, r, returnTo, c)
+					return
+				case mfa.MethodWebAuthn:
+					a.webAuthnSettingsRedirect(w, r, returnTo, c)
+					return
+				}
+
+				if !requirement.SatisfiedBy(session) {
+					stepUp = true
+					aal = string(requirement.AAL)
+					refresh = refresh || session.GetAuthenticatorAssuranceLevel() >= requirement.AAL
+				}
+				acr = requirement.AchievedACR(session)
+			}
+
+			if stepUp {
+				response, httpCookies, err = a.handleCreateFlowNewSession(r, aal, returnTo, loginChallenge, refresh, session, c)
+			} else {
+				response, httpCookies, err = a.handleCreateFlowWithSession(w, r, session, loginChallenge, c, acr)
+			}
 		} else {
-			response, httpCookies, err = a.handleCreateFlowNewSession(r, aal, returnTo, loginChallenge, refresh, session)
+			response, httpCookies, err = a.handleCreateFlowNewSession(r, aal, returnTo, loginChallenge, refresh, session, c)
 		}
 	}
 
@@ -249,7 +319,7 @@ func (a *API) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(response)
 }
 
-func (a *API) handleCreateFlowNewSession(r *http.Request, aal, returnTo, loginChallenge string, refresh bool, session *client.Session) (*client.LoginFlow, []*http.Cookie, error) {
+func (a *API) handleCreateFlowNewSession(r *http.Request, aal, returnTo, loginChallenge string, refresh bool, session *client.Session, stateCookie cookies.FlowStateCookie) (*client.LoginFlow, []*http.Cookie, error) {
 	// redirect user to this endpoint with the login_challenge after login
 	// see https://github.com/ory/kratos/issues/3052
 
@@ -274,7 +344,8 @@ func (a *API) handleCreateFlowNewSession(r *http.Request, aal, returnTo, loginCh
 		return nil, nil, fmt.Errorf("failed to create login flow, err: %w", err)
 	}
 
-	flow, err = a.service.FilterFlowProviderList(r.Context(), flow)
+	// the providers are restricted to the ones allowed for the selected tenant
+	flow, err = a.service.FilterFlowProviderList(r.Context(), flow, a.tenantMgr.TenantID(stateCookie, loginChallenge))
 	if err != nil {
 		return nil, nil, fmt.Errorf("error when filtering providers: %v\n", err)
 	}
@@ -282,10 +353,10 @@ func (a *API) handleCreateFlowNewSession(r *http.Request, aal, returnTo, loginCh
 	return flow, cookies, nil
 }
 
-func (a *API) handleCreateFlowWithSession(w http.ResponseWriter, r *http.Request, session *client.Session, loginChallenge string, stateCookie cookies.FlowStateCookie) (*BrowserLocationChangeRequired, []*http.Cookie, error) {
+func (a *API) handleCreateFlowWithSession(w http.ResponseWriter, r *http.Request, session *client.Session, loginChallenge string, stateCookie cookies.FlowStateCookie, acr string) (*BrowserLocationChangeRequired, []*http.Cookie, error) {
 	tenantID := a.tenantMgr.TenantID(stateCookie, loginChallenge)
 
-	response, cookies, err := a.service.AcceptLoginRequest(r.Context(), session, loginChallenge, tenantID)
+	response, cookies, err := a.service.AcceptLoginRequest(r.Context(), session, loginChallenge, tenantID, acr)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to accept login request: %w", err)
 	}
@@ -370,7 +441,7 @@ func (a *API) handleGetLoginFlow(w http.ResponseWriter, r *http.Request) {
 		_, kratosSessionErr := r.Cookie(KRATOS_SESSION_COOKIE_NAME)
 		c, cookieErr := a.cookieManager.GetStateCookie(r)
 		if kratosSessionErr == nil && cookieErr == nil && a.tenantMgr.IsAuthenticatedForChallenge(c, lc) && a.tenantMgr.TenantID(c, lc) == "" {
-			rt, err := a.tenantSelectionURL(lc)
+			rt, err := a.tenantSelectionURL(lc, "")
 			if err != nil {
 				a.logger.Errorf("failed to build tenant-selection redirect: %v", err)
 				http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -388,11 +459,49 @@ func (a *API) handleGetLoginFlow(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if !a.passkeyEnabled {
+		flow.Ui.Nodes = withoutPasskeyNodes(flow.Ui.Nodes)
+	}
+
 	setCookies(w, flowCookies)
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(flow)
 }
 
+// handleGetPasskeyLoginOptions returns the WebAuthn assertion options of a
+// login flow so that the UI can offer passkeys through conditional mediation.
+func (a *API) handleGetPasskeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	flowId := r.URL.Query().Get("flow")
+	if flowId == "" {
+		http.Error(w, "Missing flow ID", http.StatusBadRequest)
+		return
+	}
+
+	flow, flowCookies, err := a.service.GetLoginFlow(r.Context(), flowId, r.Cookies())
+	if err != nil {
+		a.logger.Errorf("Error when getting login flow: %v\n", err)
+		http.Error(w, "Failed to get login flow", http.StatusInternalServerError)
+		return
+	}
+
+	options, err := a.service.GetPasskeyLoginOptions(r.Context(), flow)
+	if err != nil {
+		a.logger.Errorf("Error when getting passkey login options: %v\n", err)
+		http.Error(w, "Failed to get passkey login options", http.StatusInternalServerError)
+		return
+	}
+
+	if options == nil {
+		http.Error(w, "Passkey login is not available for this flow", http.StatusNotFound)
+		return
+	}
+
+	setCookies(w, flowCookies)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(options)
+}
+
 func (a *API) handleCreateRegistrationFlow(w http.ResponseWriter, r *http.Request) {
 	returnTo := r.URL.Query().Get("return_to")
 
@@ -403,6 +512,24 @@ func (a *API) handleCreateRegistrationFlow(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	tenantID, err := a.registrationTenantID(r, flow)
+	if err != nil {
+		a.logger.Errorf("failed to read state cookie: %v", err)
+		http.Error(w, "failed to read state cookie", http.StatusInternalServerError)
+		return
+	}
+
+	flow, err = a.service.FilterRegistrationFlowProviderList(r.Context(), flow, tenantID)
+	if errors.Is(err, ErrRegistrationNotAllowed) {
+		http.Error(w, "Registration not allowed", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error when filtering providers: %v", err)
+		http.Error(w, "Failed to create registration flow", http.StatusInternalServerError)
+		return
+	}
+
 	setCookies(w, cookies)
 	w.WriteHeader(http.StatusOK)
 
@@ -428,6 +555,24 @@ func (a *API) handleGetRegistrationFlow(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tenantID, err := a.registrationTenantID(r, flow)
+	if err != nil {
+		a.logger.Errorf("failed to read state cookie: %v", err)
+		http.Error(w, "failed to read state cookie", http.StatusInternalServerError)
+		return
+	}
+
+	flow, err = a.service.FilterRegistrationFlowProviderList(r.Context(), flow, tenantID)
+	if errors.Is(err, ErrRegistrationNotAllowed) {
+		http.Error(w, "Registration not allowed", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		a.logger.Errorf("Error when filtering providers: %v", err)
+		http.Error(w, "Failed to get registration flow", http.StatusInternalServerError)
+		return
+	}
+
 	setCookies(w, cookies)
 	w.WriteHeader(http.StatusOK)
 	toMap, _ := flow.ToMap()
@@ -451,19 +596,75 @@ func (a *API) handleUpdateRegistrationFlow(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	method := flowMethod(body.GetActualInstance())
+
+	if a.authorizationEnabled {
+		// the flow carries the app the registration is restricted for
+		registrationFlow, _, err := a.service.GetRegistrationFlow(r.Context(), flowId, r.Cookies())
+		if err != nil {
+			a.logger.Errorf("Error when getting registration flow: %v\n", err)
+			http.Error(w, "Failed to get registration flow", http.StatusInternalServerError)
+			return
+		}
+
+		tenantID, err := a.registrationTenantID(r, registrationFlow)
+		if err != nil {
+			a.logger.Errorf("failed to read state cookie: %v", err)
+			http.Error(w, "failed to read state cookie", http.StatusInternalServerError)
+			return
+		}
+
+		allowed, err := a.service.CheckAllowedRegistration(r.Context(), registrationFlow, body, tenantID)
+		if err != nil {
+			a.logger.Errorf("Error when authorizing registration: %v\n", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+
+		if !allowed {
+			a.observeAuthEvent(monitoring.EventRegistration, method, "", monitoring.OutcomeRejected)
+			http.Error(w, "Registration method not allowed", http.StatusForbidden)
+			return
+		}
+	}
+
 	registration, cookies, err := a.service.UpdateRegistrationFlow(r.Context(), flowId, *body, r.Cookies())
 	if err != nil {
 		a.logger.Errorf("Error when updating registration flow: %v\n", err)
+		a.observeAuthEvent(monitoring.EventRegistration, method, "", monitoring.OutcomeFailure)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// kratos returns the flow again when the submitted data is invalid
+	if registration.inProgressFlow != nil {
+		a.observeAuthEvent(monitoring.EventRegistration, method, "", monitoring.OutcomeFailure)
+	} else {
+		a.observeAuthEvent(monitoring.EventRegistration, method, "", monitoring.OutcomeSuccess)
+	}
+
 	setCookies(w, cookies)
 	toEncode, status := registration.GetFlowAndStatus()
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(toEncode)
 }
 
+// registrationTenantID returns the tenant selected for the login challenge of
+// a registration flow, empty when there is none or multi-tenancy is disabled
+func (a *API) registrationTenantID(r *http.Request, flow *client.RegistrationFlow) (string, error) {
+	lc := flow.GetOauth2LoginChallenge()
+	if lc == "" || !a.tenantMgr.Enabled() {
+		return "", nil
+	}
+
+	stateCookie, err := a.cookieManager.GetStateCookie(r)
+	if err != nil {
+		return "", err
+	}
+
+	return a.tenantMgr.TenantID(stateCookie, lc), nil
+}
+
 func (a *API) handleUpdateIdentifierFirstFlow(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	flowId := q.Get("flow")
@@ -499,7 +700,7 @@ func (a *API) handleUpdateIdentifierFirstFlow(w http.ResponseWriter, r *http.Req
 
 		lc := loginFlow.GetOauth2LoginChallenge()
 		if lc != "" {
-			if err := a.checkTenantSelectionByEmail(w, r, body.Identifier, lc, flowId); err != nil {
+			if err := a.checkTenantSelectionByEmail(w, r, body.Identifier, lc, flowId, loginFlow.Oauth2LoginRequest); err != nil {
 				return
 			}
 		}
@@ -538,20 +739,14 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allowed, err := a.service.CheckAllowedProvider(r.Context(), loginFlow, body)
-	if err != nil {
-		a.logger.Errorf("Error when authorizing provider: %v\n", err)
-		http.Error(w, "Server error", http.StatusInternalServerError)
+	if body.UpdateLoginFlowWithPasskeyMethod != nil && !a.passkeyEnabled {
+		a.observeLogin(loginFlow, body, monitoring.OutcomeRejected)
+		http.Error(w, "Passkey login is not enabled", http.StatusBadRequest)
 		return
 	}
 
-	if !allowed {
-		http.Error(w, "Provider not allowed", http.StatusForbidden)
-		return
-	}
-
-	// Inject tenant_id into transient_payload so the Kratos post-login webhook
-	// can include it in the tenant service notification.
+	// The state cookie carries the selected tenant, used to restrict the
+	// allowed providers and passed on to the post-login webhook.
 	// Use Oauth2LoginChallenge directly — it's always set on Hydra-initiated flows,
 	// including TOTP continuation flows where ReturnTo may not carry login_challenge.
 	stateCookie, err := a.cookieManager.GetStateCookie(r)
@@ -563,6 +758,21 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 
 	lc := loginFlow.GetOauth2LoginChallenge()
 
+	allowed, err := a.service.CheckAllowedProvider(r.Context(), loginFlow, body, a.tenantMgr.TenantID(stateCookie, lc))
+	if err != nil {
+		a.logger.Errorf("Error when authorizing provider: %v\n", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !allowed {
+		a.observeLogin(loginFlow, body, monitoring.OutcomeRejected)
+		http.Error(w, "Provider not allowed", http.StatusForbidden)
+		return
+	}
+
+	// Inject tenant_id into transient_payload so the Kratos post-login webhook
+	// can include it in the tenant service notification.
 	if lc != "" && a.tenantMgr.Enabled() {
 		tenantID := a.tenantMgr.TenantID(stateCookie, lc)
 		if tenantID != "" && tenantID != cookies.NoTenantAvailable {
@@ -573,6 +783,7 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 	redirectTo, flow, httpCookies, err := a.service.UpdateLoginFlow(r.Context(), flowId, *body, httpCookies)
 	if err != nil {
 		a.logger.Errorf("Error when updating login flow: %v\n", err)
+		a.observeLogin(loginFlow, body, monitoring.OutcomeFailure)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -596,14 +807,28 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shouldEnforceMfa, err := a.shouldEnforceMFAWithSession(r.Context(), session)
+	subject := a.mfaSubject(session, flowCookie, lc)
+	if lr := loginFlow.Oauth2LoginRequest; lr != nil {
+		subject.ClientID = lr.Client.GetClientId()
+		subject.ClientName = lr.Client.GetClientName()
+		subject.RequestedACR = lr.GetOidcContext().AcrValues
+	}
+
+	requirement, err := a.mfaRequirement(r.Context(), session, subject)
+	if err != nil {
+		a.logger.Errorf("MFA policy evaluation error: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	mfaSetup, err := a.shouldEnforceMFAWithSession(r.Context(), session, requirement)
 	if err != nil {
 		a.logger.Errorf("enforce MFA check error: %v", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	shouldRegenerateBackupCodes, err := a.shouldRegenerateBackupCodesWithSession(r.Context(), session)
+	shouldRegenerateBackupCodes, err := a.shouldRegenerateBackupCodesWithSession(r.Context(), session, flowCookie, body.UpdateLoginFlowWithLookupSecretMethod != nil)
 	if err != nil {
 		a.logger.Errorf("backup codes check error: %v", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -639,9 +864,21 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if shouldEnforceMfa {
+	switch mfaSetup {
+	case mfa.MethodTOTP:
 		a.mfaSettingsRedirect(w, r, flowReturnTo, flowCookie)
 		return
+	case mfa.MethodWebAuthn:
+		a.webAuthnSettingsRedirect(w, r, flowReturnTo, flowCookie)
+		return
+	}
+
+	// The user has the second factor required for this login but did not
+	// use it, send them back to handleCreateFlow to step up.
+	if lc != "" && requirement != nil && !requirement.SatisfiedBy(session) {
+		a.cookieManager.SetStateCookie(w, flowCookie)
+		a.redirectResponse(w, r, &BrowserLocationChangeRequired{RedirectTo: &flowReturnTo})
+		return
 	}
 
 	// For Hydra-initiated flows, check whether tenant selection is needed
@@ -658,7 +895,14 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if tenantSession != nil && a.tenantMgr.Enabled() {
-			needsSelection, updatedCookie, err := a.tenantMgr.NeedsTenantSelection(r.Context(), tenantSession, flowCookie, lc)
+			loginRequest, err := hydraLoginRequest(loginFlow.Oauth2LoginRequest)
+			if err != nil {
+				a.logger.Errorf("failed to read login request: %v", err)
+				http.Error(w, "failed to check tenant selection", http.StatusInternalServerError)
+				return
+			}
+
+			needsSelection, updatedCookie, err := a.tenantMgr.NeedsTenantSelection(r.Context(), tenantSession, flowCookie, lc, loginRequest)
 			if err != nil {
 				a.logger.Errorf("failed to check tenant selection: %v", err)
 				http.Error(w, "failed to check tenant selection", http.StatusInternalServerError)
@@ -688,12 +932,18 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		// When Kratos gave a redirect and we didn't handle tenants, fall through
 		// to let the redirect block below follow Kratos's instruction.
 		if redirectTo == nil || (tenantSession != nil && a.tenantMgr.Enabled()) {
-			response, acceptCookies, err := a.handleCreateFlowWithSession(w, r, tenantSession, lc, flowCookie)
+			acr := ""
+			if requirement != nil {
+				acr = requirement.AchievedACR(tenantSession)
+			}
+
+			response, acceptCookies, err := a.handleCreateFlowWithSession(w, r, tenantSession, lc, flowCookie, acr)
 			if err != nil {
 				a.logger.Errorf("failed to accept login request: %v", err)
 				http.Error(w, "failed to accept login request", http.StatusInternalServerError)
 				return
 			}
+			a.observeLogin(loginFlow, body, monitoring.OutcomeSuccess)
 			setCookies(w, acceptCookies)
 			a.redirectResponse(w, r, response)
 			return
@@ -708,6 +958,9 @@ func (a *API) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Kratos returned the session, the login is complete.
+	a.observeLogin(loginFlow, body, monitoring.OutcomeSuccess)
+
 	if a.isHTMLRequest(r) {
 		// redirect to returnTo url instead of returning a json response
 		if returnTo, ok := loginFlow.GetReturnToOk(); ok {
@@ -775,14 +1028,15 @@ func (a *API) shouldEnforceVerificationWithSession(ctx context.Context, session
 }
 
 // tenantSelectionURL builds the tenant-selection page URL for the given login challenge.
-func (a *API) tenantSelectionURL(loginChallenge string) (string, error) {
-	return a.tenantSelectionURLWithFlow(loginChallenge, "")
+func (a *API) tenantSelectionURL(loginChallenge, hintError string) (string, error) {
+	return a.tenantSelectionURLWithFlow(loginChallenge, "", hintError)
 }
 
 // tenantSelectionURLWithFlow builds the tenant-selection page URL including an
 // optional flow ID. When flowID is non-empty the tenant selection page uses it
-// to look up tenants via the Kratos flow (pre-1FA path).
-func (a *API) tenantSelectionURLWithFlow(loginChallenge, flowID string) (string, error) {
+// to look up tenants via the Kratos flow (pre-1FA path). hintError is the
+// reason the tenant hinted by the relying party was rejected, if any.
+func (a *API) tenantSelectionURLWithFlow(loginChallenge, flowID, hintError string) (string, error) {
 	redirect, err := url.JoinPath("/", a.contextPath, "/ui/select_tenant")
 	if err != nil {
 		return "", fmt.Errorf("cannot build tenant-selection path: %w", err)
@@ -798,6 +1052,9 @@ func (a *API) tenantSelectionURLWithFlow(loginChallenge, flowID string) (string,
 	if flowID != "" {
 		q.Set("flow", flowID)
 	}
+	if hintError != "" {
+		q.Set("hint_error", hintError)
+	}
 	redirectTo.RawQuery = q.Encode()
 	return redirectTo.String(), nil
 }
@@ -805,8 +1062,8 @@ func (a *API) tenantSelectionURLWithFlow(loginChallenge, flowID string) (string,
 // The page fetches tenants by verifying the active Kratos session server-side,
 // so no email is passed in the URL (prevents unauthenticated tenant enumeration).
 // The page will call handleTenantSelection once the user has chosen a tenant.
-func (a *API) tenantSelectionRedirect(w http.ResponseWriter, r *http.Request, loginChallenge string) {
-	rt, err := a.tenantSelectionURL(loginChallenge)
+func (a *API) tenantSelectionRedirect(w http.ResponseWriter, r *http.Request, loginChallenge, hintError string) {
+	rt, err := a.tenantSelectionURL(loginChallenge, hintError)
 	if err != nil {
 		a.logger.Errorf("failed to build tenant-selection redirect: %v", err)
 		http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -829,7 +1086,7 @@ func (a *API) tenantSelectionRedirect(w http.ResponseWriter, r *http.Request, lo
 // needs to select a tenant (pre-1FA, by email), persists the updated cookie,
 // and redirects if selection is required. Returns a non-nil error when the
 // caller should stop processing (the response has already been written).
-func (a *API) checkTenantSelectionByEmail(w http.ResponseWriter, r *http.Request, email, loginChallenge, flowId string) error {
+func (a *API) checkTenantSelectionByEmail(w http.ResponseWriter, r *http.Request, email, loginChallenge, flowId string, oauth2LoginRequest *client.OAuth2LoginRequest) error {
 	stateCookie, err := a.cookieManager.GetStateCookie(r)
 	if err != nil {
 		a.logger.Errorf("failed to read state cookie: %v", err)
@@ -837,9 +1094,16 @@ func (a *API) checkTenantSelectionByEmail(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
+	loginRequest, err := hydraLoginRequest(oauth2LoginRequest)
+	if err != nil {
+		a.logger.Errorf("failed to read login request: %v", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return err
+	}
+
 	flowCookie := stateCookie.RenewForChallenge(loginChallenge)
 	needsSelection, updatedCookie, err := a.tenantMgr.NeedsTenantSelectionByEmail(
-		r.Context(), email, flowCookie, loginChallenge,
+		r.Context(), email, flowCookie, loginChallenge, loginRequest,
 	)
 	if err != nil {
 		a.logger.Errorf("failed to check tenant selection: %v", err)
@@ -854,7 +1118,7 @@ func (a *API) checkTenantSelectionByEmail(w http.ResponseWriter, r *http.Request
 	}
 
 	if needsSelection {
-		rt, err := a.tenantSelectionURLWithFlow(loginChallenge, flowId)
+		rt, err := a.tenantSelectionURLWithFlow(loginChallenge, flowId, updatedCookie.TenantHintError)
 		if err != nil {
 			a.logger.Errorf("failed to build tenant selection URL: %v", err)
 			http.Error(w, "internal server error", http.StatusInternalServerError)
@@ -893,6 +1157,7 @@ func (a *API) verificationRedirect(w http.ResponseWriter, r *http.Request, retur
 	rt := redirectTo.String()
 	errorId := VERIFICATION_REQUIRED
 
+	a.observeAuthEvent(monitoring.EventVerificationRedirect, "", "", "")
 	a.cookieManager.SetStateCookie(w, flowStateCookie)
 	a.redirectResponse(w, r, &BrowserLocationChangeRequired{
 		Error:      &client.GenericError{Id: &errorId},
@@ -925,11 +1190,11 @@ func (a *API) redirectResponse(w http.ResponseWriter, r *http.Request, resp Redi
 	}
 }
 
-func (a *API) shouldRegenerateBackupCodesWithSession(ctx context.Context, session *client.Session) (bool, error) {
+func (a *API) shouldRegenerateBackupCodesWithSession(ctx context.Context, session *client.Session, flowCookie cookies.FlowStateCookie, codeSubmitted bool) (bool, error) {
 	ctx, span := a.tracer.Start(ctx, "kratos.API.shouldRegenerateBackupCodesWithSession")
 	defer span.End()
 
-	if !a.mfaEnabled || session == nil {
+	if session == nil {
 		return false, nil
 	}
 
@@ -944,18 +1209,34 @@ func (a *API) shouldRegenerateBackupCodesWithSession(ctx context.Context, sessio
 		return false, nil
 	}
 
-	return a.service.HasNotEnoughLookupSecretsLeft(ctx, session.Identity.GetId())
-}
+	identityID := session.Identity.GetId()
+	backupCodes, err := a.service.GetBackupCodes(ctx, identityID)
+	if err != nil {
+		return false, err
+	}
 
-func (a *API) shouldEnforceMFA(ctx context.Context, cookies []*http.Cookie) (bool, error) {
-	ctx, span := a.tracer.Start(ctx, "kratos.API.shouldEnforceMFA")
-	defer span.End()
+	// the session keeps the method, the event is only emitted for the
+	// submission that used the code
+	if codeSubmitted {
+		a.logger.Security().BackupCodeUsed(identityID, backupCodes.Remaining, logging.WithContext(ctx))
+	}
 
-	if !a.mfaEnabled {
+	if flowCookie.BackupCodesRemindLater {
+		a.logger.Debugf("User asked to be reminded later about regenerating backup codes")
 		return false, nil
 	}
 
-	session, _, err := a.service.CheckSession(ctx, cookies)
+	return backupCodes.Regenerate(), nil
+}
+
+// shouldEnforceMFA reports whether the user has to set up a second factor
+// for the login of loginChallenge, the policy is evaluated against the same
+// subject as the login.
+func (a *API) shouldEnforceMFA(ctx context.Context, httpCookies []*http.Cookie, stateCookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (bool, error) {
+	ctx, span := a.tracer.Start(ctx, "kratos.API.shouldEnforceMFA")
+	defer span.End()
+
+	session, _, err := a.service.CheckSession(ctx, httpCookies)
 	if err != nil {
 		if a.is40xError(err) {
 			a.logger.Debugf("check session failed, err: %v", err)
@@ -965,62 +1246,164 @@ func (a *API) shouldEnforceMFA(ctx context.Context, cookies []*http.Cookie) (boo
 		return false, err
 	}
 
-	return a.shouldEnforceMFAWithSession(ctx, session)
+	_, mfaSetup, err := a.loginMFARequirement(ctx, session, stateCookie, loginChallenge, loginRequest)
+	if err != nil {
+		return false, err
+	}
+
+	return mfaSetup != "", nil
 }
 
-func (a *API) shouldEnforceMFAWithSession(ctx context.Context, session *client.Session) (bool, error) {
+// mfaRequirement evaluates the MFA policy, there is nothing to evaluate
+// without a session.
+func (a *API) mfaRequirement(ctx context.Context, session *client.Session, subject mfa.Subject) (*mfa.Requirement, error) {
+	if session == nil {
+		return nil, nil
+	}
+
+	return a.mfaPolicy.Evaluate(ctx, subject)
+}
+
+// shouldEnforceMFAWithSession returns the second factor the user has to set
+// up to meet the requirement, or an empty string if they already have one.
+func (a *API) shouldEnforceMFAWithSession(ctx context.Context, session *client.Session, requirement *mfa.Requirement) (string, error) {
 	ctx, span := a.tracer.Start(ctx, "kratos.API.shouldEnforceMFAWithSession")
 	defer span.End()
 
-	if !a.mfaEnabled || session == nil {
-		return false, nil
+	if session == nil || requirement == nil {
+		return "", nil
 	}
 
-	// if using OIDC external provider, do not enforce MFA
-	for _, method := range session.AuthenticationMethods {
-		if method.Method != nil && *method.Method == "oidc" {
-			return false, nil
+	if !requirement.Required() {
+		return "", nil
+	}
+
+	// TOTP is the second factor offered by default
+	if requirement.Allows(mfa.MethodTOTP) {
+		totpAvailable, err := a.service.HasTOTPAvailable(ctx, session.Identity.GetId())
+		if err != nil {
+			return "", err
+		}
+
+		if totpAvailable {
+			return "", nil
 		}
 	}
 
-	totpAvailable, err := a.service.HasTOTPAvailable(ctx, session.Identity.GetId())
+	if requirement.Allows(mfa.MethodWebAuthn) {
+		webAuthnAvailable, err := a.service.HasWebAuthnAvailable(ctx, session.Identity.GetId())
+		if err != nil {
+			return "", err
+		}
+
+		if webAuthnAvailable {
+			return "", nil
+		}
+	}
+
+	switch {
+	case requirement.Allows(mfa.MethodTOTP):
+		return mfa.MethodTOTP, nil
+	case requirement.Allows(mfa.MethodWebAuthn):
+		return mfa.MethodWebAuthn, nil
+	}
+
+	// backup codes are issued alongside TOTP, they cannot be set up on their own
+	backupCodes, err := a.service.GetBackupCodes(ctx, session.Identity.GetId())
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	return !totpAvailable, nil
+	if backupCodes.Remaining > 0 {
+		return "", nil
+	}
+
+	return "", fmt.Errorf("none of the second factors allowed by rule %q can be set up", requirement.Rule)
 }
 
-func (a *API) is40xError(err error) bool {
-	if openAPIErr, ok := err.(*client.GenericOpenAPIError); ok {
-		if genericKratosErr, ok := openAPIErr.Model().(client.ErrorGeneric); ok {
-			statusCode := genericKratosErr.Error.GetCode()
-			return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
-		}
+// hydraLoginRequest converts the login request embedded in a Kratos login flow
+// to the Hydra one read by the tenant resolver, nil stays nil.
+func hydraLoginRequest(lr *client.OAuth2LoginRequest) (*hClient.OAuth2LoginRequest, error) {
+	if lr == nil {
+		return nil, nil
 	}
 
-	return false
+	b, err := lr.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	loginRequest := new(hClient.OAuth2LoginRequest)
+	if err := loginRequest.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+
+	return loginRequest, nil
 }
 
-func (a *API) shouldEnforceWebAuthnWithSession(ctx context.Context, session *client.Session) (bool, error) {
-	ctx, span := a.tracer.Start(ctx, "kratos.API.shouldEnforceWebAuthnWithSession")
-	defer span.End()
+// loginMFARequirement evaluates the MFA policy for the login of
+// loginChallenge reusing session, it returns the requirement and the second
+// factor the user has to set up first, if any.
+func (a *API) loginMFARequirement(ctx context.Context, session *client.Session, stateCookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (*mfa.Requirement, string, error) {
+	subject := a.mfaSubject(session, stateCookie, loginChallenge)
+	if loginRequest != nil {
+		subject.ClientID = loginRequest.Client.GetClientId()
+		subject.ClientName = loginRequest.Client.GetClientName()
+		subject.RequestedACR = loginRequest.GetOidcContext().AcrValues
+	}
 
-	if !a.oidcWebAuthnSequencingEnabled {
-		return false, nil
+	requirement, err := a.mfaRequirement(ctx, session, subject)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to evaluate MFA policy: %w", err)
 	}
 
-	// enforce only if one of the authentication methods was oidc
-	for _, method := range session.AuthenticationMethods {
-		if method.GetMethod() == "oidc" {
-			webAuthnAvailable, err := a.service.HasWebAuthnAvailable(ctx, session.Identity.GetId())
-			if err != nil {
-				return false, err
-			}
-			return !webAuthnAvailable, nil
+	mfaSetup, err := a.shouldEnforceMFAWithSession(ctx, session, requirement)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return requirement, mfaSetup, nil
+}
+
+// mfaSubject collects the attributes the MFA policy is evaluated against,
+// the OAuth2 client and requested ACR values are added from the login
+// request by the callers that have one.
+//
+// The tenant is resolved as for the Hydra login request accepted for
+// loginChallenge, consent reads it back from the Hydra context, so that both
+// evaluate the policy of the same tenant.
+func (a *API) mfaSubject(session *client.Session, stateCookie cookies.FlowStateCookie, loginChallenge string) mfa.Subject {
+	subject := mfa.Subject{}
+
+	if tenantID := a.tenantMgr.TenantID(stateCookie, loginChallenge); tenantID != cookies.NoTenantAvailable {
+		subject.TenantID = tenantID
+	}
+
+	if session == nil {
+		return subject
+	}
+
+	if session.Identity != nil {
+		subject.IdentityID = session.Identity.GetId()
+		subject.SchemaID = session.Identity.GetSchemaId()
+	}
+
+	if methods := session.GetAuthenticationMethods(); len(methods) > 0 {
+		subject.FirstFactor = methods[0].GetMethod()
+	}
+
+	return subject
+}
+
+func (a *API) is40xError(err error) bool {
+	if openAPIErr, ok := err.(*client.GenericOpenAPIError); ok {
+		if genericKratosErr, ok := openAPIErr.Model().(client.ErrorGeneric); ok {
+			statusCode := genericKratosErr.Error.GetCode()
+			return statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
 		}
 	}
-	return false, nil
+
+	return false
 }
 
 func (a *API) webAuthnSettingsRedirect(w http.ResponseWriter, r *http.Request, returnTo string, flowStateCookie cookies.FlowStateCookie) {
@@ -1046,6 +1429,7 @@ func (a *API) webAuthnSettingsRedirect(w http.ResponseWriter, r *http.Request, r
 	rt := redirectTo.String()
 
 	flowStateCookie.WebauthnSetup = true
+	a.observeAuthEvent(monitoring.EventMFARedirect, mfa.MethodWebAuthn, "", "")
 	a.cookieManager.SetStateCookie(w, flowStateCookie)
 	a.redirectResponse(w, r, &BrowserLocationChangeRequired{
 		Error:      &client.GenericError{Id: &errorId},
@@ -1081,6 +1465,7 @@ func (a *API) mfaSettingsRedirect(w http.ResponseWriter, r *http.Request, return
 
 	flowStateCookie.TotpSetup = true
 
+	a.observeAuthEvent(monitoring.EventMFARedirect, mfa.MethodTOTP, "", "")
 	a.cookieManager.SetStateCookie(w, flowStateCookie)
 	a.redirectResponse(w, r, &BrowserLocationChangeRequired{
 		Error:      &client.GenericError{Id: &errorId},
@@ -1197,6 +1582,11 @@ func (a *API) handleUpdateRecoveryFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// a valid recovery code sends the user to the settings flow
+	if flow.HasRedirectTo() {
+		a.observeAuthEvent(monitoring.EventRecovery, flowMethod(body.GetActualInstance()), "", monitoring.OutcomeSuccess)
+	}
+
 	setCookies(w, cookies)
 	a.redirectResponse(w, r, &BrowserLocationChangeRequired{
 		RedirectTo: flow.RedirectTo,
@@ -1277,6 +1667,52 @@ func (a *API) handleGetSettingsFlow(w http.ResponseWriter, r *http.Request) {
 	w.Write(resp)
 }
 
+func (a *API) handleGetBackupCodes(w http.ResponseWriter, r *http.Request) {
+	session, _, err := a.service.CheckSession(r.Context(), r.Cookies())
+	if err != nil {
+		if a.is40xError(err) {
+			http.Error(w, "no active session", http.StatusUnauthorized)
+			return
+		}
+		a.logger.Errorf("check session error: %v", err)
+		http.Error(w, "failed to check session", http.StatusInternalServerError)
+		return
+	}
+
+	backupCodes, err := a.service.GetBackupCodes(r.Context(), session.Identity.GetId())
+	if err != nil {
+		a.logger.Errorf("Error when getting backup codes: %v\n", err)
+		http.Error(w, "Failed to get backup codes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(backupCodes)
+}
+
+// handleBackupCodesRemindLater records in the flow state cookie that the user
+// chose not to regenerate their backup codes, so they are not asked again
+// for the rest of the login flow.
+func (a *API) handleBackupCodesRemindLater(w http.ResponseWriter, r *http.Request) {
+	stateCookie, err := a.cookieManager.GetStateCookie(r)
+	if err != nil {
+		a.logger.Errorf("failed to read state cookie: %v", err)
+		http.Error(w, "failed to read state cookie", http.StatusInternalServerError)
+		return
+	}
+
+	stateCookie.BackupCodesRemindLater = true
+
+	if err := a.cookieManager.SetStateCookie(w, stateCookie); err != nil {
+		a.logger.Errorf("failed to set state cookie: %v", err)
+		http.Error(w, "failed to set state cookie", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (a *API) handleUpdateSettingsFlow(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	flowId := q.Get("flow")
@@ -1514,21 +1950,64 @@ func kratosSessionUnsetCookie() *http.Cookie {
 	}
 }
 
+// observeAuthEvent records an authentication funnel event, a failure to do so
+// does not affect the request.
+func (a *API) observeAuthEvent(event, method, clientID, outcome string) {
+	if err := a.monitor.SetAuthEventMetric(monitoring.AuthEventTags(event, method, clientID, outcome), 1); err != nil {
+		a.logger.Debugf("cannot record auth event metric: %v", err)
+	}
+}
+
+// observeLogin records the outcome of a login flow update, a success is only
+// recorded once the login completes, when Kratos issues the session or the
+// Hydra login is accepted, and also records the time elapsed since the flow
+// was created. Redirects to an identity provider or to the next step are not
+// counted.
+func (a *API) observeLogin(flow *client.LoginFlow, body *client.UpdateLoginFlowBody, outcome string) {
+	method := flowMethod(body.GetActualInstance())
+
+	clientID := ""
+	if lr := flow.Oauth2LoginRequest; lr != nil {
+		clientID = lr.Client.GetClientId()
+	}
+
+	a.observeAuthEvent(monitoring.EventLogin, method, clientID, outcome)
+
+	if outcome != monitoring.OutcomeSuccess || flow.IssuedAt.IsZero() {
+		return
+	}
+
+	if err := a.monitor.SetLoginDurationMetric(monitoring.LoginDurationTags(method, clientID), time.Since(flow.IssuedAt).Seconds()); err != nil {
+		a.logger.Debugf("cannot record login duration metric: %v", err)
+	}
+}
+
+// flowMethod returns the method of the variant of a flow update body.
+func flowMethod(body any) string {
+	if m, ok := body.(interface{ GetMethod() string }); ok {
+		return m.GetMethod()
+	}
+	return ""
+}
+
 func NewAPI(
 	service ServiceInterface,
-	verificationEnabled,
-	mfaEnabled,
-	oidcWebAuthnSequencingEnabled bool,
+	verificationEnabled bool,
+	passkeyEnabled bool,
+	authorizationEnabled bool,
+	mfaPolicy MFAPolicyInterface,
 	tenantMgr TenantResolverInterface,
 	baseURL string,
 	cookieManager AuthCookieManagerInterface,
 	tracer tracing.TracingInterface,
+	monitor monitoring.MonitorInterface,
 	logger logging.LoggerInterface) *API {
 	a := new(API)
 
 	a.verificationEnabled = verificationEnabled
-	a.mfaEnabled = mfaEnabled
-	a.oidcWebAuthnSequencingEnabled = oidcWebAuthnSequencingEnabled
+	a.passkeyEnabled = passkeyEnabled
+	a.authorizationEnabled = authorizationEnabled
+	a.mfaPolicy = mfaPolicy
 	a.tenantMgr = tenantMgr
 	a.service = service
 	a.baseURL = baseURL
@@ -1543,11 +2022,22 @@ func NewAPI(
 	a.contextPath = fullBaseURL.Path
 
 	a.tracer = tracer
+	a.monitor = monitor
 	a.logger = logger
 
 	return a
 }
 
+func withoutPasskeyNodes(nodes []client.UiNode) []client.UiNode {
+	ret := make([]client.UiNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Group != "passkey" {
+			ret = append(ret, node)
+		}
+	}
+	return ret
+}
+
 func setCookies(w http.ResponseWriter, cookies []*http.Cookie, exclude ...string) {
 	for _, c := range httpHelpers.FilterCookies(cookies, exclude...) {
 		http.SetCookie(w, c)
@@ -27,6 +27,8 @@ import (
 	kClient "github.com/ory/kratos-client-go/v25"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 )
 
@@ -36,6 +38,7 @@ const (
 	HANDLE_UPDATE_LOGIN_FLOW_URL                  = BASE_URL + "/api/kratos/self-service/login"
 	HANDLE_UPDATE_IDENTIFIER_FIRST_LOGIN_FLOW_URL = BASE_URL + "/api/kratos/self-service/login/id-first"
 	HANDLE_GET_LOGIN_FLOW_URL                     = BASE_URL + "/api/kratos/self-service/login/flows"
+	HANDLE_GET_PASSKEY_LOGIN_OPTIONS_URL          = BASE_URL + "/api/kratos/self-service/login/passkey"
 	HANDLE_ERROR_URL                              = BASE_URL + "/api/kratos/self-service/errors"
 	HANDLE_CREATE_RECOVERY_FLOW_URL               = BASE_URL + "/api/kratos/self-service/recovery/browser"
 	HANDLE_UPDATE_RECOVERY_FLOW_URL               = BASE_URL + "/api/kratos/self-service/recovery"
@@ -43,6 +46,8 @@ const (
 	HANDLE_CREATE_SETTINGS_FLOW_URL               = BASE_URL + "/api/kratos/self-service/settings/browser"
 	HANDLE_UPDATE_SETTINGS_FLOW_URL               = BASE_URL + "/api/kratos/self-service/settings"
 	HANDLE_GET_SETTINGS_FLOW_URL                  = BASE_URL + "/api/kratos/self-service/settings/flows"
+	HANDLE_GET_BACKUP_CODES_URL                   = BASE_URL + "/api/kratos/self-service/settings/backup-codes"
+	HANDLE_BACKUP_CODES_REMIND_LATER_URL          = BASE_URL + "/api/kratos/self-service/settings/backup-codes/remind-later"
 	HANDLE_CREATE_VERIFICATION_FLOW_URL           = BASE_URL + "/api/kratos/self-service/verification/browser"
 	HANDLE_UPDATE_VERIFICATION_FLOW_URL           = BASE_URL + "/api/kratos/self-service/verification"
 	HANDLE_GET_VERIFICATION_FLOW_URL              = BASE_URL + "/api/kratos/self-service/verification/flows"
@@ -59,6 +64,7 @@ func TestHandleCreateFlowWithoutParams(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -67,7 +73,7 @@ func TestHandleCreateFlowWithoutParams(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -87,6 +93,7 @@ func TestHandleCreateFlowWithoutSessionAcceptJSON(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -104,12 +111,12 @@ func TestHandleCreateFlowWithoutSessionAcceptJSON(t *testing.T) {
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, nil, cookies.FlowStateCookie{}).Return(true, nil)
 	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), gomock.Any(), returnTo, loginChallenge, gomock.Any(), req.Cookies()).Return(flow, req.Cookies(), nil)
-	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow).Return(flow, nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(flow, nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -141,6 +148,7 @@ func TestHandleCreateFlowWithoutSessionNotAcceptJSON(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -158,12 +166,12 @@ func TestHandleCreateFlowWithoutSessionNotAcceptJSON(t *testing.T) {
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, nil, cookies.FlowStateCookie{}).Return(true, nil)
 	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), gomock.Any(), returnTo, loginChallenge, gomock.Any(), req.Cookies()).Return(flow, req.Cookies(), nil)
-	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow).Return(flow, nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(flow, nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -189,6 +197,7 @@ func TestHandleCreateFlowWithoutSessionFailOnCreateBrowserLoginFlow(t *testing.T
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -210,7 +219,7 @@ func TestHandleCreateFlowWithoutSessionFailOnCreateBrowserLoginFlow(t *testing.T
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -230,6 +239,7 @@ func TestHandleCreateFlowWithoutSessionFailOnFilterProviders(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -246,13 +256,13 @@ func TestHandleCreateFlowWithoutSessionFailOnFilterProviders(t *testing.T) {
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, nil, cookies.FlowStateCookie{}).Return(true, nil)
 	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), gomock.Any(), returnTo, loginChallenge, gomock.Any(), req.Cookies()).Return(flow, req.Cookies(), nil)
-	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow).Return(nil, fmt.Errorf("oh no"))
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(nil, fmt.Errorf("oh no"))
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -272,6 +282,7 @@ func TestHandleCreateFlowWithoutSessionWhenNoProvidersAllowedAcceptJSON(t *testi
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -290,12 +301,12 @@ func TestHandleCreateFlowWithoutSessionWhenNoProvidersAllowedAcceptJSON(t *testi
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, nil, cookies.FlowStateCookie{}).Return(true, nil)
 	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), gomock.Any(), returnTo, loginChallenge, gomock.Any(), req.Cookies()).Return(flow, req.Cookies(), nil)
-	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow).Return(flow, nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(flow, nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -327,6 +338,7 @@ func TestHandleCreateFlowWithoutSessionWhenNoProvidersAllowedNotAcceptJSON(t *te
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = "test"
@@ -345,12 +357,12 @@ func TestHandleCreateFlowWithoutSessionWhenNoProvidersAllowedNotAcceptJSON(t *te
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, nil, cookies.FlowStateCookie{}).Return(true, nil)
 	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), gomock.Any(), returnTo, loginChallenge, gomock.Any(), req.Cookies()).Return(flow, req.Cookies(), nil)
-	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow).Return(flow, nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(flow, nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -376,6 +388,7 @@ func TestHandleCreateFlowRedirectToSetupWebauthn(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -397,16 +410,17 @@ func TestHandleCreateFlowRedirectToSetupWebauthn(t *testing.T) {
 	req.URL.RawQuery = values.Encode()
 
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{mfa.MethodWebAuthn}}, nil).AnyTimes()
 	mockService.EXPECT().HasWebAuthnAvailable(gomock.Any(), session.Id).Return(false, nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, true, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -429,6 +443,242 @@ func TestHandleCreateFlowRedirectToSetupWebauthn(t *testing.T) {
 	}
 }
 
+func TestHandleCreateFlowPolicyRequiresWebauthnForClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	loginChallenge := "login_challenge_2341235123231"
+
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	method := "password"
+	aal := kClient.AUTHENTICATORASSURANCELEVEL_AAL1
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &method}}
+	session.AuthenticatorAssuranceLevel = &aal
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+
+	client := hClient.NewOAuth2Client()
+	client.SetClientId("admin-console")
+	loginRequest := hClient.NewOAuth2LoginRequestWithDefaults()
+	loginRequest.Client = *client
+
+	expectedSubject := mfa.Subject{
+		ClientID:    "admin-console",
+		IdentityID:  "test",
+		SchemaID:    "test.json",
+		FirstFactor: "password",
+	}
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), loginChallenge).Return(loginRequest, nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), expectedSubject).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{mfa.MethodWebAuthn}}, nil)
+	mockService.EXPECT().HasWebAuthnAvailable(gomock.Any(), session.Identity.GetId()).Return(false, nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+	loginFlow := BrowserLocationChangeRequired{}
+	if err := json.Unmarshal(data, &loginFlow); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+	if !strings.HasPrefix(*loginFlow.RedirectTo, "/ui/setup_passkey") {
+		t.Errorf("expected redirect_to to start with '/ui/setup_passkey' got %v", *loginFlow.RedirectTo)
+	}
+}
+
+func TestHandleCreateFlowPolicyNotRequiringMFAForClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	redirect := "https://some/path/to/somewhere"
+	redirectTo := BrowserLocationChangeRequired{RedirectTo: &redirect}
+
+	loginChallenge := "login_challenge_2341235123231"
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1, Rule: "wiki"}, nil)
+	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, cookies.FlowStateCookie{}).Return(false, nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any(), gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any()).Return()
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+}
+
+func TestHandleCreateFlowStepUpForRequestedACR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	loginChallenge := "login_challenge_2341235123231"
+	returnTo, _ := url.JoinPath(BASE_URL, "ui/login")
+	returnTo = returnTo + "?login_challenge=" + loginChallenge
+
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	method := "password"
+	aal := kClient.AUTHENTICATORASSURANCELEVEL_AAL1
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &method}}
+	session.AuthenticatorAssuranceLevel = &aal
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = "test"
+	flow.State = "choose_method"
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, ACR: "aal2"}, nil)
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), session.Identity.GetId()).Return(true, nil)
+	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), "aal2", returnTo, loginChallenge, false, req.Cookies()).Return(flow, req.Cookies(), nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "").Return(flow, nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+	loginFlow := kClient.NewLoginFlowWithDefaults()
+	if err := json.Unmarshal(data, loginFlow); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if loginFlow.Id != flow.Id {
+		t.Fatalf("Invalid flow id, expected: %s, got: %s", flow.Id, loginFlow.Id)
+	}
+}
+
+func TestHandleCreateFlowReportsAchievedACR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	loginChallenge := "login_challenge_2341235123231"
+
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	password, totp := "password", "totp"
+	aal := kClient.AUTHENTICATORASSURANCELEVEL_AAL2
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &password}, {Method: &totp}}
+	session.AuthenticatorAssuranceLevel = &aal
+
+	redirect := "https://some/path/to/somewhere"
+	redirectTo := BrowserLocationChangeRequired{RedirectTo: &redirect}
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, ACR: "aal2"}, nil)
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), session.Identity.GetId()).Return(true, nil)
+	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, cookies.FlowStateCookie{}).Return(false, nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any(), "aal2").Return(&redirectTo, req.Cookies(), nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any()).Return()
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+}
+
 func TestHandleCreateFlowWithSessionAcceptJSON(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -437,6 +687,7 @@ func TestHandleCreateFlowWithSessionAcceptJSON(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	session := kClient.NewSession("test")
@@ -452,17 +703,18 @@ func TestHandleCreateFlowWithSessionAcceptJSON(t *testing.T) {
 	req.Header.Set("Accept", "application/json, text/plain, */*")
 
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, cookies.FlowStateCookie{}).Return(false, nil)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any(), gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil).Times(1)
 	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any()).Return()
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -492,6 +744,7 @@ func TestHandleCreateFlowWithSessionNotAcceptJSON(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	session := kClient.NewSession("test")
@@ -507,17 +760,18 @@ func TestHandleCreateFlowWithSessionNotAcceptJSON(t *testing.T) {
 	req.Header.Set("Accept", "application/x-www-form-urlencoded")
 
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, cookies.FlowStateCookie{}).Return(false, nil)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any(), gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil).Times(1)
 	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any()).Return()
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -547,6 +801,7 @@ func TestHandleCreateFlowWithSessionFailOnAcceptLoginRequest(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	session := kClient.NewSession("test")
@@ -559,17 +814,18 @@ func TestHandleCreateFlowWithSessionFailOnAcceptLoginRequest(t *testing.T) {
 	req.URL.RawQuery = values.Encode()
 
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, cookies.FlowStateCookie{}).Return(false, nil)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any()).Return(nil, nil, fmt.Errorf("error"))
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, gomock.Any(), gomock.Any()).Return(nil, nil, fmt.Errorf("error"))
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil).Times(1)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -588,6 +844,7 @@ func TestHandleCreateFlowRedirectToVerification(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	loginChallenge := "login_challenge_2341235123231"
@@ -603,6 +860,7 @@ func TestHandleCreateFlowRedirectToVerification(t *testing.T) {
 	req.URL.RawQuery = values.Encode()
 
 	mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil).AnyTimes()
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerification").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockService.EXPECT().RequireVerificationForEmail(gomock.Any(), gomock.Any()).Return(true, unverifiedEmail, nil).Times(1)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
@@ -611,7 +869,7 @@ func TestHandleCreateFlowRedirectToVerification(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, true, false, true, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, true, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -649,6 +907,7 @@ func TestHandleCreateFlowRequireVerificationError(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	loginChallenge := "login_challenge_2341235123231"
 	identityId := "test"
@@ -662,6 +921,7 @@ func TestHandleCreateFlowRequireVerificationError(t *testing.T) {
 	req.URL.RawQuery = values.Encode()
 
 	mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil).AnyTimes()
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockService.EXPECT().RequireVerificationForEmail(gomock.Any(), gomock.Any()).Return(false, "", fmt.Errorf("failed check for verification")).Times(1)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
@@ -671,7 +931,7 @@ func TestHandleCreateFlowRequireVerificationError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, true, false, true, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, true, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -700,6 +960,7 @@ func TestHandleGetLoginFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -715,7 +976,7 @@ func TestHandleGetLoginFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -738,6 +999,215 @@ func TestHandleGetLoginFlow(t *testing.T) {
 	}
 }
 
+func TestHandleGetLoginFlowHidesPasskeyNodesWhenDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	id := "test"
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetId(id)
+	flow.SetState("choose_method")
+	flow.Ui.Nodes = []kClient.UiNode{
+		{
+			Group:      "default",
+			Type:       "input",
+			Attributes: kClient.UiNodeInputAttributesAsUiNodeAttributes(&kClient.UiNodeInputAttributes{Name: "csrf_token", Type: "hidden", NodeType: "input"}),
+		},
+		{
+			Group:      "passkey",
+			Type:       "input",
+			Attributes: kClient.UiNodeInputAttributesAsUiNodeAttributes(&kClient.UiNodeInputAttributes{Name: "passkey_challenge", Type: "hidden", NodeType: "input"}),
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("id", id)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), id, req.Cookies()).Return(flow, req.Cookies(), nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
+	}
+
+	flowResponse := kClient.NewLoginFlowWithDefaults()
+	if err := json.NewDecoder(res.Body).Decode(flowResponse); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	for _, node := range flowResponse.Ui.Nodes {
+		if node.Group == "passkey" {
+			t.Fatal("Expected passkey nodes to be removed when passkey login is disabled")
+		}
+	}
+	if len(flowResponse.Ui.Nodes) != 1 {
+		t.Fatalf("Expected 1 node, got %d", len(flowResponse.Ui.Nodes))
+	}
+}
+
+func TestHandleGetPasskeyLoginOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	id := "test"
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetId(id)
+	options := &PasskeyLoginOptions{
+		FlowID:    id,
+		CsrfToken: "csrf",
+		Mediation: "conditional",
+		PublicKey: json.RawMessage(`{"challenge":"Y2hhbGxlbmdl"}`),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_PASSKEY_LOGIN_OPTIONS_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", id)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), id, req.Cookies()).Return(flow, req.Cookies(), nil)
+	mockService.EXPECT().GetPasskeyLoginOptions(gomock.Any(), flow).Return(options, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, true, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
+	}
+
+	resp := new(PasskeyLoginOptions)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if resp.FlowID != id || resp.CsrfToken != "csrf" || resp.Mediation != "conditional" {
+		t.Fatalf("Unexpected passkey login options %+v", resp)
+	}
+	if string(resp.PublicKey) != string(options.PublicKey) {
+		t.Fatalf("Expected public key options %s, got %s", options.PublicKey, resp.PublicKey)
+	}
+}
+
+func TestHandleGetPasskeyLoginOptionsUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	id := "test"
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetId(id)
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_PASSKEY_LOGIN_OPTIONS_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", id)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), id, req.Cookies()).Return(flow, req.Cookies(), nil)
+	mockService.EXPECT().GetPasskeyLoginOptions(gomock.Any(), flow).Return(nil, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, true, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatal("Expected HTTP status code 404, got: ", res.Status)
+	}
+}
+
+func TestHandleGetPasskeyLoginOptionsDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_PASSKEY_LOGIN_OPTIONS_URL+"?flow=test", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatal("Expected HTTP status code 404, got: ", res.Status)
+	}
+}
+
+func TestHandleUpdateFlowPasskeyDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	flowId := "test"
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = flowId
+
+	flowBody := kClient.UpdateLoginFlowWithPasskeyMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithPasskeyMethod("passkey"))
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", flowId)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(&flowBody, req.Cookies(), nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatal("Expected HTTP status code 400, got: ", res.Status)
+	}
+}
+
 func TestHandleGetLoginFlowFail(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -746,6 +1216,7 @@ func TestHandleGetLoginFlowFail(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -761,7 +1232,7 @@ func TestHandleGetLoginFlowFail(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -784,6 +1255,7 @@ func TestHandleCreateFlowRedirectsToTenantSelectionWhenNoTenantSelected(t *testi
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	loginChallenge := "test-challenge"
@@ -801,15 +1273,17 @@ func TestHandleCreateFlowRedirectsToTenantSelectionWhenNoTenantSelected(t *testi
 
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge).
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
+	mockTenantMgr.EXPECT().TenantID(gomock.Any(), loginChallenge).Return("")
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, gomock.Any()).
 		Return(tenants.LoginInterception{SelectTenant: true, Cookie: stateCookie}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -835,6 +1309,83 @@ func TestHandleCreateFlowRedirectsToTenantSelectionWhenNoTenantSelected(t *testi
 	}
 }
 
+// TestHandleCreateFlowRedirectsToTenantSelectionWithHintError verifies that
+// the reason a tenant hint was rejected is passed to the tenant selection page.
+func TestHandleCreateFlowRedirectsToTenantSelectionWithHintError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
+
+	loginChallenge := "test-challenge"
+	session := kClient.NewSession("test")
+
+	stateCookie := cookies.FlowStateCookie{
+		LoginChallengeHash: cookies.ChallengeHash(loginChallenge),
+	}
+
+	hintedCookie := stateCookie
+	hintedCookie.TenantHintError = tenants.TenantHintNotMember
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	// the login request is fetched once and handed to the tenant resolver
+	loginRequest := hClient.NewOAuth2LoginRequestWithDefaults()
+	loginRequest.SetRequestUrl("https://hydra/oauth2/auth?tenant=t9")
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), loginChallenge).Return(loginRequest, nil, nil).Times(1)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
+	mockTenantMgr.EXPECT().TenantID(gomock.Any(), loginChallenge).Return("")
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, loginRequest).
+		Return(tenants.LoginInterception{SelectTenant: true, Cookie: hintedCookie}, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected HTTP status code 200, got: %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	var resp BrowserLocationChangeRequired
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if resp.Error == nil || resp.Error.GetId() != "tenant_selection_required" {
+		t.Fatalf("Expected error id tenant_selection_required, got: %v", resp.Error)
+	}
+	if resp.RedirectTo == nil {
+		t.Fatal("Expected redirect_to to be set")
+	}
+	redirectTo, err := url.Parse(*resp.RedirectTo)
+	if err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if redirectTo.Query().Get("hint_error") != tenants.TenantHintNotMember {
+		t.Fatalf("Expected hint_error %s, got: %s", tenants.TenantHintNotMember, *resp.RedirectTo)
+	}
+}
+
 // TestHandleCreateFlowAcceptsLoginWithExistingTenantID verifies that
 // when the user already has a tenant selected in the state cookie, handleCreateFlow
 // proceeds through the session block (MFA, WebAuthn) then
@@ -847,6 +1398,7 @@ func TestHandleCreateFlowAcceptsLoginWithExistingTenantID(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	loginChallenge := "test-challenge"
@@ -868,19 +1420,20 @@ func TestHandleCreateFlowAcceptsLoginWithExistingTenantID(t *testing.T) {
 
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge).
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, gomock.Any()).
 		Return(tenants.LoginInterception{AcceptLogin: true, Cookie: stateCookie}, nil)
 	// Inside handleCreateFlowWithSession: TenantID is called to extract the ID.
-	mockTenantMgr.EXPECT().TenantID(stateCookie, loginChallenge).Return(tenantID)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, tenantID).Return(&redirectTo, req.Cookies(), nil)
+	mockTenantMgr.EXPECT().TenantID(stateCookie, loginChallenge).Return(tenantID).Times(2)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, tenantID, gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
 	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any())
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -914,6 +1467,7 @@ func TestHandleCreateFlowRedirectsToTenantSelectionWhenHasTenants(t *testing.T)
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	loginChallenge := "test-challenge"
@@ -931,15 +1485,17 @@ func TestHandleCreateFlowRedirectsToTenantSelectionWhenHasTenants(t *testing.T)
 
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge).
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
+	mockTenantMgr.EXPECT().TenantID(gomock.Any(), loginChallenge).Return("")
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, gomock.Any()).
 		Return(tenants.LoginInterception{SelectTenant: true, Cookie: stateCookie}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -976,6 +1532,7 @@ func TestHandleCreateFlowSkipsTenantSelectionForNoTenantUser(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	loginChallenge := "test-challenge"
@@ -999,19 +1556,20 @@ func TestHandleCreateFlowSkipsTenantSelectionForNoTenantUser(t *testing.T) {
 
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookieInitial, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceWebAuthnWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookieInitial, loginChallenge).
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookieInitial, loginChallenge, gomock.Any()).
 		Return(tenants.LoginInterception{AcceptLogin: true, Cookie: stateCookieWithSentinel}, nil)
 	// Inside handleCreateFlowWithSession: TenantID extracts the sentinel.
-	mockTenantMgr.EXPECT().TenantID(stateCookieWithSentinel, loginChallenge).Return(cookies.NoTenantAvailable)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, cookies.NoTenantAvailable).Return(&redirectTo, req.Cookies(), nil)
+	mockTenantMgr.EXPECT().TenantID(stateCookieWithSentinel, loginChallenge).Return(cookies.NoTenantAvailable).Times(2)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, cookies.NoTenantAvailable, gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
 	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any())
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1048,6 +1606,7 @@ func TestHandleCreateFlowAcceptsLoginAfterOIDCAuth(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	loginChallenge := "oidc-challenge"
@@ -1073,40 +1632,125 @@ func TestHandleCreateFlowAcceptsLoginAfterOIDCAuth(t *testing.T) {
 
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	// DeferMFAChecks=true → MFA/WebAuthn checks are skipped
-	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge).
+	// DeferMFAChecks=true → MFA/WebAuthn checks wait for the tenant
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, gomock.Any()).
 		Return(tenants.LoginInterception{DeferMFAChecks: true, AcceptLogin: true, Cookie: updatedCookie}, nil)
 	// DeferMFAChecks=true means the cookie doesn't match this challenge,
 	// so MustReAuthenticate is called. Hydra says skip=true (user just
 	// authenticated via OIDC), so forceLogin=false and AcceptLogin proceeds.
 	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, stateCookie).Return(false, nil)
-	mockTenantMgr.EXPECT().TenantID(updatedCookie, loginChallenge).Return(cookies.NoTenantAvailable)
-	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, cookies.NoTenantAvailable).Return(&redirectTo, req.Cookies(), nil)
+	mockTenantMgr.EXPECT().TenantID(updatedCookie, loginChallenge).Return(cookies.NoTenantAvailable).Times(2)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), mfa.Subject{}).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), session, loginChallenge, cookies.NoTenantAvailable, gomock.Any()).Return(&redirectTo, req.Cookies(), nil)
 	mockCookieManager.EXPECT().ClearStateCookie(gomock.Any())
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected HTTP status code 200, got: %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	var resp BrowserLocationChangeRequired
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if resp.RedirectTo == nil || *resp.RedirectTo != redirect {
+		t.Fatalf("Expected redirect_to %s, got: %v", redirect, resp.RedirectTo)
+	}
+}
+
+// TestHandleCreateFlowStepsUpForTenantOfReusedSession verifies that a login
+// reusing a session is checked against the MFA policy of the tenant it is
+// accepted with, so that the user is asked for the second factor instead of
+// being rejected at consent.
+func TestHandleCreateFlowStepsUpForTenantOfReusedSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
+
+	loginChallenge := "reuse-challenge"
+	returnTo, _ := url.JoinPath(BASE_URL, "ui/login")
+	returnTo = returnTo + "?login_challenge=" + loginChallenge
+
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("identity-1", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	method := "password"
+	aal := kClient.AUTHENTICATORASSURANCELEVEL_AAL1
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &method}}
+	session.AuthenticatorAssuranceLevel = &aal
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = "test"
+	flow.State = "choose_method"
+
+	// the session comes from a previous login, the tenant is resolved from
+	// the hint of this one
+	stateCookie := cookies.FlowStateCookie{}
+	updatedCookie := cookies.FlowStateCookie{
+		LoginChallengeHash: cookies.ChallengeHash(loginChallenge),
+		TenantID:           "acme",
+	}
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_CREATE_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("login_challenge", loginChallenge)
+	req.URL.RawQuery = values.Encode()
+	req.Header.Set("Accept", "application/json, text/plain, */*")
+
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetLoginRequest(gomock.Any(), gomock.Any()).Return(hClient.NewOAuth2LoginRequestWithDefaults(), nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTenantMgr.EXPECT().InterceptLogin(gomock.Any(), session, stateCookie, loginChallenge, gomock.Any()).
+		Return(tenants.LoginInterception{DeferMFAChecks: true, AcceptLogin: true, Cookie: updatedCookie}, nil)
+	mockService.EXPECT().MustReAuthenticate(gomock.Any(), loginChallenge, session, stateCookie).Return(false, nil)
+	mockTenantMgr.EXPECT().TenantID(updatedCookie, loginChallenge).Return("acme").Times(2)
+	expectedSubject := mfa.Subject{TenantID: "acme", IdentityID: "identity-1", SchemaID: "test.json", FirstFactor: "password"}
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), expectedSubject).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Rule: "acme"}, nil)
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), "identity-1").Return(true, nil)
+	mockService.EXPECT().CreateBrowserLoginFlow(gomock.Any(), "aal2", returnTo, loginChallenge, false, req.Cookies()).Return(flow, req.Cookies(), nil)
+	mockService.EXPECT().FilterFlowProviderList(gomock.Any(), flow, "acme").Return(flow, nil)
+	mockService.EXPECT().AcceptLoginRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
 	res := w.Result()
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		t.Fatalf("Expected HTTP status code 200, got: %d", res.StatusCode)
 	}
 
-	data, err := io.ReadAll(res.Body)
-	if err != nil {
-		t.Fatalf("Expected error to be nil got %v", err)
-	}
-	var resp BrowserLocationChangeRequired
-	if err := json.Unmarshal(data, &resp); err != nil {
+	loginFlow := kClient.NewLoginFlowWithDefaults()
+	if err := json.NewDecoder(res.Body).Decode(loginFlow); err != nil {
 		t.Fatalf("Expected error to be nil got %v", err)
 	}
-	if resp.RedirectTo == nil || *resp.RedirectTo != redirect {
-		t.Fatalf("Expected redirect_to %s, got: %v", redirect, resp.RedirectTo)
+	if loginFlow.Id != flow.Id {
+		t.Fatalf("Invalid flow id, expected: %s, got: %s", flow.Id, loginFlow.Id)
 	}
 }
 
@@ -1118,8 +1762,9 @@ func TestHandleCreateRegistrationFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
-	api := NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger)
+	api := NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger)
 
 	t.Run("service.CreateBrowserRegistrationFlow returns error", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/registration/create?return_to=/error", nil)
@@ -1144,6 +1789,26 @@ func TestHandleCreateRegistrationFlow(t *testing.T) {
 		}
 	})
 
+	t.Run("registration not allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/registration/create", nil)
+		w := httptest.NewRecorder()
+
+		flow := kClient.NewRegistrationFlowWithDefaults()
+		mockService.EXPECT().CreateBrowserRegistrationFlow(gomock.Any(), "").
+			Return(flow, nil, nil)
+		mockService.EXPECT().FilterRegistrationFlowProviderList(gomock.Any(), flow, "").
+			Return(nil, ErrRegistrationNotAllowed)
+
+		api.handleCreateRegistrationFlow(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected %d, got %d", http.StatusForbidden, res.StatusCode)
+		}
+	})
+
 	t.Run("success - custom return_to", func(t *testing.T) {
 		flowID := "flow-abc-123"
 		req := httptest.NewRequest(http.MethodGet, "/registration/create?return_to=/welcome", nil)
@@ -1155,6 +1820,8 @@ func TestHandleCreateRegistrationFlow(t *testing.T) {
 
 		mockService.EXPECT().CreateBrowserRegistrationFlow(gomock.Any(), "/welcome").
 			Return(flow, cookies, nil)
+		mockService.EXPECT().FilterRegistrationFlowProviderList(gomock.Any(), flow, "").
+			Return(flow, nil)
 
 		api.handleCreateRegistrationFlow(w, req)
 
@@ -1195,6 +1862,8 @@ func TestHandleCreateRegistrationFlow(t *testing.T) {
 
 		mockService.EXPECT().CreateBrowserRegistrationFlow(gomock.Any(), "").
 			Return(flow, cookies, nil)
+		mockService.EXPECT().FilterRegistrationFlowProviderList(gomock.Any(), flow, "").
+			Return(flow, nil)
 
 		api.handleCreateRegistrationFlow(w, req)
 
@@ -1233,8 +1902,9 @@ func TestHandleGetRegistrationFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
-	api := NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger)
+	api := NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger)
 
 	t.Run("Missing id parameter", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/registration", nil)
@@ -1295,6 +1965,8 @@ func TestHandleGetRegistrationFlow(t *testing.T) {
 
 		mockService.EXPECT().GetRegistrationFlow(gomock.Any(), id, req.Cookies()).
 			Return(flow, cookies, nil)
+		mockService.EXPECT().FilterRegistrationFlowProviderList(gomock.Any(), flow, "").
+			Return(flow, nil)
 
 		api.handleGetRegistrationFlow(w, req)
 
@@ -1334,8 +2006,11 @@ func TestHandleUpdateRegistrationFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
-	api := NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger)
+	api := NewAPI(mockService, false, false, true, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger)
 
 	t.Run("ParseRegistrationFlowMethodBody returns error", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/registration/update?flow=e2c802141dc51a06676974687562", nil)
@@ -1367,6 +2042,11 @@ func TestHandleUpdateRegistrationFlow(t *testing.T) {
 		body := &kClient.UpdateRegistrationFlowBody{}
 		mockService.EXPECT().ParseRegistrationFlowMethodBody(req).
 			Return(body, nil)
+		flow := kClient.NewRegistrationFlowWithDefaults()
+		mockService.EXPECT().GetRegistrationFlow(gomock.Any(), "e2c802141dc51a06676974687562", req.Cookies()).
+			Return(flow, nil, nil)
+		mockService.EXPECT().CheckAllowedRegistration(gomock.Any(), flow, body, "").
+			Return(true, nil)
 		mockService.EXPECT().UpdateRegistrationFlow(gomock.Any(), "e2c802141dc51a06676974687562", *body, req.Cookies()).
 			Return(nil, nil, errors.New("update failed"))
 		mockLogger.EXPECT().Errorf("Error when updating registration flow: %v\n", gomock.Any())
@@ -1386,6 +2066,32 @@ func TestHandleUpdateRegistrationFlow(t *testing.T) {
 		}
 	})
 
+	t.Run("registration method not allowed", func(t *testing.T) {
+		flowID := "e2c802141dc51a06676974687562"
+		req := httptest.NewRequest(http.MethodPost, "/registration/update?flow="+flowID, nil)
+		w := httptest.NewRecorder()
+
+		passwordBody := kClient.NewUpdateRegistrationFlowWithPasswordMethod("password", "secret", map[string]interface{}{})
+		body := kClient.UpdateRegistrationFlowWithPasswordMethodAsUpdateRegistrationFlowBody(passwordBody)
+		flow := kClient.NewRegistrationFlowWithDefaults()
+
+		mockService.EXPECT().ParseRegistrationFlowMethodBody(req).
+			Return(&body, nil)
+		mockService.EXPECT().GetRegistrationFlow(gomock.Any(), flowID, req.Cookies()).
+			Return(flow, nil, nil)
+		mockService.EXPECT().CheckAllowedRegistration(gomock.Any(), flow, &body, "").
+			Return(false, nil)
+
+		api.handleUpdateRegistrationFlow(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected %d, got %d", http.StatusForbidden, res.StatusCode)
+		}
+	})
+
 	t.Run("success", func(t *testing.T) {
 		flowID := "e2c802141dc51a06676974687562"
 		req := httptest.NewRequest(http.MethodPost, "/registration/update?flow="+flowID, nil)
@@ -1399,6 +2105,11 @@ func TestHandleUpdateRegistrationFlow(t *testing.T) {
 
 		cookies := []*http.Cookie{{Name: "updated", Value: "ok"}}
 
+		flow := kClient.NewRegistrationFlowWithDefaults()
+		mockService.EXPECT().GetRegistrationFlow(gomock.Any(), flowID, req.Cookies()).
+			Return(flow, nil, nil)
+		mockService.EXPECT().CheckAllowedRegistration(gomock.Any(), flow, body, "").
+			Return(true, nil)
 		mockService.EXPECT().UpdateRegistrationFlow(gomock.Any(), flowID, *body, req.Cookies()).
 			Return(mockRegistration, cookies, nil)
 
@@ -1421,6 +2132,58 @@ func TestHandleUpdateRegistrationFlow(t *testing.T) {
 			t.Fatalf("expected cookie 'updated=ok' to be set")
 		}
 	})
+
+	t.Run("registration method not allowed for the tenant", func(t *testing.T) {
+		flowID := "e2c802141dc51a06676974687562"
+		loginChallenge := "login_challenge_2341235123231"
+		req := httptest.NewRequest(http.MethodPost, "/registration/update?flow="+flowID, nil)
+		w := httptest.NewRecorder()
+
+		body := &kClient.UpdateRegistrationFlowBody{}
+		flow := kClient.NewRegistrationFlowWithDefaults()
+		flow.SetOauth2LoginChallenge(loginChallenge)
+		stateCookie := cookies.FlowStateCookie{}
+
+		mockService.EXPECT().ParseRegistrationFlowMethodBody(req).
+			Return(body, nil)
+		mockService.EXPECT().GetRegistrationFlow(gomock.Any(), flowID, req.Cookies()).
+			Return(flow, nil, nil)
+		mockTenantMgr.EXPECT().Enabled().Return(true)
+		mockCookieManager.EXPECT().GetStateCookie(req).Return(stateCookie, nil)
+		mockTenantMgr.EXPECT().TenantID(stateCookie, loginChallenge).Return("acme")
+		mockService.EXPECT().CheckAllowedRegistration(gomock.Any(), flow, body, "acme").
+			Return(false, nil)
+
+		NewAPI(mockService, false, false, true, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).handleUpdateRegistrationFlow(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected %d, got %d", http.StatusForbidden, res.StatusCode)
+		}
+	})
+
+	t.Run("authorization disabled", func(t *testing.T) {
+		flowID := "e2c802141dc51a06676974687562"
+		req := httptest.NewRequest(http.MethodPost, "/registration/update?flow="+flowID, nil)
+		w := httptest.NewRecorder()
+
+		body := &kClient.UpdateRegistrationFlowBody{}
+		mockService.EXPECT().ParseRegistrationFlowMethodBody(req).
+			Return(body, nil)
+		mockService.EXPECT().UpdateRegistrationFlow(gomock.Any(), flowID, *body, req.Cookies()).
+			Return(&RegistrationFlowResponse{changeRequired: &BrowserLocationChangeRequired{}}, nil, nil)
+
+		NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).handleUpdateRegistrationFlow(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("expected %d, got %d", http.StatusUnprocessableEntity, res.StatusCode)
+		}
+	})
 }
 
 func TestHandleUpdateIdentifierFirstFlow(t *testing.T) {
@@ -1431,6 +2194,7 @@ func TestHandleUpdateIdentifierFirstFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -1451,7 +2215,7 @@ func TestHandleUpdateIdentifierFirstFlow(t *testing.T) {
 	mockService.EXPECT().UpdateIdentifierFirstLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(redirectFlow, req.Cookies(), nil)
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1483,6 +2247,7 @@ func TestHandleUpdateIdentifierFirstFlowFailOnParseLoginFlowMethodBody(t *testin
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flowBody := new(kClient.UpdateLoginFlowWithIdentifierFirstMethod)
@@ -1498,7 +2263,7 @@ func TestHandleUpdateIdentifierFirstFlowFailOnParseLoginFlowMethodBody(t *testin
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1517,6 +2282,7 @@ func TestHandleUpdateIdentifierFirstFlowFailOnUpdateIdLoginFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -1536,7 +2302,7 @@ func TestHandleUpdateIdentifierFirstFlowFailOnUpdateIdLoginFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1558,6 +2324,7 @@ func TestHandleUpdateIdentifierFirstFlowRedirectsToTenantSelection(t *testing.T)
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	flowId := "flow-123"
@@ -1585,13 +2352,13 @@ func TestHandleUpdateIdentifierFirstFlowRedirectsToTenantSelection(t *testing.T)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	flowCookie := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(loginChallenge)}
-	mockTenantMgr.EXPECT().NeedsTenantSelectionByEmail(gomock.Any(), "user@example.com", flowCookie, loginChallenge).
+	mockTenantMgr.EXPECT().NeedsTenantSelectionByEmail(gomock.Any(), "user@example.com", flowCookie, loginChallenge, gomock.Any()).
 		Return(true, flowCookie, nil)
 	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), flowCookie).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1632,6 +2399,7 @@ func TestHandleUpdateIdentifierFirstFlowProceedsWhenNoTenants(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	flowId := "flow-123"
@@ -1665,13 +2433,13 @@ func TestHandleUpdateIdentifierFirstFlowProceedsWhenNoTenants(t *testing.T) {
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	flowCookie := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(loginChallenge)}
-	mockTenantMgr.EXPECT().NeedsTenantSelectionByEmail(gomock.Any(), "user@example.com", flowCookie, loginChallenge).
+	mockTenantMgr.EXPECT().NeedsTenantSelectionByEmail(gomock.Any(), "user@example.com", flowCookie, loginChallenge, gomock.Any()).
 		Return(false, sentinelCookie, nil)
 	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), sentinelCookie).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1706,6 +2474,7 @@ func TestHandleUpdateIdentifierFirstFlowSkipsTenantForNonHydraFlow(t *testing.T)
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 	mockTenantMgr := NewMockTenantResolverInterface(ctrl)
 
 	flowId := "flow-123"
@@ -1733,7 +2502,7 @@ func TestHandleUpdateIdentifierFirstFlowSkipsTenantForNonHydraFlow(t *testing.T)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, mockTenantMgr, BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1765,12 +2534,16 @@ func TestHandleUpdateFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	flowId := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
 	flow.Id = flowId
 	flow.ExpiresAt = time.Now().UTC()
+	flow.IssuedAt = time.Now().UTC().Add(-time.Minute)
+	flow.Oauth2LoginRequest = &kClient.OAuth2LoginRequest{Client: &kClient.OAuth2Client{ClientId: kClient.PtrString("client-1")}}
 	redirectTo := "https://some/path/to/somewhere"
 	redirectFlow := new(BrowserLocationChangeRequired)
 	redirectFlow.RedirectTo = &redirectTo
@@ -1785,6 +2558,7 @@ func TestHandleUpdateFlow(t *testing.T) {
 
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldRegenerateBackupCodesWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, nil)
@@ -1792,11 +2566,16 @@ func TestHandleUpdateFlow(t *testing.T) {
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(redirectFlow, nil, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
+
+	// The redirect to the identity provider does not complete the login,
+	// neither the success nor the duration are recorded.
+	mockMonitor.EXPECT().SetAuthEventMetric(gomock.Any(), gomock.Any()).Times(0)
+	mockMonitor.EXPECT().SetLoginDurationMetric(gomock.Any(), gomock.Any()).Times(0)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1816,6 +2595,64 @@ func TestHandleUpdateFlow(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateFlowSessionIssued(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	flowId := "test"
+	identityId := "test"
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = flowId
+	flow.IssuedAt = time.Now().UTC().Add(-time.Minute)
+	returnToUrl := "https://some/return/url"
+	flow.ReturnTo = &returnToUrl
+
+	flowBody := new(kClient.UpdateLoginFlowBody)
+	flowBody.UpdateLoginFlowWithPasswordMethod = kClient.NewUpdateLoginFlowWithPasswordMethod(identityId, "password", "password")
+
+	session := kClient.NewSession(identityId)
+	session.Identity = kClient.NewIdentity(identityId, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	successfulLogin := kClient.NewSuccessfulNativeLogin(*session)
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", flowId)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
+	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(nil, successfulLogin, req.Cookies(), nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventLogin, "password", "", monitoring.OutcomeSuccess), 1.0).Return(nil)
+	mockMonitor.EXPECT().SetLoginDurationMetric(monitoring.LoginDurationTags("password", ""), gomock.Any()).Return(nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+}
+
 func TestHandleUpdateFlowWhenProviderNotAllowed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1824,6 +2661,8 @@ func TestHandleUpdateFlowWhenProviderNotAllowed(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -1842,11 +2681,14 @@ func TestHandleUpdateFlowWhenProviderNotAllowed(t *testing.T) {
 
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(false, nil)
+
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventLogin, "oidc", "", monitoring.OutcomeRejected), 1.0).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1865,38 +2707,212 @@ func TestHandleUpdateFlowFailOnParseLoginFlowMethodBody(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	flowId := "test"
+
+	flowBody := new(kClient.UpdateLoginFlowBody)
+	flowBody.UpdateLoginFlowWithOidcMethod = kClient.NewUpdateLoginFlowWithOidcMethod("oidc", "oidc")
+
+	loginFlow := kClient.NewLoginFlowWithDefaults()
+	loginFlow.SetRequestedAal("aal1")
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", flowId)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, gomock.Any()).Return(loginFlow, nil, nil)
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, nil, fmt.Errorf("error"))
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatal("Expected HTTP status code 500, got: ", res.Status)
+	}
+}
+
+func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockSecurityLogger := NewMockSecurityLoggerInterface(ctrl)
+
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	session := kClient.NewSession("test")
+
+	lookupMethod := kClient.NewSessionAuthenticationMethodWithDefaults()
+	lookupMethod.SetMethod("lookup_secret")
+
+	pwdMethod := kClient.NewSessionAuthenticationMethodWithDefaults()
+	pwdMethod.SetMethod("password")
+
+	session.SetAuthenticatorAssuranceLevel("aal2")
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{*pwdMethod, *lookupMethod}
+
+	flowId := "test"
+	redirectTo := "https://some/path/to/somewhere"
+	redirectFlow := new(BrowserLocationChangeRequired)
+	redirectFlow.RedirectTo = &redirectTo
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = flowId
+	returnTo := "https://some/return/url"
+	flow.ReturnTo = &returnTo
+
+	flowBody := new(kClient.UpdateLoginFlowBody)
+	flowBody.UpdateLoginFlowWithLookupSecretMethod = kClient.NewUpdateLoginFlowWithLookupSecretMethod("xt879l1a", "lookup_secret")
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", flowId)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
+	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(redirectFlow, nil, req.Cookies(), nil)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFA").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2}, nil).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.Service.HasTOTPAvailable").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), gomock.Any()).Return(true, nil)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldRegenerateBackupCodesWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockService.EXPECT().GetBackupCodes(gomock.Any(), session.Identity.GetId()).Return(&BackupCodes{Configured: true, Remaining: 1, Threshold: 3}, nil)
+	mockLogger.EXPECT().Security().Return(mockSecurityLogger)
+	mockSecurityLogger.EXPECT().BackupCodeUsed(session.Identity.GetId(), 1, gomock.Any())
+	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if _, err := json.Marshal(flow); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
+	}
+
+	resp := new(BrowserLocationChangeRequired)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if !strings.Contains(resp.GetRedirectTo(), "/backup_codes_regenerate") {
+		t.Fatalf("Expected redirect to backup codes regeneration, got %s", resp.GetRedirectTo())
+	}
+}
+
+func TestHandleUpdateLoginFlowRemindLaterSkipsRegenerateBackupCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockSecurityLogger := NewMockSecurityLoggerInterface(ctrl)
+
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	session := kClient.NewSession("test")
+
+	lookupMethod := kClient.NewSessionAuthenticationMethodWithDefaults()
+	lookupMethod.SetMethod("lookup_secret")
+
+	pwdMethod := kClient.NewSessionAuthenticationMethodWithDefaults()
+	pwdMethod.SetMethod("password")
+
+	session.SetAuthenticatorAssuranceLevel("aal2")
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{*pwdMethod, *lookupMethod}
+
+	flowId := "test"
+	redirectTo := "https://some/path/to/somewhere"
+	redirectFlow := new(BrowserLocationChangeRequired)
+	redirectFlow.RedirectTo = &redirectTo
 
-	flowId := "test"
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = flowId
+	returnTo := "https://some/return/url"
+	flow.ReturnTo = &returnTo
 
 	flowBody := new(kClient.UpdateLoginFlowBody)
-	flowBody.UpdateLoginFlowWithOidcMethod = kClient.NewUpdateLoginFlowWithOidcMethod("oidc", "oidc")
-
-	loginFlow := kClient.NewLoginFlowWithDefaults()
-	loginFlow.SetRequestedAal("aal1")
+	flowBody.UpdateLoginFlowWithLookupSecretMethod = kClient.NewUpdateLoginFlowWithLookupSecretMethod("xt879l1a", "lookup_secret")
 
 	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
 	values := req.URL.Query()
 	values.Add("flow", flowId)
 	req.URL.RawQuery = values.Encode()
 
-	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, gomock.Any()).Return(loginFlow, nil, nil)
-	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, nil, fmt.Errorf("error"))
-	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
+	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(redirectFlow, nil, req.Cookies(), nil)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFA").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{BackupCodesRemindLater: true}, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2}, nil).AnyTimes()
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.Service.HasTOTPAvailable").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), gomock.Any()).Return(true, nil)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldRegenerateBackupCodesWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockService.EXPECT().GetBackupCodes(gomock.Any(), session.Identity.GetId()).Return(&BackupCodes{Configured: true, Remaining: 1, Threshold: 3}, nil)
+	mockLogger.EXPECT().Security().Return(mockSecurityLogger)
+	mockSecurityLogger.EXPECT().BackupCodeUsed(session.Identity.GetId(), 1, gomock.Any())
+	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
 	res := w.Result()
 
-	if res.StatusCode != http.StatusInternalServerError {
-		t.Fatal("Expected HTTP status code 500, got: ", res.Status)
+	if _, err := json.Marshal(flow); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
+	}
+
+	resp := new(BrowserLocationChangeRequired)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if resp.GetRedirectTo() != redirectTo {
+		t.Fatalf("Expected redirect to %s, got %s", redirectTo, resp.GetRedirectTo())
 	}
 }
 
-func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
+// TestHandleUpdateLoginFlowReportsBackupCodeOnlyWhenSubmitted verifies that
+// a login reusing a session that used a backup code does not report the code
+// as used again.
+func TestHandleUpdateLoginFlowReportsBackupCodeOnlyWhenSubmitted(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -1904,6 +2920,8 @@ func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockSecurityLogger := NewMockSecurityLoggerInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	session := kClient.NewSession("test")
@@ -1928,7 +2946,7 @@ func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
 	flow.ReturnTo = &returnTo
 
 	flowBody := new(kClient.UpdateLoginFlowBody)
-	flowBody.UpdateLoginFlowWithLookupSecretMethod = kClient.NewUpdateLoginFlowWithLookupSecretMethod("xt879l1a", "lookup_secret")
+	flowBody.UpdateLoginFlowWithPasswordMethod = kClient.NewUpdateLoginFlowWithPasswordMethod("test", "password", "password")
 
 	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
 	values := req.URL.Query()
@@ -1937,24 +2955,27 @@ func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
 
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
 	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(redirectFlow, nil, req.Cookies(), nil)
 
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceVerificationWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFA").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{BackupCodesRemindLater: true}, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2}, nil).AnyTimes()
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.Service.HasTOTPAvailable").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), gomock.Any()).Return(true, nil)
 
 	mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldRegenerateBackupCodesWithSession").Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
-	mockService.EXPECT().HasNotEnoughLookupSecretsLeft(gomock.Any(), session.Identity.GetId()).Return(true, nil)
+	mockService.EXPECT().GetBackupCodes(gomock.Any(), session.Identity.GetId()).Return(&BackupCodes{Configured: true, Remaining: 1, Threshold: 3}, nil)
+	mockLogger.EXPECT().Security().Return(mockSecurityLogger).Times(0)
+	mockSecurityLogger.EXPECT().BackupCodeUsed(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, true, true, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -1966,6 +2987,14 @@ func TestHandleUpdateLoginFlowRedirectToRegenerateBackupCodes(t *testing.T) {
 	if res.StatusCode != http.StatusOK {
 		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
 	}
+
+	resp := new(BrowserLocationChangeRequired)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if resp.GetRedirectTo() != redirectTo {
+		t.Fatalf("Expected redirect to %s, got %s", redirectTo, resp.GetRedirectTo())
+	}
 }
 
 func TestHandleUpdateFlowFailOnUpdateOIDCLoginFlow(t *testing.T) {
@@ -1976,6 +3005,7 @@ func TestHandleUpdateFlowFailOnUpdateOIDCLoginFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 	flowId := "test"
@@ -1993,13 +3023,13 @@ func TestHandleUpdateFlowFailOnUpdateOIDCLoginFlow(t *testing.T) {
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(nil, nil, nil, fmt.Errorf("error"))
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2018,6 +3048,7 @@ func TestHandleUpdateFlowFailOnCheckAllowedProvider(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewLoginFlowWithDefaults()
@@ -2033,12 +3064,13 @@ func TestHandleUpdateFlowFailOnCheckAllowedProvider(t *testing.T) {
 
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, fmt.Errorf("error"))
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(false, fmt.Errorf("error"))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2057,6 +3089,8 @@ func TestHandleUpdateFlowRedirectToVerification(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
 	flowId := "test"
 	identityId := "test"
@@ -2079,18 +3113,21 @@ func TestHandleUpdateFlowRedirectToVerification(t *testing.T) {
 
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
 	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(nil, nil, req.Cookies(), nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
 	mockService.EXPECT().RequireVerificationForEmail(gomock.Any(), session).Return(true, unverifiedEmail, nil).Times(1)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), gomock.Any()).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1}, nil)
 	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
 	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventVerificationRedirect, "", "", ""), 1.0).Return(nil)
+
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, true, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, true, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2115,6 +3152,97 @@ func TestHandleUpdateFlowRedirectToVerification(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateFlowStepUpForRequestedACR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	flowId := "test"
+	identityId := "test"
+	loginChallenge := "login_challenge_2341235123231"
+
+	client := kClient.NewOAuth2Client()
+	client.SetClientId("admin-console")
+	oidcContext := kClient.NewOAuth2ConsentRequestOpenIDConnectContext()
+	oidcContext.SetAcrValues([]string{"aal2"})
+	loginRequest := kClient.NewOAuth2LoginRequest()
+	loginRequest.SetClient(*client)
+	loginRequest.SetOidcContext(*oidcContext)
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Id = flowId
+	flow.SetOauth2LoginChallenge(loginChallenge)
+	flow.SetOauth2LoginRequest(*loginRequest)
+
+	flowBody := new(kClient.UpdateLoginFlowBody)
+	flowBody.UpdateLoginFlowWithPasswordMethod = kClient.NewUpdateLoginFlowWithPasswordMethod(identityId, "password", "password")
+
+	session := kClient.NewSession(identityId)
+	session.Identity = kClient.NewIdentity(identityId, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	method := "password"
+	aal := kClient.AUTHENTICATORASSURANCELEVEL_AAL1
+	session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &method}}
+	session.AuthenticatorAssuranceLevel = &aal
+
+	expectedSubject := mfa.Subject{
+		ClientID:     "admin-console",
+		IdentityID:   identityId,
+		SchemaID:     "test.json",
+		FirstFactor:  "password",
+		RequestedACR: []string{"aal2"},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_UPDATE_LOGIN_FLOW_URL, nil)
+	values := req.URL.Query()
+	values.Add("flow", flowId)
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
+	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
+	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(nil, nil, req.Cookies(), nil)
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockMFAPolicy.EXPECT().Evaluate(gomock.Any(), expectedSubject).Return(&mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, ACR: "aal2"}, nil)
+	mockService.EXPECT().HasTOTPAvailable(gomock.Any(), identityId).Return(true, nil)
+	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), gomock.Any()).Return(nil)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Return(context.Background(), trace.SpanFromContext(context.Background())).AnyTimes()
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	redirect := BrowserLocationChangeRequired{}
+	if err := json.Unmarshal(data, &redirect); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	expectedRedirect, _ := url.JoinPath(BASE_URL, "ui/login")
+	expectedRedirect = expectedRedirect + "?login_challenge=" + loginChallenge
+	if redirect.RedirectTo == nil || *redirect.RedirectTo != expectedRedirect {
+		t.Errorf("expected redirect_to to be %s, got %v", expectedRedirect, redirect.RedirectTo)
+	}
+}
+
 func TestHandleUpdateFlowRequireVerificationError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2123,6 +3251,7 @@ func TestHandleUpdateFlowRequireVerificationError(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	identityId := "test"
@@ -2144,7 +3273,7 @@ func TestHandleUpdateFlowRequireVerificationError(t *testing.T) {
 
 	mockService.EXPECT().ParseLoginFlowMethodBody(gomock.Any(), gomock.Any()).Return(flowBody, req.Cookies(), nil)
 	mockService.EXPECT().GetLoginFlow(gomock.Any(), flowId, req.Cookies()).Return(flow, nil, nil)
-	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+	mockService.EXPECT().CheckAllowedProvider(gomock.Any(), gomock.Any(), gomock.Any(), "").Return(true, nil)
 	mockService.EXPECT().UpdateLoginFlow(gomock.Any(), flowId, *flowBody, req.Cookies()).Return(nil, nil, req.Cookies(), nil)
 	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{}, nil)
 	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
@@ -2155,7 +3284,7 @@ func TestHandleUpdateFlowRequireVerificationError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, true, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, true, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2184,6 +3313,7 @@ func TestHandleCreateRecoveryFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/reset_email"
 
@@ -2196,7 +3326,7 @@ func TestHandleCreateRecoveryFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2218,6 +3348,7 @@ func TestHandleCreateRecoveryFlowWithSession(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/reset_email"
 
@@ -2239,7 +3370,7 @@ func TestHandleCreateRecoveryFlowWithSession(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2274,6 +3405,7 @@ func TestHandleCreateRecoveryFlowFailOnCreateBrowserRecoveryFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/reset_email"
 
@@ -2286,7 +3418,7 @@ func TestHandleCreateRecoveryFlowFailOnCreateBrowserRecoveryFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2305,6 +3437,7 @@ func TestHandleGetRecoveryFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewRecoveryFlowWithDefaults()
@@ -2320,7 +3453,7 @@ func TestHandleGetRecoveryFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2351,6 +3484,7 @@ func TestHandleGetRecoveryFlowFail(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewRecoveryFlowWithDefaults()
@@ -2366,7 +3500,7 @@ func TestHandleGetRecoveryFlowFail(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2385,6 +3519,7 @@ func TestHandleUpdateRecoveryFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewRecoveryFlowWithDefaults()
@@ -2408,7 +3543,7 @@ func TestHandleUpdateRecoveryFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2436,6 +3571,7 @@ func TestHandleUpdateRecoveryFlowFailOnParseRecoveryFlowMethodBody(t *testing.T)
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 
@@ -2452,7 +3588,7 @@ func TestHandleUpdateRecoveryFlowFailOnParseRecoveryFlowMethodBody(t *testing.T)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2471,6 +3607,7 @@ func TestHandleCreateSettingsFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/setup_complete"
 
@@ -2484,7 +3621,7 @@ func TestHandleCreateSettingsFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2506,6 +3643,7 @@ func TestHandleCreateSettingsFlowWithRedirect(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/setup_complete"
 
@@ -2527,7 +3665,7 @@ func TestHandleCreateSettingsFlowWithRedirect(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2549,6 +3687,7 @@ func TestHandleCreateSettingsFlowFailOnCreateBrowserSettingsFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	redirect := "https://example.com/ui/setup_complete"
 
@@ -2562,7 +3701,7 @@ func TestHandleCreateSettingsFlowFailOnCreateBrowserSettingsFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2581,6 +3720,7 @@ func TestHandleGetSettingsFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewSettingsFlowWithDefaults()
@@ -2597,7 +3737,7 @@ func TestHandleGetSettingsFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2627,6 +3767,7 @@ func TestHandleGetSettingsFlowWithRedirect(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewSettingsFlowWithDefaults()
@@ -2650,7 +3791,7 @@ func TestHandleGetSettingsFlowWithRedirect(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2673,6 +3814,7 @@ func TestHandleGetSettingsFlowFail(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	id := "test"
 	flow := kClient.NewSettingsFlowWithDefaults()
@@ -2688,7 +3830,7 @@ func TestHandleGetSettingsFlowFail(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2699,6 +3841,123 @@ func TestHandleGetSettingsFlowFail(t *testing.T) {
 	}
 }
 
+func TestHandleGetBackupCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("identity", "schema", "", nil)
+	usedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	backupCodes := &BackupCodes{
+		Configured: true,
+		Remaining:  11,
+		Used:       []UsedBackupCode{{UsedAt: usedAt}},
+		Threshold:  3,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_BACKUP_CODES_URL, nil)
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(session, nil, nil)
+	mockService.EXPECT().GetBackupCodes(gomock.Any(), "identity").Return(backupCodes, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected HTTP status code 200, got: ", res.Status)
+	}
+
+	resp := new(BackupCodes)
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		t.Fatalf("Expected error to be nil got %v", err)
+	}
+	if !reflect.DeepEqual(resp, backupCodes) {
+		t.Fatalf("Expected backup codes to be %+v, got %+v", backupCodes, resp)
+	}
+}
+
+func TestHandleGetBackupCodesNoSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	statusCode := int64(http.StatusUnauthorized)
+	genericErr := kClient.GenericError{Code: &statusCode, Message: "Unauthorized"}
+	errorModel := kClient.ErrorGeneric{Error: genericErr}
+
+	openAPIErr := &kClient.GenericOpenAPIError{}
+	v := reflect.ValueOf(openAPIErr).Elem()
+	f := v.FieldByName("model")
+	rf := reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+	rf.Set(reflect.ValueOf(errorModel))
+
+	req := httptest.NewRequest(http.MethodGet, HANDLE_GET_BACKUP_CODES_URL, nil)
+
+	mockService.EXPECT().CheckSession(gomock.Any(), req.Cookies()).Return(nil, nil, openAPIErr)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("Expected HTTP status code 401, got: ", res.Status)
+	}
+}
+
+func TestHandleBackupCodesRemindLater(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
+
+	stateCookie := cookies.FlowStateCookie{LoginChallengeHash: "hash", BackupCodeUsed: true}
+
+	req := httptest.NewRequest(http.MethodPost, HANDLE_BACKUP_CODES_REMIND_LATER_URL, nil)
+
+	mockCookieManager.EXPECT().GetStateCookie(gomock.Any()).Return(stateCookie, nil)
+	mockCookieManager.EXPECT().SetStateCookie(gomock.Any(), cookies.FlowStateCookie{
+		LoginChallengeHash:     "hash",
+		BackupCodeUsed:         true,
+		BackupCodesRemindLater: true,
+	}).Return(nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatal("Expected HTTP status code 204, got: ", res.Status)
+	}
+}
+
 func TestHandleUpdateSettingsFlow(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2707,6 +3966,7 @@ func TestHandleUpdateSettingsFlow(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewSettingsFlowWithDefaults()
@@ -2728,7 +3988,7 @@ func TestHandleUpdateSettingsFlow(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2756,6 +4016,7 @@ func TestHandleUpdateSettingsFlowPrivilegedSessionRequired(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	returnTo := "https://example.com/settings"
@@ -2788,7 +4049,7 @@ func TestHandleUpdateSettingsFlowPrivilegedSessionRequired(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2823,6 +4084,7 @@ func TestHandleUpdateSettingsFlowWithRedirect(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 	flow := kClient.NewSettingsFlowWithDefaults()
@@ -2846,7 +4108,7 @@ func TestHandleUpdateSettingsFlowWithRedirect(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2878,6 +4140,7 @@ func TestHandleUpdateWebAuthnSettingsFlowWithReturnTo(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	returnTo := "https://example.com/ui/login?login_challenge=test"
 	flowId := "test"
@@ -2901,7 +4164,7 @@ func TestHandleUpdateWebAuthnSettingsFlowWithReturnTo(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2924,6 +4187,7 @@ func TestHandleUpdateWebAuthnSettingsFlowWithoutReturnTo(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	returnTo := "https://example.com/setup_passkey"
 	flowId := "test"
@@ -2956,7 +4220,7 @@ func TestHandleUpdateWebAuthnSettingsFlowWithoutReturnTo(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -2979,6 +4243,7 @@ func TestHandleUpdateSettingsFlowFailOnParseSettingsFlowMethodBody(t *testing.T)
 	mockService := NewMockServiceInterface(ctrl)
 	mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
 	mockTracer := NewMockTracingInterface(ctrl)
+	mockMFAPolicy := NewMockMFAPolicyInterface(ctrl)
 
 	flowId := "test"
 
@@ -2996,7 +4261,7 @@ func TestHandleUpdateSettingsFlowFailOnParseSettingsFlowMethodBody(t *testing.T)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
-	NewAPI(mockService, false, false, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, false, false, false, mockMFAPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 
@@ -3086,10 +4351,12 @@ func TestHandleCreateVerificationFlow(t *testing.T) {
 				false,
 				false,
 				false,
+				NewMockMFAPolicyInterface(ctrl),
 				tenants.NewNoOpTenantResolver(),
 				BASE_URL,
 				mockCookieManager,
 				mockTracer,
+				monitoring.NewNoopMonitor("", nil),
 				mockLogger,
 			).RegisterEndpoints(mux)
 
@@ -3137,8 +4404,8 @@ func TestHandleGetVerificationFlow(t *testing.T) {
 		expectBody     bool
 	}{
 		{
-			name:        "missing flow id",
-			queryFlowID: "",
+			name:           "missing flow id",
+			queryFlowID:    "",
 			expectedStatus: http.StatusBadRequest,
 			expectBody:     false,
 		},
@@ -3226,10 +4493,12 @@ func TestHandleGetVerificationFlow(t *testing.T) {
 				false,
 				false,
 				false,
+				NewMockMFAPolicyInterface(ctrl),
 				tenants.NewNoOpTenantResolver(),
 				BASE_URL,
 				mockCookieManager,
 				mockTracer,
+				monitoring.NewNoopMonitor("", nil),
 				mockLogger,
 			).RegisterEndpoints(mux)
 
@@ -3410,10 +4679,12 @@ func TestHandleUpdateVerificationFlow(t *testing.T) {
 				false,
 				false,
 				false,
+				NewMockMFAPolicyInterface(ctrl),
 				tenants.NewNoOpTenantResolver(),
 				BASE_URL,
 				mockCookieManager,
 				mockTracer,
+				monitoring.NewNoopMonitor("", nil),
 				mockLogger,
 			).RegisterEndpoints(mux)
 
@@ -3463,9 +4734,14 @@ func TestShouldEnforceMFA(t *testing.T) {
 		expectedErrMsg string
 	}{
 		{
-			name:           "mfa disabled returns false immediately",
-			mfaEnabled:     false,
-			setupMocks:     func(mockService *MockServiceInterface, mockLogger *MockLoggerInterface) {},
+			name:       "mfa disabled does not enforce mfa",
+			mfaEnabled: false,
+			setupMocks: func(mockService *MockServiceInterface, mockLogger *MockLoggerInterface) {
+				session := kClient.NewSession(identityId)
+				session.Identity = identity
+				session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &pwdMethod}}
+				mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
+			},
 			expectedResult: false,
 		},
 		{
@@ -3528,9 +4804,35 @@ func TestShouldEnforceMFA(t *testing.T) {
 				session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &pwdMethod}}
 				mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
 				mockService.EXPECT().HasTOTPAvailable(gomock.Any(), identityId).Return(false, nil)
+				mockService.EXPECT().HasWebAuthnAvailable(gomock.Any(), identityId).Return(false, nil)
 			},
 			expectedResult: true,
 		},
+		{
+			name:       "session without totp and with a security key does not enforce mfa",
+			mfaEnabled: true,
+			setupMocks: func(mockService *MockServiceInterface, mockLogger *MockLoggerInterface) {
+				session := kClient.NewSession(identityId)
+				session.Identity = identity
+				session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &pwdMethod}}
+				mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
+				mockService.EXPECT().HasTOTPAvailable(gomock.Any(), identityId).Return(false, nil)
+				mockService.EXPECT().HasWebAuthnAvailable(gomock.Any(), identityId).Return(true, nil)
+			},
+			expectedResult: false,
+		},
+		{
+			name:       "session with code method does not enforce mfa",
+			mfaEnabled: true,
+			setupMocks: func(mockService *MockServiceInterface, mockLogger *MockLoggerInterface) {
+				codeMethod := "code"
+				session := kClient.NewSession(identityId)
+				session.Identity = identity
+				session.AuthenticationMethods = []kClient.SessionAuthenticationMethod{{Method: &codeMethod}}
+				mockService.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
+			},
+			expectedResult: false,
+		},
 		{
 			name:       "has totp available returns error propagates error",
 			mfaEnabled: true,
@@ -3562,11 +4864,16 @@ func TestShouldEnforceMFA(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(
 				context.Background(), trace.SpanFromContext(context.Background()),
 			).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), "mfa.Service.Evaluate").Return(
+				context.Background(), trace.SpanFromContext(context.Background()),
+			).AnyTimes()
 
 			tt.setupMocks(mockService, mockLogger)
 
-			api := NewAPI(mockService, false, tt.mfaEnabled, false, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, mockLogger)
-			result, err := api.shouldEnforceMFA(context.Background(), []*http.Cookie{})
+			mfaPolicy := mfa.NewService(mfa.NewDefaultPolicy(tt.mfaEnabled, false), nil, mockTracer, nil, mockLogger)
+
+			api := NewAPI(mockService, false, false, false, mfaPolicy, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger)
+			result, err := api.shouldEnforceMFA(context.Background(), []*http.Cookie{}, cookies.FlowStateCookie{}, "", nil)
 
 			if tt.expectedErrMsg != "" {
 				if err == nil {
@@ -3585,3 +4892,73 @@ func TestShouldEnforceMFA(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldEnforceMFAWithSessionMethods(t *testing.T) {
+	identityId := "identity-test"
+
+	tests := []struct {
+		name           string
+		methods        []string
+		setupMocks     func(mockService *MockServiceInterface)
+		expectedResult string
+		expectErr      bool
+	}{
+		{
+			name:    "webauthn only rule asks for a security key",
+			methods: []string{mfa.MethodWebAuthn},
+			setupMocks: func(mockService *MockServiceInterface) {
+				mockService.EXPECT().HasWebAuthnAvailable(gomock.Any(), identityId).Return(false, nil)
+			},
+			expectedResult: mfa.MethodWebAuthn,
+		},
+		{
+			name:    "backup codes only rule accepts remaining backup codes",
+			methods: []string{mfa.MethodLookupSecret},
+			setupMocks: func(mockService *MockServiceInterface) {
+				mockService.EXPECT().GetBackupCodes(gomock.Any(), identityId).Return(&BackupCodes{Configured: true, Remaining: 3}, nil)
+			},
+			expectedResult: "",
+		},
+		{
+			name:    "backup codes only rule cannot be set up",
+			methods: []string{mfa.MethodLookupSecret},
+			setupMocks: func(mockService *MockServiceInterface) {
+				mockService.EXPECT().GetBackupCodes(gomock.Any(), identityId).Return(&BackupCodes{}, nil)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+			mockCookieManager := NewMockAuthCookieManagerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "kratos.API.shouldEnforceMFAWithSession").Return(
+				context.Background(), trace.SpanFromContext(context.Background()),
+			)
+
+			tt.setupMocks(mockService)
+
+			session := kClient.NewSession(identityId)
+			session.Identity = kClient.NewIdentity(identityId, "test.json", "https://test.com/test.json", map[string]string{})
+			requirement := &mfa.Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: tt.methods, Rule: "test"}
+
+			api := NewAPI(mockService, false, false, false, nil, tenants.NewNoOpTenantResolver(), BASE_URL, mockCookieManager, mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger)
+			result, err := api.shouldEnforceMFAWithSession(context.Background(), session, requirement)
+
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+
+			if result != tt.expectedResult {
+				t.Fatalf("expected %q, got %q", tt.expectedResult, result)
+			}
+		})
+	}
+}
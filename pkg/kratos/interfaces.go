@@ -7,10 +7,12 @@ import (
 	"context"
 	"net/http"
 
+	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
 	"github.com/canonical/identity-platform-login-ui/internal/hydra"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 )
 
@@ -31,6 +33,11 @@ type AuthorizerInterface interface {
 	ListObjects(context.Context, string, string, string) ([]string, error)
 }
 
+// MFAPolicyInterface decides which second factors a login must go through.
+type MFAPolicyInterface interface {
+	Evaluate(context.Context, mfa.Subject) (*mfa.Requirement, error)
+}
+
 type TenantResolverInterface interface {
 	// Enabled reports whether tenant selection is active.
 	// When false, handlers skip tenant-selection redirects entirely.
@@ -52,23 +59,26 @@ type TenantResolverInterface interface {
 	// NeedsTenantSelection checks whether the user needs to pick a tenant.
 	// Returns needsSelection=true when the user has tenants but none is
 	// selected yet. When the user has no tenants, the sentinel is stored in
-	// the returned cookie. When disabled this always returns false.
-	NeedsTenantSelection(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string) (needsSelection bool, updatedCookie cookies.FlowStateCookie, err error)
+	// the returned cookie. The tenant hinted in loginRequest, which may be
+	// nil, is picked when the user is a member of it. When disabled this
+	// always returns false.
+	NeedsTenantSelection(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (needsSelection bool, updatedCookie cookies.FlowStateCookie, err error)
 	// NeedsTenantSelectionByEmail is like NeedsTenantSelection but works with
 	// an email address instead of a session. Used after the identifier-first
 	// step when the user has entered their email but hasn't authenticated yet.
-	NeedsTenantSelectionByEmail(ctx context.Context, email string, cookie cookies.FlowStateCookie, loginChallenge string) (needsSelection bool, updatedCookie cookies.FlowStateCookie, err error)
+	NeedsTenantSelectionByEmail(ctx context.Context, email string, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (needsSelection bool, updatedCookie cookies.FlowStateCookie, err error)
 	// InterceptLogin is the main plugin entry-point used by handleCreateFlow.
 	// It returns a single LoginInterception that tells the handler whether to
 	// defer MFA checks, redirect to tenant selection, or accept the login.
 	// When disabled this returns zero-value fields (no intervention).
-	InterceptLogin(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string) (tenants.LoginInterception, error)
+	InterceptLogin(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (tenants.LoginInterception, error)
 }
 
 type ServiceInterface interface {
 	CheckSession(context.Context, []*http.Cookie) (*kClient.Session, []*http.Cookie, error)
-	AcceptLoginRequest(context.Context, *kClient.Session, string, string) (*BrowserLocationChangeRequired, []*http.Cookie, error)
+	AcceptLoginRequest(context.Context, *kClient.Session, string, string, string) (*BrowserLocationChangeRequired, []*http.Cookie, error)
 	MustReAuthenticate(context.Context, string, *kClient.Session, cookies.FlowStateCookie) (bool, error)
+	GetLoginRequest(context.Context, string) (*hClient.OAuth2LoginRequest, []*http.Cookie, error)
 	CreateBrowserLoginFlow(context.Context, string, string, string, bool, []*http.Cookie) (*kClient.LoginFlow, []*http.Cookie, error)
 	CreateBrowserRegistrationFlow(context.Context, string) (*kClient.RegistrationFlow, []*http.Cookie, error)
 	CreateBrowserRecoveryFlow(context.Context, string) (*kClient.RecoveryFlow, []*http.Cookie, error)
@@ -86,16 +96,19 @@ type ServiceInterface interface {
 	UpdateSettingsFlow(context.Context, string, kClient.UpdateSettingsFlowBody, []*http.Cookie) (*kClient.SettingsFlow, *BrowserLocationChangeRequired, []*http.Cookie, error)
 	UpdateVerificationFlow(context.Context, string, kClient.UpdateVerificationFlowBody, []*http.Cookie) (*kClient.VerificationFlow, []*http.Cookie, error)
 	GetFlowError(context.Context, string) (*kClient.FlowError, []*http.Cookie, error)
-	CheckAllowedProvider(context.Context, *kClient.LoginFlow, *kClient.UpdateLoginFlowBody) (bool, error)
-	FilterFlowProviderList(context.Context, *kClient.LoginFlow) (*kClient.LoginFlow, error)
+	CheckAllowedProvider(context.Context, *kClient.LoginFlow, *kClient.UpdateLoginFlowBody, string) (bool, error)
+	FilterFlowProviderList(context.Context, *kClient.LoginFlow, string) (*kClient.LoginFlow, error)
+	CheckAllowedRegistration(context.Context, *kClient.RegistrationFlow, *kClient.UpdateRegistrationFlowBody, string) (bool, error)
+	FilterRegistrationFlowProviderList(context.Context, *kClient.RegistrationFlow, string) (*kClient.RegistrationFlow, error)
 	ParseLoginFlowMethodBody(*http.Request, string) (*kClient.UpdateLoginFlowBody, []*http.Cookie, error)
+	GetPasskeyLoginOptions(context.Context, *kClient.LoginFlow) (*PasskeyLoginOptions, error)
 	ParseIdentifierFirstLoginFlowMethodBody(*http.Request) (*kClient.UpdateLoginFlowWithIdentifierFirstMethod, []*http.Cookie, error)
 	ParseRegistrationFlowMethodBody(*http.Request) (*kClient.UpdateRegistrationFlowBody, error)
 	ParseRecoveryFlowMethodBody(*http.Request) (*kClient.UpdateRecoveryFlowBody, error)
 	ParseSettingsFlowMethodBody(*http.Request) (*kClient.UpdateSettingsFlowBody, error)
 	HasTOTPAvailable(context.Context, string) (bool, error)
 	HasWebAuthnAvailable(context.Context, string) (bool, error)
-	HasNotEnoughLookupSecretsLeft(context.Context, string) (bool, error)
+	GetBackupCodes(context.Context, string) (*BackupCodes, error)
 	RequireVerificationForEmail(context.Context, *kClient.Session) (bool, string, error)
 }
 
@@ -29,7 +29,6 @@ import (
 )
 
 const (
-	MinimumBackupCodesAmount     = 3
 	RecoveryCodeSent             = 1060003
 	InvalidProperty              = 4000002
 	NotEnoughCharacters          = 4000003
@@ -48,8 +47,33 @@ const (
 	NewPasswordPolicyViolation   = 4000039
 	InvalidRecoveryCode          = 4060006
 	AmrPopValue                  = "pop"
+	AmrMfaValue                  = "mfa"
 )
 
+// RegistrationProvider is the provider denying registration to an app
+const RegistrationProvider = "registration"
+
+// ErrRegistrationNotAllowed is returned for the registration flows of an app
+// denied the registration provider
+var ErrRegistrationNotAllowed = errors.New("registration not allowed")
+
+// firstPartyMethods are the methods restricted by the authorization model as
+// providers named after the method, as the upstream providers of oidc and saml
+var firstPartyMethods = []string{"password", "webauthn", "passkey", "code"}
+
+// amrValues maps the kratos authentication methods to the RFC 8176
+// authentication method reference values.
+var amrValues = map[string]string{
+	"password":      "pwd",
+	"totp":          "otp",
+	"lookup_secret": "otp",
+	"code":          "otp",
+	"webauthn":      "hwk",
+	"passkey":       "hwk",
+	"oidc":          "fed",
+	"saml":          "fed",
+}
+
 type Service struct {
 	kratos      KratosClientInterface
 	kratosAdmin KratosAdminClientInterface
@@ -58,6 +82,7 @@ type Service struct {
 
 	oidcWebAuthnSequencingEnabled bool
 	multiTenancyEnabled           bool
+	backupCodesThreshold          int
 
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
@@ -121,6 +146,34 @@ type LookupSecrets []struct {
 	UsedAt time.Time `json:"used_at,omitempty"`
 }
 
+// BackupCodes reports the state of the backup codes of an identity, the
+// codes themselves are never exposed.
+type BackupCodes struct {
+	Configured bool             `json:"configured"`
+	Remaining  int              `json:"remaining"`
+	Used       []UsedBackupCode `json:"used"`
+	Threshold  int              `json:"threshold"`
+}
+
+type UsedBackupCode struct {
+	UsedAt time.Time `json:"used_at"`
+}
+
+// Regenerate returns true when the user is running out of backup codes.
+func (b *BackupCodes) Regenerate() bool {
+	return b.Configured && b.Remaining <= b.Threshold
+}
+
+// PasskeyLoginOptions holds the WebAuthn assertion options of a login flow,
+// PublicKey can be passed as is to navigator.credentials.get together with
+// Mediation to offer the passkeys of the user in the browser autofill.
+type PasskeyLoginOptions struct {
+	FlowID    string          `json:"flow_id"`
+	CsrfToken string          `json:"csrf_token"`
+	Mediation string          `json:"mediation"`
+	PublicKey json.RawMessage `json:"publicKey"`
+}
+
 type RegistrationFlowResponse struct {
 	completedFlow  *kClient.SuccessfulNativeRegistration
 	inProgressFlow *kClient.RegistrationFlow
@@ -172,7 +225,7 @@ func (s *Service) CheckSession(ctx context.Context, cookies []*http.Cookie) (*kC
 	return session, resp.Cookies(), nil
 }
 
-func (s *Service) AcceptLoginRequest(ctx context.Context, session *kClient.Session, lc string, tenantID string) (*BrowserLocationChangeRequired, []*http.Cookie, error) {
+func (s *Service) AcceptLoginRequest(ctx context.Context, session *kClient.Session, lc string, tenantID string, acr string) (*BrowserLocationChangeRequired, []*http.Cookie, error) {
 	ctx, span := s.tracer.Start(ctx, "kratos.Service.AcceptLoginRequest")
 	defer span.End()
 
@@ -185,20 +238,20 @@ func (s *Service) AcceptLoginRequest(ctx context.Context, session *kClient.Sessi
 
 	accept := hClient.NewAcceptOAuth2LoginRequest(session.Identity.Id)
 	accept.SetRemember(true)
-	accept.Amr = []string{}
+	accept.Amr = s.amr(session)
 
 	if tenantID != "" && tenantID != cookies.NoTenantAvailable {
 		accept.SetContext(map[string]interface{}{"tenant_id": tenantID})
 	}
 
-	for _, r := range session.AuthenticationMethods {
-		method := r.GetMethod()
+	// report the requested ACR value the session achieved, or fall back to
+	// the session AAL, so it ends up in the ID token
+	if acr == "" {
+		acr = string(session.GetAuthenticatorAssuranceLevel())
+	}
 
-		accept.Amr = append(accept.Amr, method)
-		// ensure we add pop for webauthn method
-		if s.oidcWebAuthnSequencingEnabled && method == "webauthn" {
-			accept.Amr = append(accept.Amr, AmrPopValue)
-		}
+	if acr != "" {
+		accept.SetAcr(acr)
 	}
 
 	accept.IdentityProviderSessionId = &session.Id
@@ -229,6 +282,31 @@ func (s *Service) AcceptLoginRequest(ctx context.Context, session *kClient.Sessi
 	return &BrowserLocationChangeRequired{RedirectTo: &redirectTo.RedirectTo}, resp.Cookies(), nil
 }
 
+// amr returns the RFC 8176 values for the methods used in the session,
+// methods without an equivalent are left out.
+func (s *Service) amr(session *kClient.Session) []string {
+	amr := []string{}
+
+	for _, r := range session.AuthenticationMethods {
+		method := r.GetMethod()
+
+		if value, ok := amrValues[method]; ok && !slices.Contains(amr, value) {
+			amr = append(amr, value)
+		}
+
+		// ensure we add pop for webauthn method
+		if s.oidcWebAuthnSequencingEnabled && method == "webauthn" && !slices.Contains(amr, AmrPopValue) {
+			amr = append(amr, AmrPopValue)
+		}
+	}
+
+	if session.GetAuthenticatorAssuranceLevel() >= kClient.AUTHENTICATORASSURANCELEVEL_AAL2 {
+		amr = append(amr, AmrMfaValue)
+	}
+
+	return amr
+}
+
 func (s *Service) GetLoginRequest(ctx context.Context, loginChallenge string) (*hClient.OAuth2LoginRequest, []*http.Cookie, error) {
 	ctx, span := s.tracer.Start(ctx, "kratos.Service.GetLoginRequest")
 	defer span.End()
@@ -252,6 +330,29 @@ func (s *Service) GetLoginRequest(ctx context.Context, loginChallenge string) (*
 	return redirectTo, resp.Cookies(), nil
 }
 
+// GetOAuth2Client returns the Hydra OAuth2 client with the given ID.
+func (s *Service) GetOAuth2Client(ctx context.Context, clientID string) (*hClient.OAuth2Client, error) {
+	ctx, span := s.tracer.Start(ctx, "kratos.Service.GetOAuth2Client")
+	defer span.End()
+
+	client, resp, err := s.hydra.OAuth2API().
+		GetOAuth2Client(ctx, clientID).
+		Execute()
+
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return client, nil
+}
+
 func (s *Service) MustReAuthenticate(ctx context.Context, hydraLoginChallenge string, session *kClient.Session, c cookies.FlowStateCookie) (bool, error) {
 	ctx, span := s.tracer.Start(ctx, "kratos.Service.MustReAuthenticate")
 	defer span.End()
@@ -1125,33 +1226,145 @@ func (s *Service) GetFlowError(ctx context.Context, id string) (*kClient.FlowErr
 	return flowError, resp.Cookies(), nil
 }
 
-func (s *Service) CheckAllowedProvider(ctx context.Context, loginFlow *kClient.LoginFlow, updateFlowBody *kClient.UpdateLoginFlowBody) (bool, error) {
+func (s *Service) CheckAllowedProvider(ctx context.Context, loginFlow *kClient.LoginFlow, updateFlowBody *kClient.UpdateLoginFlowBody, tenantID string) (bool, error) {
 	ctx, span := s.tracer.Start(ctx, "kratos.Service.CheckAllowedProvider")
 	defer span.End()
 
-	provider := s.getProviderName(updateFlowBody)
+	provider := s.getProviderName(updateFlowBody, string(loginFlow.GetRequestedAal()))
+	// second factors and the identifier step are not restricted
+	if provider == "" {
+		span.SetStatus(codes.Ok, "")
+		return true, nil
+	}
+
 	clientName := s.getClientName(loginFlow)
 
-	allowedProviders, err := s.authz.ListObjects(ctx, fmt.Sprintf("app:%s", clientName), "allowed_access", "provider")
+	policy, err := s.providerPolicy(ctx, clientName, tenantID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return false, err
 	}
-	// If the user has not configured providers for this app, we allow all providers
-	if len(allowedProviders) == 0 {
-		span.SetStatus(codes.Ok, "")
-		return true, nil
+
+	span.SetStatus(codes.Ok, "")
+	return policy.allows(provider), nil
+}
+
+// CheckAllowedRegistration reports whether the app and the tenant of a
+// registration flow may register users, with the method of updateFlowBody
+// when not nil
+func (s *Service) CheckAllowedRegistration(ctx context.Context, flow *kClient.RegistrationFlow, updateFlowBody *kClient.UpdateRegistrationFlowBody, tenantID string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "kratos.Service.CheckAllowedRegistration")
+	defer span.End()
+
+	policy, err := s.providerPolicy(ctx, s.getRegistrationClientName(flow), tenantID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
 	}
+
+	allowed := policy.allowsRegistration()
+	if provider := s.getRegistrationProviderName(updateFlowBody); allowed && provider != "" {
+		allowed = policy.allows(provider)
+	}
+
 	span.SetStatus(codes.Ok, "")
-	return s.contains(allowedProviders, fmt.Sprintf("%v", provider)), nil
+	return allowed, nil
 }
 
-func (s *Service) getProviderName(updateFlowBody *kClient.UpdateLoginFlowBody) string {
-	if updateFlowBody.GetActualInstance() == updateFlowBody.UpdateLoginFlowWithOidcMethod {
+// providerPolicy returns the providers and first-party methods the app and the
+// tenant of a flow may use
+func (s *Service) providerPolicy(ctx context.Context, clientName, tenantID string) (*providerPolicy, error) {
+	allowed, restricted, err := s.allowedProviders(ctx, clientName, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := s.authz.ListObjects(ctx, fmt.Sprintf("app:%s", clientName), "denied_access", "provider")
+	if err != nil {
+		return nil, err
+	}
+
+	if tenantID != "" && tenantID != cookies.NoTenantAvailable {
+		tenantDenied, err := s.authz.ListObjects(ctx, fmt.Sprintf("tenant:%s", tenantID), "denied_access", "provider")
+		if err != nil {
+			return nil, err
+		}
+
+		denied = append(denied, tenantDenied...)
+	}
+
+	return &providerPolicy{allowed: allowed, restricted: restricted, denied: denied}, nil
+}
+
+// allowedProviders returns the providers allowed for the app and the tenant
+// of a login, the intersection of both lists when both are configured. An
+// app or tenant without allowed providers does not restrict the list,
+// restricted is false when neither does.
+func (s *Service) allowedProviders(ctx context.Context, clientName, tenantID string) ([]string, bool, error) {
+	appProviders, err := s.authz.ListObjects(ctx, fmt.Sprintf("app:%s", clientName), "allowed_access", "provider")
+	if err != nil {
+		return nil, false, err
+	}
+
+	if tenantID == "" || tenantID == cookies.NoTenantAvailable {
+		return appProviders, len(appProviders) > 0, nil
+	}
+
+	tenantProviders, err := s.authz.ListObjects(ctx, fmt.Sprintf("tenant:%s", tenantID), "allowed_access", "provider")
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case len(appProviders) == 0:
+		return tenantProviders, len(tenantProviders) > 0, nil
+	case len(tenantProviders) == 0:
+		return appProviders, true, nil
+	}
+
+	providers := make([]string, 0, len(appProviders))
+	for _, provider := range appProviders {
+		if s.contains(tenantProviders, provider) {
+			providers = append(providers, provider)
+		}
+	}
+	return providers, true, nil
+}
+
+// getProviderName returns the provider restricting a login method, the
+// upstream provider of oidc and saml or the name of a first-party method used
+// as a first factor of a flow requesting aal, empty for methods that are not
+// restricted
+func (s *Service) getProviderName(updateFlowBody *kClient.UpdateLoginFlowBody, aal string) string {
+	switch updateFlowBody.GetActualInstance() {
+	case nil:
+		return ""
+	case updateFlowBody.UpdateLoginFlowWithOidcMethod:
 		return updateFlowBody.UpdateLoginFlowWithOidcMethod.Provider
+	case updateFlowBody.UpdateLoginFlowWithSamlMethod:
+		return updateFlowBody.UpdateLoginFlowWithSamlMethod.Provider
 	}
-	return ""
+
+	return restrictedMethod(flowMethod(updateFlowBody.GetActualInstance()), aal)
+}
+
+func (s *Service) getRegistrationProviderName(updateFlowBody *kClient.UpdateRegistrationFlowBody) string {
+	if updateFlowBody == nil {
+		return ""
+	}
+
+	switch updateFlowBody.GetActualInstance() {
+	case nil:
+		return ""
+	case updateFlowBody.UpdateRegistrationFlowWithOidcMethod:
+		return updateFlowBody.UpdateRegistrationFlowWithOidcMethod.Provider
+	case updateFlowBody.UpdateRegistrationFlowWithSamlMethod:
+		return updateFlowBody.UpdateRegistrationFlowWithSamlMethod.Provider
+	}
+
+	return restrictedMethod(flowMethod(updateFlowBody.GetActualInstance()), "")
 }
 
 func (s *Service) getClientName(loginFlow *kClient.LoginFlow) string {
@@ -1163,39 +1376,189 @@ func (s *Service) getClientName(loginFlow *kClient.LoginFlow) string {
 	return ""
 }
 
-func (s *Service) FilterFlowProviderList(ctx context.Context, flow *kClient.LoginFlow) (*kClient.LoginFlow, error) {
+func (s *Service) getRegistrationClientName(flow *kClient.RegistrationFlow) string {
+	if flow.Oauth2LoginRequest != nil {
+		return flow.Oauth2LoginRequest.Client.GetClientName()
+	}
+	return ""
+}
+
+func (s *Service) FilterFlowProviderList(ctx context.Context, flow *kClient.LoginFlow, tenantID string) (*kClient.LoginFlow, error) {
 	ctx, span := s.tracer.Start(ctx, "kratos.Service.FilterFlowProviderList")
 	defer span.End()
 
 	clientName := s.getClientName(flow)
 
-	allowedProviders, err := s.authz.ListObjects(ctx, fmt.Sprintf("app:%s", clientName), "allowed_access", "provider")
+	policy, err := s.providerPolicy(ctx, clientName, tenantID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
-	// If the user has not configured providers for this app, we allow all providers
-	if len(allowedProviders) == 0 {
+	// If the user has not configured providers for this app or tenant, we allow all providers
+	if policy.unrestricted() {
+		span.SetStatus(codes.Ok, "")
+		return flow, nil
+	}
+
+	flow.Ui.Nodes = policy.filterNodes(flow.Ui.Nodes, string(flow.GetRequestedAal()))
+	span.SetStatus(codes.Ok, "")
+	return flow, nil
+}
+
+// FilterRegistrationFlowProviderList removes the providers and methods the
+// app and the tenant may not register with, ErrRegistrationNotAllowed is
+// returned when they may not register users at all
+func (s *Service) FilterRegistrationFlowProviderList(ctx context.Context, flow *kClient.RegistrationFlow, tenantID string) (*kClient.RegistrationFlow, error) {
+	ctx, span := s.tracer.Start(ctx, "kratos.Service.FilterRegistrationFlowProviderList")
+	defer span.End()
+
+	policy, err := s.providerPolicy(ctx, s.getRegistrationClientName(flow), tenantID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if !policy.allowsRegistration() {
+		span.SetStatus(codes.Ok, "")
+		return nil, ErrRegistrationNotAllowed
+	}
+
+	if policy.unrestricted() {
 		span.SetStatus(codes.Ok, "")
 		return flow, nil
 	}
 
-	// Filter UI nodes
-	var nodes []kClient.UiNode
-	for _, node := range flow.Ui.Nodes {
-		switch node.Group {
-		case "oidc":
-			if s.contains(allowedProviders, fmt.Sprintf("%v", node.Attributes.UiNodeInputAttributes.GetValue())) {
-				nodes = append(nodes, node)
+	flow.Ui.Nodes = policy.filterNodes(flow.Ui.Nodes, "")
+	span.SetStatus(codes.Ok, "")
+	return flow, nil
+}
+
+// providerPolicy holds the upstream providers and first-party methods an app
+// and a tenant may use. First-party methods are restricted as providers named
+// after the method, registration as the provider named registration.
+type providerPolicy struct {
+	allowed    []string
+	restricted bool
+	denied     []string
+}
+
+// allows reports whether a provider is usable, it must not be denied and must
+// be allowed when the allowed providers are restricted
+func (p *providerPolicy) allows(provider string) bool {
+	if slices.Contains(p.denied, provider) {
+		return false
+	}
+
+	return !p.restricted || slices.Contains(p.allowed, provider)
+}
+
+// allowsRegistration reports whether users may register, registration is
+// only restricted by denying it so that the allowed providers of existing
+// setups keep registration enabled
+func (p *providerPolicy) allowsRegistration() bool {
+	return !slices.Contains(p.denied, RegistrationProvider)
+}
+
+func (p *providerPolicy) unrestricted() bool {
+	return !p.restricted && len(p.denied) == 0
+}
+
+// filterNodes keeps the nodes of allowed providers and methods of a flow
+// requesting aal, the nodes of other groups such as default or profile are
+// kept
+func (p *providerPolicy) filterNodes(nodes []kClient.UiNode, aal string) []kClient.UiNode {
+	var ret []kClient.UiNode
+	for _, node := range nodes {
+		switch {
+		case node.Group == "oidc" || node.Group == "saml":
+			if p.allows(fmt.Sprintf("%v", node.Attributes.UiNodeInputAttributes.GetValue())) {
+				ret = append(ret, node)
+			}
+		case restrictedMethod(node.Group, aal) != "":
+			if p.allows(node.Group) {
+				ret = append(ret, node)
 			}
+		default:
+			ret = append(ret, node)
 		}
 	}
 
-	flow.Ui.Nodes = nodes
+	return ret
+}
+
+// restrictedMethod returns the method if it is a first-party method
+// restricted by the authorization model, empty otherwise. Second factors of
+// an aal2 flow, such as webauthn after oidc, are not restricted.
+func restrictedMethod(method, aal string) string {
+	if !slices.Contains(firstPartyMethods, method) {
+		return ""
+	}
+
+	if aal == string(kClient.AUTHENTICATORASSURANCELEVEL_AAL2) && !is1FAMethod(method, aal) {
+		return ""
+	}
+
+	return method
+}
+
+// GetPasskeyLoginOptions returns the assertion options Kratos generated for a
+// discoverable credential login, nil if the flow does not offer passkeys.
+func (s *Service) GetPasskeyLoginOptions(ctx context.Context, flow *kClient.LoginFlow) (*PasskeyLoginOptions, error) {
+	_, span := s.tracer.Start(ctx, "kratos.Service.GetPasskeyLoginOptions")
+	defer span.End()
+
+	options := &PasskeyLoginOptions{FlowID: flow.GetId(), Mediation: "conditional"}
+	challenge := ""
+	for _, node := range flow.Ui.GetNodes() {
+		attributes := node.Attributes.UiNodeInputAttributes
+		if attributes == nil {
+			continue
+		}
+
+		switch attributes.Name {
+		case "csrf_token":
+			options.CsrfToken = fmt.Sprintf("%v", attributes.GetValue())
+		case "passkey_challenge":
+			challenge = fmt.Sprintf("%v", attributes.GetValue())
+		}
+	}
+
+	if challenge == "" {
+		s.logger.Debugf("Login flow %s has no passkey challenge", flow.GetId())
+		span.SetStatus(codes.Ok, "")
+		return nil, nil
+	}
+
+	// Kratos stores the whole credential assertion, unwrap the publicKey options
+	var assertion map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(challenge), &assertion); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to parse passkey challenge: %v", err)
+	}
+
+	publicKey, ok := assertion["publicKey"]
+	if !ok {
+		publicKey = json.RawMessage(challenge)
+	}
+
+	// Discoverable credentials are picked by the authenticator, an allow list
+	// would prevent the browser from offering them in the autofill
+	var publicKeyOptions map[string]json.RawMessage
+	if err := json.Unmarshal(publicKey, &publicKeyOptions); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to parse passkey challenge: %v", err)
+	}
+	delete(publicKeyOptions, "allowCredentials")
+
+	options.PublicKey, _ = json.Marshal(publicKeyOptions)
+
 	span.SetStatus(codes.Ok, "")
-	return flow, nil
+	return options, nil
 }
 
 func (s *Service) ParseIdentifierFirstLoginFlowMethodBody(r *http.Request) (*kClient.UpdateLoginFlowWithIdentifierFirstMethod, []*http.Cookie, error) {
@@ -1225,8 +1588,12 @@ func (s *Service) ParseLoginFlowMethodBody(r *http.Request, requestedAAL string)
 
 	var methodPayload methodOnly
 	if err := json.Unmarshal(bodyBytes, &methodPayload); err != nil {
-		// fallback where method might be missing
+		// fallback where method might be missing, discoverable credential
+		// assertions are posted without identifier through passkey_login
 		methodPayload.Method = "webauthn"
+		if form, err := url.ParseQuery(string(bodyBytes)); err == nil && form.Has("passkey_login") {
+			methodPayload.Method = "passkey"
+		}
 	}
 
 	var ret kClient.UpdateLoginFlowBody
@@ -1268,6 +1635,24 @@ func (s *Service) ParseLoginFlowMethodBody(r *http.Request, requestedAAL string)
 		}
 		ret = kClient.UpdateLoginFlowWithWebAuthnMethodAsUpdateLoginFlowBody(&body)
 
+	case "passkey":
+		var body kClient.UpdateLoginFlowWithPasskeyMethod
+		if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+			if err := r.ParseForm(); err != nil {
+				return nil, cookies, err
+			}
+			csrf := r.Form.Get("csrf_token")
+			login := r.Form.Get("passkey_login")
+			body.CsrfToken = &csrf
+			body.PasskeyLogin = &login
+		} else {
+			if err := parseBody(r.Body, &body); err != nil {
+				return nil, cookies, err
+			}
+		}
+		body.SetMethod("passkey")
+		ret = kClient.UpdateLoginFlowWithPasskeyMethodAsUpdateLoginFlowBody(&body)
+
 	case "lookup_secret":
 		var body kClient.UpdateLoginFlowWithLookupSecretMethod
 		if err := parseBody(r.Body, &body); err != nil {
@@ -1287,7 +1672,7 @@ func (s *Service) ParseLoginFlowMethodBody(r *http.Request, requestedAAL string)
 	// Remove session cookie if this is a 1FA method.
 	// When webauthn is used as a 2FA method (requestedAAL == "aal2"), the session
 	// cookie MUST be preserved so Kratos can upgrade the AAL level.
-	if s.is1FAMethod(methodPayload.Method, requestedAAL) {
+	if is1FAMethod(methodPayload.Method, requestedAAL) {
 		cookies = httpHelpers.FilterCookies(cookies, KRATOS_SESSION_COOKIE_NAME)
 	}
 
@@ -1507,57 +1892,63 @@ func (s *Service) HasWebAuthnAvailable(ctx context.Context, id string) (bool, er
 	return false, nil
 }
 
-func (s *Service) HasNotEnoughLookupSecretsLeft(ctx context.Context, id string) (bool, error) {
-	ctx, span := s.tracer.Start(ctx, "kratos.Service.HasNotEnoughLookupSecretsLeft")
+func (s *Service) GetBackupCodes(ctx context.Context, id string) (*BackupCodes, error) {
+	ctx, span := s.tracer.Start(ctx, "kratos.Service.GetBackupCodes")
 	defer span.End()
 
+	backupCodes := &BackupCodes{Used: []UsedBackupCode{}, Threshold: s.backupCodesThreshold}
+
 	identity, _, err := s.kratosAdmin.IdentityApi().
 		GetIdentity(ctx, id).
 		IncludeCredential([]string{"lookup_secret"}).
 		Execute()
 
 	if err != nil {
-		return false, err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
 	lookupSecret, ok := identity.GetCredentials()["lookup_secret"]
 	if !ok {
 		s.logger.Debugf("User has no lookup secret credentials")
-		return false, nil
+		return backupCodes, nil
 	}
 
 	lookupCredentials, ok := lookupSecret.Config["recovery_codes"]
 	if !ok {
 		s.logger.Debugf("Recovery codes unavailable")
-		return false, nil
+		return backupCodes, nil
 	}
 
 	jsonbody, err := json.Marshal(lookupCredentials)
 	if err != nil {
 		s.logger.Errorf("Marshalling to json failed: %s", err)
-		return false, err
+		return nil, err
 	}
 
 	lookupSecrets := new(LookupSecrets)
 	if err := json.Unmarshal(jsonbody, &lookupSecrets); err != nil {
 		s.logger.Errorf("Unmarshalling failed: %s", err)
-		return false, err
+		return nil, err
 	}
 
-	unusedCodes := 0
+	backupCodes.Configured = true
 	for _, code := range *lookupSecrets {
 		if code.UsedAt.IsZero() {
-			unusedCodes += 1
+			backupCodes.Remaining += 1
+			continue
 		}
-	}
 
-	if unusedCodes > MinimumBackupCodesAmount {
-		return false, nil
+		backupCodes.Used = append(backupCodes.Used, UsedBackupCode{UsedAt: code.UsedAt})
 	}
 
-	s.logger.Debugf("Only %d backup codes are left, redirect the user to generate a new set", unusedCodes)
+	if backupCodes.Regenerate() {
+		s.logger.Debugf("Only %d backup codes are left, redirect the user to generate a new set", backupCodes.Remaining)
+	}
 
-	return true, nil
+	span.SetStatus(codes.Ok, "")
+	return backupCodes, nil
 }
 
 func (s *Service) RequireVerificationForEmail(ctx context.Context, session *kClient.Session) (bool, string, error) {
@@ -1611,9 +2002,9 @@ func (s *Service) RequireVerificationForEmail(ctx context.Context, session *kCli
 	return false, "", nil
 }
 
-func (s *Service) is1FAMethod(method, aal string) bool {
+func is1FAMethod(method, aal string) bool {
 	switch method {
-	case "password", "oidc":
+	case "password", "oidc", "passkey":
 		return true
 	case "webauthn":
 		// webauthn is a 2FA method when the flow requests aal2; in that case the
@@ -1689,7 +2080,7 @@ func (s *Service) parseKratosRedirectResponse(ctx context.Context, resp *http.Re
 	}, nil
 }
 
-func NewService(kratos KratosClientInterface, kratosAdmin KratosAdminClientInterface, hydra HydraClientInterface, authzClient AuthorizerInterface, oidcWebAuthnSequencingEnabled, multiTenancyEnabled bool, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+func NewService(kratos KratosClientInterface, kratosAdmin KratosAdminClientInterface, hydra HydraClientInterface, authzClient AuthorizerInterface, oidcWebAuthnSequencingEnabled, multiTenancyEnabled bool, backupCodesThreshold int, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
 	s := new(Service)
 
 	s.kratos = kratos
@@ -1699,6 +2090,7 @@ func NewService(kratos KratosClientInterface, kratosAdmin KratosAdminClientInter
 
 	s.oidcWebAuthnSequencingEnabled = oidcWebAuthnSequencingEnabled
 	s.multiTenancyEnabled = multiTenancyEnabled
+	s.backupCodesThreshold = backupCodesThreshold
 
 	s.monitor = monitor
 	s.tracer = tracer
@@ -12,7 +12,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -23,7 +25,9 @@ import (
 	gomock "go.uber.org/mock/gomock"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+	"github.com/canonical/identity-platform-login-ui/internal/hydra"
 	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/ory/mocks"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package kratos -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
@@ -74,7 +78,7 @@ func TestCheckSessionSuccess(t *testing.T) {
 		},
 	)
 
-	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CheckSession(ctx, cookies)
+	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckSession(ctx, cookies)
 
 	if s != session {
 		t.Fatalf("expected session to be %v not  %v", session, s)
@@ -121,7 +125,7 @@ func TestCheckSessionFails(t *testing.T) {
 		},
 	)
 
-	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CheckSession(ctx, cookies)
+	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckSession(ctx, cookies)
 
 	if s != nil {
 		t.Fatalf("expected session to be nil not  %v", s)
@@ -185,7 +189,7 @@ func TestAcceptLoginRequestSuccess(t *testing.T) {
 		},
 	)
 
-	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "")
+	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "", "")
 
 	if *rt != redirectResp {
 		t.Fatalf("expected redirect to be %v not  %v", redirectResp, *rt)
@@ -198,6 +202,47 @@ func TestAcceptLoginRequestSuccess(t *testing.T) {
 	}
 }
 
+func TestAcceptLoginRequestWithACR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockHydraOauthApi := NewMockOAuth2API(ctrl)
+
+	ctx := context.Background()
+	loginChallenge := "123456"
+	redirectTo := hClient.NewOAuth2RedirectTo("http://redirect/to/path")
+	acceptLoginRequest := hClient.OAuth2APIAcceptOAuth2LoginRequestRequest{
+		ApiService: mockHydraOauthApi,
+	}
+	session := kClient.NewSession("test")
+	session.Identity = kClient.NewIdentity("id", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockHydra.EXPECT().OAuth2API().Times(1).Return(mockHydraOauthApi)
+	mockHydraOauthApi.EXPECT().AcceptOAuth2LoginRequest(ctx).Times(1).Return(acceptLoginRequest)
+	mockHydraOauthApi.EXPECT().AcceptOAuth2LoginRequestExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r hClient.OAuth2APIAcceptOAuth2LoginRequestRequest) (*hClient.OAuth2RedirectTo, *http.Response, error) {
+			if accept := (*hClient.AcceptOAuth2LoginRequest)(reflect.ValueOf(r).FieldByName("acceptOAuth2LoginRequest").UnsafePointer()); accept.GetAcr() != "phr" {
+				t.Fatalf("expected acr to be phr, got %s", accept.GetAcr())
+			}
+			return redirectTo, new(http.Response), nil
+		},
+	)
+
+	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "", "phr")
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
 func TestAcceptLoginRequestFails(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -224,7 +269,7 @@ func TestAcceptLoginRequestFails(t *testing.T) {
 	mockHydraOauthApi.EXPECT().AcceptOAuth2LoginRequest(ctx).Times(1).Return(acceptLoginRequest)
 	mockHydraOauthApi.EXPECT().AcceptOAuth2LoginRequestExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("error"))
 
-	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "")
+	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "", "")
 
 	if rt != nil {
 		t.Fatalf("expected redirect to be %v not  %v", nil, rt)
@@ -253,7 +298,7 @@ func TestAcceptLoginRequestFailsWithoutSession(t *testing.T) {
 
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
-	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, nil, "challenge", "")
+	rt, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, nil, "challenge", "", "")
 
 	if rt != nil {
 		t.Fatalf("expected redirect to be %v not %v", nil, rt)
@@ -277,49 +322,49 @@ func TestAcceptLoginRequestWithPopForWebAuthn(t *testing.T) {
 			name:                          "TOTP with flag enabled should not include pop",
 			authMethods:                   []string{"totp"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"totp": true},
+			expectedAmr:                   map[string]bool{"otp": true},
 		},
 		{
 			name:                          "WebAuthn with flag enabled should include pop",
 			authMethods:                   []string{"webauthn"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"webauthn": true, "pop": true},
+			expectedAmr:                   map[string]bool{"hwk": true, "pop": true},
 		},
 		{
 			name:                          "TOTP and WebAuthn with flag enabled should include pop",
 			authMethods:                   []string{"totp", "webauthn"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"totp": true, "webauthn": true, "pop": true},
+			expectedAmr:                   map[string]bool{"otp": true, "hwk": true, "pop": true},
 		},
 		{
 			name:                          "TOTP with flag disabled should not include pop",
 			authMethods:                   []string{"totp"},
 			oidcWebAuthnSequencingEnabled: false,
-			expectedAmr:                   map[string]bool{"totp": true},
+			expectedAmr:                   map[string]bool{"otp": true},
 		},
 		{
 			name:                          "WebAuthn with flag disabled should not include pop",
 			authMethods:                   []string{"webauthn"},
 			oidcWebAuthnSequencingEnabled: false,
-			expectedAmr:                   map[string]bool{"webauthn": true},
+			expectedAmr:                   map[string]bool{"hwk": true},
 		},
 		{
 			name:                          "Password should not include pop even with flag enabled",
 			authMethods:                   []string{"password"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"password": true},
+			expectedAmr:                   map[string]bool{"pwd": true},
 		},
 		{
 			name:                          "OIDC should not include pop even with flag enabled",
 			authMethods:                   []string{"oidc"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"oidc": true},
+			expectedAmr:                   map[string]bool{"fed": true},
 		},
 		{
 			name:                          "Multiple methods with TOTP should not include pop",
 			authMethods:                   []string{"password", "totp"},
 			oidcWebAuthnSequencingEnabled: true,
-			expectedAmr:                   map[string]bool{"password": true, "totp": true},
+			expectedAmr:                   map[string]bool{"pwd": true, "otp": true},
 		},
 	}
 
@@ -384,11 +429,120 @@ func TestAcceptLoginRequestWithPopForWebAuthn(t *testing.T) {
 				},
 			)
 
-			_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, tt.oidcWebAuthnSequencingEnabled, false, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "")
+			_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, tt.oidcWebAuthnSequencingEnabled, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, loginChallenge, "", "")
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+		})
+	}
+}
+
+func TestAcceptLoginRequestAmrAndAcr(t *testing.T) {
+	tests := []struct {
+		name        string
+		authMethods []string
+		aal         kClient.AuthenticatorAssuranceLevel
+		acr         string
+		expectedAmr []string
+		expectedAcr string
+	}{
+		{
+			name:        "password",
+			authMethods: []string{"password"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL1,
+			expectedAmr: []string{"pwd"},
+			expectedAcr: "aal1",
+		},
+		{
+			name:        "password and totp",
+			authMethods: []string{"password", "totp"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedAmr: []string{"pwd", "otp", "mfa"},
+			expectedAcr: "aal2",
+		},
+		{
+			name:        "password and backup code",
+			authMethods: []string{"password", "lookup_secret"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedAmr: []string{"pwd", "otp", "mfa"},
+			expectedAcr: "aal2",
+		},
+		{
+			name:        "oidc and webauthn",
+			authMethods: []string{"oidc", "webauthn"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedAmr: []string{"fed", "hwk", "mfa"},
+			expectedAcr: "aal2",
+		},
+		{
+			name:        "passkey",
+			authMethods: []string{"passkey"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL1,
+			expectedAmr: []string{"hwk"},
+			expectedAcr: "aal1",
+		},
+		{
+			name:        "unknown methods are left out",
+			authMethods: []string{"password", "v0.6_legacy_session"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL1,
+			expectedAmr: []string{"pwd"},
+			expectedAcr: "aal1",
+		},
+		{
+			name:        "requested acr takes precedence over the aal",
+			authMethods: []string{"password", "webauthn"},
+			aal:         kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			acr:         "phr",
+			expectedAmr: []string{"pwd", "hwk", "mfa"},
+			expectedAcr: "phr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+			accepted := hClient.NewAcceptOAuth2LoginRequestWithDefaults()
+			server := mocks.NewHydraServerStubWithLoginRecorder(accepted)
+			defer server.Close()
+
+			ctx := context.Background()
+
+			session := kClient.NewSession("test")
+			session.Identity = kClient.NewIdentity(mocks.OAUTH2_SUBJECT, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+			session.SetAuthenticatorAssuranceLevel(tt.aal)
+			for _, method := range tt.authMethods {
+				m := method
+				session.AuthenticationMethods = append(session.AuthenticationMethods, kClient.SessionAuthenticationMethod{Method: &m})
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
+			rt, _, err := NewService(mockKratos, mockAdminKratos, hydra.NewClient(server.URL, false, monitoring.NewNoopMonitor("", nil), mockLogger), mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).AcceptLoginRequest(ctx, session, "challenge", "", tt.acr)
 			if err != nil {
 				t.Fatalf("expected error to be nil not %v", err)
 			}
+
+			if rt.GetRedirectTo() != mocks.AUTHORIZATION_REDIRECT {
+				t.Fatalf("expected redirect to be %s, got %s", mocks.AUTHORIZATION_REDIRECT, rt.GetRedirectTo())
+			}
+
+			if !reflect.DeepEqual(accepted.Amr, tt.expectedAmr) {
+				t.Fatalf("expected amr to be %v, got %v", tt.expectedAmr, accepted.Amr)
+			}
+
+			if accepted.GetAcr() != tt.expectedAcr {
+				t.Fatalf("expected acr to be %s, got %s", tt.expectedAcr, accepted.GetAcr())
+			}
 		})
 	}
 }
@@ -427,7 +581,7 @@ func TestGetLoginRequestSuccess(t *testing.T) {
 		},
 	)
 
-	ret, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetLoginRequest(ctx, loginChallenge)
+	ret, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetLoginRequest(ctx, loginChallenge)
 
 	if ret != lr {
 		t.Fatalf("expected response to be %v not  %v", lr, ret)
@@ -464,7 +618,7 @@ func TestGetLoginRequestFails(t *testing.T) {
 	mockHydraOauthApi.EXPECT().GetOAuth2LoginRequest(ctx).Times(1).Return(getLoginRequest)
 	mockHydraOauthApi.EXPECT().GetOAuth2LoginRequestExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("error"))
 
-	ret, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetLoginRequest(ctx, loginChallenge)
+	ret, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetLoginRequest(ctx, loginChallenge)
 
 	if ret != nil {
 		t.Fatalf("expected redirect to be %v not  %v", nil, ret)
@@ -477,6 +631,74 @@ func TestGetLoginRequestFails(t *testing.T) {
 	}
 }
 
+func TestGetOAuth2ClientSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockHydraOauthApi := NewMockOAuth2API(ctrl)
+
+	ctx := context.Background()
+	clientID := "app"
+	getClient := hClient.OAuth2APIGetOAuth2ClientRequest{
+		ApiService: mockHydraOauthApi,
+	}
+	client := hClient.NewOAuth2ClientWithDefaults()
+
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetOAuth2Client").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockHydra.EXPECT().OAuth2API().Times(1).Return(mockHydraOauthApi)
+	mockHydraOauthApi.EXPECT().GetOAuth2Client(ctx, clientID).Times(1).Return(getClient)
+	mockHydraOauthApi.EXPECT().GetOAuth2ClientExecute(gomock.Any()).Times(1).Return(client, new(http.Response), nil)
+
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetOAuth2Client(ctx, clientID)
+
+	if ret != client {
+		t.Fatalf("expected client to be %v not  %v", client, ret)
+	}
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestGetOAuth2ClientFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockHydraOauthApi := NewMockOAuth2API(ctrl)
+
+	ctx := context.Background()
+	getClient := hClient.OAuth2APIGetOAuth2ClientRequest{
+		ApiService: mockHydraOauthApi,
+	}
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockHydra.EXPECT().OAuth2API().Times(1).Return(mockHydraOauthApi)
+	mockHydraOauthApi.EXPECT().GetOAuth2Client(ctx, "app").Times(1).Return(getClient)
+	mockHydraOauthApi.EXPECT().GetOAuth2ClientExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("error"))
+
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetOAuth2Client(ctx, "app")
+
+	if ret != nil {
+		t.Fatalf("expected client to be %v not  %v", nil, ret)
+	}
+	if err == nil {
+		t.Fatalf("expected error not nil")
+	}
+}
+
 func TestMustReAuthenticateSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -519,7 +741,7 @@ func TestMustReAuthenticateSuccess(t *testing.T) {
 		},
 	)
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, loginChallenge, session, state)
 
 	if ret != false {
@@ -572,7 +794,7 @@ func TestMustReAuthenticateBackupCodeUsed(t *testing.T) {
 		},
 	)
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, loginChallenge, session, state)
 
 	if ret != false {
@@ -625,7 +847,7 @@ func TestMustReAuthenticateNoSkip(t *testing.T) {
 		},
 	)
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, loginChallenge, session, state)
 
 	if ret != true {
@@ -654,7 +876,7 @@ func TestMustReAuthenticateNoLoginChallenge(t *testing.T) {
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.MustReAuthenticate").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, "", session, cookies.FlowStateCookie{})
 
 	if ret != true {
@@ -682,7 +904,7 @@ func TestMustReAuthenticateNoSession(t *testing.T) {
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.MustReAuthenticate").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, loginChallenge, nil, cookies.FlowStateCookie{})
 
 	if ret != true {
@@ -720,7 +942,7 @@ func TestMustReAuthenticateFails(t *testing.T) {
 	mockHydraOauthApi.EXPECT().GetOAuth2LoginRequest(ctx).Times(1).Return(getLoginRequest)
 	mockHydraOauthApi.EXPECT().GetOAuth2LoginRequestExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("error"))
 
-	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).
+	ret, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).
 		MustReAuthenticate(ctx, loginChallenge, session, cookies.FlowStateCookie{})
 
 	if ret != true {
@@ -786,7 +1008,7 @@ func TestCreateBrowserLoginFlowWithLoginChallengeSuccess(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
 
 	if f != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, f)
@@ -850,7 +1072,7 @@ func TestCreateBrowserLoginFlowWithReturnToSuccess(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, "", refresh, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, "", refresh, cookies)
 
 	if f != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, f)
@@ -918,7 +1140,7 @@ func TestCreateBrowserLoginFlowWithSequencingAndLoginChallenge(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, true, false, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, true, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
 
 	if f != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, f)
@@ -958,7 +1180,7 @@ func TestCreateBrowserLoginFlowWithoutReturnToLoginChallenge(t *testing.T) {
 	mockKratos.EXPECT().FrontendApi().Times(1).Return(mockKratosFrontendApi)
 	mockKratosFrontendApi.EXPECT().CreateBrowserLoginFlow(ctx).Times(1).Return(request)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, "", "", refresh, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, "", "", refresh, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -1004,7 +1226,7 @@ func TestCreateBrowserLoginFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().CreateBrowserLoginFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().CreateBrowserLoginFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserLoginFlow(ctx, aal, returnTo, loginChallenge, refresh, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -1410,7 +1632,7 @@ func TestGetLoginFlowSuccess(t *testing.T) {
 		},
 	)
 
-	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetLoginFlow(ctx, id, cookies)
+	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetLoginFlow(ctx, id, cookies)
 
 	if s != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, s)
@@ -1453,7 +1675,7 @@ func TestGetLoginFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().GetLoginFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().GetLoginFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetLoginFlow(ctx, id, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetLoginFlow(ctx, id, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -1508,7 +1730,7 @@ func TestUpdateIdentifierFirstLoginFlowSuccess(t *testing.T) {
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.UpdateIdentifierFirstLoginFlow").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
-	r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
+	r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
 
 	if *r.RedirectTo != redirectTo {
 		t.Fatalf("expected redirect URL %s, got %s", redirectTo, *r.RedirectTo)
@@ -1548,7 +1770,7 @@ func TestUpdateIdentifierFirstLoginFlowFail(t *testing.T) {
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.UpdateIdentifierFirstLoginFlow").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 
-	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
+	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
 
 	expectedErr := "missing csrf token"
 	if err == nil || !strings.Contains(err.Error(), expectedErr) {
@@ -1593,7 +1815,7 @@ func TestUpdateIdentifierFirstLoginFlowFailStatusBadRequest(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.UpdateIdentifierFirstLoginFlow").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
+	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
 
 	if err == nil {
 		t.Fatalf("expected error, got nil")
@@ -1637,7 +1859,7 @@ func TestUpdateIdentifierFirstLoginFlowFailUnexpectedStatus(t *testing.T) {
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.UpdateIdentifierFirstLoginFlow").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
-	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
+	_, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateIdentifierFirstLoginFlow(ctx, flowId, body, cookies)
 
 	expectedErr := "unexpected status: 410"
 	if err == nil || !strings.Contains(err.Error(), expectedErr) {
@@ -1698,7 +1920,7 @@ func TestUpdateLoginFlowSuccess(t *testing.T) {
 		},
 	)
 
-	r, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
+	r, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
 
 	if *r.RedirectTo != *flow.RedirectBrowserTo {
 		t.Fatalf("expected redirectTo to be %s not %s", *flow.RedirectBrowserTo, *r.RedirectTo)
@@ -1754,7 +1976,7 @@ func TestUpdateLoginFlowErrorWebAuthnNotSet(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateLoginFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().UpdateLoginFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	_, _, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
+	_, _, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
 
 	if err == nil {
 		t.Fatalf("expected error not nil")
@@ -1808,7 +2030,7 @@ func TestUpdateLoginFlowErrorWhenBackupCodesNotSet(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateLoginFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().UpdateLoginFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	_, _, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
+	_, _, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
 
 	if err == nil {
 		t.Fatalf("expected error not nil")
@@ -1925,7 +2147,7 @@ func TestGetUiError(t *testing.T) {
 			body, _ := json.Marshal(errorResp)
 			resp := io.NopCloser(bytes.NewBuffer(body))
 
-			err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).getUiError(resp)
+			err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).getUiError(resp)
 
 			if err == nil || err.Error() != tt.expectErr {
 				t.Fatalf("expected error '%s', got %v", tt.expectErr, err)
@@ -1973,7 +2195,7 @@ func TestUpdateLoginFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateLoginFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
-	r, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
+	r, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateLoginFlow(ctx, flowId, *body, cookies)
 
 	if r != nil {
 		t.Fatalf("expected flow to be %v not %+v", nil, r)
@@ -2022,7 +2244,7 @@ func TestGetFlowErrorSuccess(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetFlowError(ctx, id)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetFlowError(ctx, id)
 
 	if !reflect.DeepEqual(f, flow) {
 		t.Fatalf("expected flow to be %+v not %+v", flow, f)
@@ -2062,7 +2284,7 @@ func TestGetFlowErrorFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().GetFlowError(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().GetFlowErrorExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetFlowError(ctx, id)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetFlowError(ctx, id)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not %+v", nil, f)
@@ -2102,9 +2324,10 @@ func TestCheckAllowedProviderAllowedSuccess(t *testing.T) {
 	flow.Oauth2LoginRequest = loginReq
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]string{provider}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return([]string{provider}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body)
+	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body, "")
 
 	if !allowed {
 		t.Fatalf("expected allowed to be true")
@@ -2141,9 +2364,10 @@ func TestCheckAllowedProviderNotAllowedSuccess(t *testing.T) {
 	flow.Oauth2LoginRequest = loginReq
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]string{"other_provider"}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return([]string{"other_provider"}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body)
+	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body, "")
 
 	if allowed {
 		t.Fatalf("expected allowed to be false")
@@ -2179,9 +2403,9 @@ func TestCheckAllowedProviderFail(t *testing.T) {
 	flow.Oauth2LoginRequest = loginReq
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(make([]string, 0), fmt.Errorf("oh no"))
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return(make([]string, 0), fmt.Errorf("oh no"))
 
-	_, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body)
+	_, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body, "")
 
 	if err == nil {
 		t.Fatalf("expected error not nil")
@@ -2190,7 +2414,7 @@ func TestCheckAllowedProviderFail(t *testing.T) {
 
 func TestGetClientNameOathkeeper(t *testing.T) {
 	loginFlow := &kClient.LoginFlow{}
-	service := NewService(nil, nil, nil, nil, false, false, nil, nil, nil)
+	service := NewService(nil, nil, nil, nil, false, false, 3, nil, nil, nil)
 
 	actualClientName := service.getClientName(loginFlow)
 
@@ -2203,7 +2427,7 @@ func TestGetClientNameOathkeeper(t *testing.T) {
 func TestGetClientNameOAuth2Request(t *testing.T) {
 	expectedClientName := "mockClientName"
 	loginFlow := &kClient.LoginFlow{Oauth2LoginRequest: &kClient.OAuth2LoginRequest{Client: &kClient.OAuth2Client{ClientName: &expectedClientName}}}
-	service := NewService(nil, nil, nil, nil, false, false, nil, nil, nil)
+	service := NewService(nil, nil, nil, nil, false, false, 3, nil, nil, nil)
 
 	actualClientName := service.getClientName(loginFlow)
 
@@ -2247,9 +2471,10 @@ func TestFilterFlowProviderListAllowAll(t *testing.T) {
 	flow.Ui = ui
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(kratosProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return(kratosProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow)
+	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "")
 
 	if !reflect.DeepEqual(f.Ui, ui) {
 		t.Fatalf("expected ui to be %v not  %v", ui, f.Ui)
@@ -2295,9 +2520,10 @@ func TestFilterFlowProviderListAllowSome(t *testing.T) {
 	flow.Ui = ui
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(allowedProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return(allowedProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow)
+	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "")
 
 	expectedUi := *kClient.NewUiContainerWithDefaults()
 	expectedUi.Nodes = []kClient.UiNode{ui.Nodes[0], ui.Nodes[3]}
@@ -2345,9 +2571,10 @@ func TestFilterFlowProviderListAllowNone(t *testing.T) {
 	flow.Ui = ui
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(allowedProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return(allowedProviders, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow)
+	f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "")
 
 	if !reflect.DeepEqual(f.Ui, ui) {
 		t.Fatalf("expected Ui to be %v not  %v", ui, f.Ui)
@@ -2392,45 +2619,85 @@ func TestFilterFlowProviderListFail(t *testing.T) {
 	flow.Ui = ui
 
 	mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, fmt.Errorf("oh no"))
+	mockAuthz.EXPECT().ListObjects(ctx, gomock.Any(), "allowed_access", "provider").Times(1).Return(nil, fmt.Errorf("oh no"))
 
-	_, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow)
+	_, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "")
 
 	if err == nil {
 		t.Fatalf("expected error to be not nil")
 	}
 }
 
-func TestParseLoginFlowOidcMethodBody(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+func TestFilterFlowProviderListWithTenant(t *testing.T) {
+	tests := []struct {
+		name              string
+		appProviders      []string
+		tenantProviders   []string
+		expectedProviders []int
+	}{
+		{name: "intersection", appProviders: []string{"1", "2", "4"}, tenantProviders: []string{"2", "3", "4"}, expectedProviders: []int{1, 3}},
+		{name: "tenant only", appProviders: []string{}, tenantProviders: []string{"3"}, expectedProviders: []int{2}},
+		{name: "app only", appProviders: []string{"1"}, tenantProviders: []string{}, expectedProviders: []int{0}},
+		{name: "disjoint", appProviders: []string{"1"}, tenantProviders: []string{"2"}, expectedProviders: []int{}},
+		{name: "unrestricted", appProviders: []string{}, tenantProviders: []string{}, expectedProviders: []int{0, 1, 2, 3}},
+	}
 
-	mockLogger := NewMockLoggerInterface(ctrl)
-	mockHydra := NewMockHydraClientInterface(ctrl)
-	mockKratos := NewMockKratosClientInterface(ctrl)
-	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
-	mockAuthz := NewMockAuthorizerInterface(ctrl)
-	mockTracer := NewMockTracingInterface(ctrl)
-	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	body := kClient.UpdateLoginFlowWithOidcMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithOidcMethodWithDefaults())
-	jsonBody, _ := body.MarshalJSON()
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockHydra := NewMockHydraClientInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
-	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+			ctx := context.Background()
 
-	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+			client_name := "foo"
+			client := kClient.NewOAuth2ClientWithDefaults()
+			client.ClientName = &client_name
+			loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+			loginReq.Client = client
+			ui := *kClient.NewUiContainerWithDefaults()
+			for _, p := range []string{"1", "2", "3", "4"} {
+				node := kClient.NewUiNodeWithDefaults()
+				attributes := kClient.NewUiNodeInputAttributesWithDefaults()
+				attributes.Value = p
+				node.Attributes = kClient.UiNodeInputAttributesAsUiNodeAttributes(attributes)
+				node.Group = "oidc"
+				ui.Nodes = append(ui.Nodes, *node)
+			}
+			flow := kClient.NewLoginFlowWithDefaults()
+			flow.Oauth2LoginRequest = loginReq
+			flow.Ui = ui
 
-	actual, _ := b.MarshalJSON()
-	expected, _ := body.MarshalJSON()
-	if !reflect.DeepEqual(actual, expected) {
-		t.Fatalf("expected flow to be %v not %v", expected, actual)
-	}
-	if err != nil {
-		t.Fatalf("expected error to be nil not  %v", err)
+			mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return(test.appProviders, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "tenant:t1", "allowed_access", "provider").Times(1).Return(test.tenantProviders, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return([]string{}, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "tenant:t1", "denied_access", "provider").Times(1).Return([]string{}, nil)
+
+			f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, true, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "t1")
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+
+			var expectedNodes []kClient.UiNode
+			for _, i := range test.expectedProviders {
+				expectedNodes = append(expectedNodes, ui.Nodes[i])
+			}
+			if !reflect.DeepEqual(f.Ui.Nodes, expectedNodes) {
+				t.Fatalf("expected nodes to be %v not  %v", expectedNodes, f.Ui.Nodes)
+			}
+		})
 	}
 }
 
-func TestParseLoginFlowPasswordMethodBody(t *testing.T) {
+func TestCheckAllowedProviderWithTenant(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -2442,29 +2709,37 @@ func TestParseLoginFlowPasswordMethodBody(t *testing.T) {
 	mockTracer := NewMockTracingInterface(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
-	flow := kClient.NewUpdateLoginFlowWithPasswordMethodWithDefaults()
-	flow.SetMethod("password")
-
-	body := kClient.UpdateLoginFlowWithPasswordMethodAsUpdateLoginFlowBody(flow)
+	ctx := context.Background()
 
-	jsonBody, _ := body.MarshalJSON()
+	oidcBody := kClient.NewUpdateLoginFlowWithOidcMethod("oidc", "google")
+	body := kClient.UpdateLoginFlowWithOidcMethodAsUpdateLoginFlowBody(oidcBody)
 
-	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+	client_name := "foo"
+	client := kClient.NewOAuth2ClientWithDefaults()
+	client.ClientName = &client_name
+	loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+	loginReq.Client = client
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.Oauth2LoginRequest = loginReq
 
-	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+	// the app allows the provider but the tenant does not
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return([]string{"google", "azure"}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, "tenant:t1", "allowed_access", "provider").Times(1).Return([]string{"azure"}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return([]string{}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, "tenant:t1", "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	actual, _ := b.MarshalJSON()
-	expected, _ := body.MarshalJSON()
+	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, true, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body, "t1")
 
-	if !reflect.DeepEqual(string(actual), string(expected)) {
-		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+	if allowed {
+		t.Fatalf("expected allowed to be false")
 	}
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
 }
 
-func TestParseLoginFlowTotpMethodBody(t *testing.T) {
+func TestCheckAllowedProviderIgnoresNoTenantAvailable(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -2476,56 +2751,426 @@ func TestParseLoginFlowTotpMethodBody(t *testing.T) {
 	mockTracer := NewMockTracingInterface(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
-	flow := kClient.NewUpdateLoginFlowWithTotpMethodWithDefaults()
-	flow.SetMethod("totp")
-
-	body := kClient.UpdateLoginFlowWithTotpMethodAsUpdateLoginFlowBody(flow)
-
-	jsonBody, _ := body.MarshalJSON()
+	ctx := context.Background()
 
-	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+	oidcBody := kClient.NewUpdateLoginFlowWithOidcMethod("oidc", "google")
+	body := kClient.UpdateLoginFlowWithOidcMethodAsUpdateLoginFlowBody(oidcBody)
+	flow := kClient.NewLoginFlowWithDefaults()
 
-	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().ListObjects(ctx, "app:", "allowed_access", "provider").Times(1).Return([]string{}, nil)
+	mockAuthz.EXPECT().ListObjects(ctx, "app:", "denied_access", "provider").Times(1).Return([]string{}, nil)
 
-	actual, _ := b.MarshalJSON()
-	expected, _ := body.MarshalJSON()
+	allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, true, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &body, cookies.NoTenantAvailable)
 
-	if !reflect.DeepEqual(string(actual), string(expected)) {
-		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+	if !allowed {
+		t.Fatalf("expected allowed to be true")
 	}
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
 }
 
-func TestParseLoginFlowLookupSecretMethodBody(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+func newPolicyTestNode(group, value string) kClient.UiNode {
+	node := kClient.NewUiNodeWithDefaults()
+	attributes := kClient.NewUiNodeInputAttributesWithDefaults()
+	attributes.Value = value
+	node.Attributes = kClient.UiNodeInputAttributesAsUiNodeAttributes(attributes)
+	node.Group = group
+	return *node
+}
 
-	mockLogger := NewMockLoggerInterface(ctrl)
-	mockHydra := NewMockHydraClientInterface(ctrl)
-	mockKratos := NewMockKratosClientInterface(ctrl)
-	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
-	mockAuthz := NewMockAuthorizerInterface(ctrl)
-	mockTracer := NewMockTracingInterface(ctrl)
-	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+func TestCheckAllowedProviderFirstPartyMethods(t *testing.T) {
+	passwordBody := kClient.UpdateLoginFlowWithPasswordMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithPasswordMethod("joe", "password", "secret"))
+	totpBody := kClient.UpdateLoginFlowWithTotpMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithTotpMethod("totp", "123456"))
+	webAuthnBody := kClient.UpdateLoginFlowWithWebAuthnMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithWebAuthnMethod("joe", "webauthn"))
 
-	flow := kClient.NewUpdateLoginFlowWithLookupSecretMethodWithDefaults()
-	flow.SetMethod("lookup_secret")
+	tests := []struct {
+		name     string
+		body     kClient.UpdateLoginFlowBody
+		aal      kClient.AuthenticatorAssuranceLevel
+		allowed  []string
+		denied   []string
+		lookup   bool
+		expected bool
+	}{
+		{name: "password not in allowed providers", body: passwordBody, allowed: []string{"github"}, lookup: true, expected: false},
+		{name: "password in allowed providers", body: passwordBody, allowed: []string{"github", "password"}, lookup: true, expected: true},
+		{name: "password denied", body: passwordBody, allowed: []string{}, denied: []string{"password"}, lookup: true, expected: false},
+		{name: "password unrestricted", body: passwordBody, allowed: []string{}, lookup: true, expected: true},
+		{name: "second factor not restricted", body: totpBody, lookup: false, expected: true},
+		{name: "webauthn first factor not in allowed providers", body: webAuthnBody, aal: kClient.AUTHENTICATORASSURANCELEVEL_AAL1, allowed: []string{"github"}, lookup: true, expected: false},
+		{name: "webauthn second factor not restricted", body: webAuthnBody, aal: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, lookup: false, expected: true},
+	}
 
-	body := kClient.UpdateLoginFlowWithLookupSecretMethodAsUpdateLoginFlowBody(flow)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	jsonBody, _ := body.MarshalJSON()
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockHydra := NewMockHydraClientInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 
-	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+			ctx := context.Background()
 
-	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+			client_name := "foo"
+			client := kClient.NewOAuth2ClientWithDefaults()
+			client.ClientName = &client_name
+			loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+			loginReq.Client = client
+			flow := kClient.NewLoginFlowWithDefaults()
+			flow.Oauth2LoginRequest = loginReq
+			if test.aal != "" {
+				flow.SetRequestedAal(test.aal)
+			}
 
-	actual, _ := b.MarshalJSON()
-	expected, _ := body.MarshalJSON()
+			mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedProvider").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			if test.lookup {
+				mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return(test.allowed, nil)
+				mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return(test.denied, nil)
+			}
 
-	if !reflect.DeepEqual(string(actual), string(expected)) {
-		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+			allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedProvider(ctx, flow, &test.body, "")
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+			if allowed != test.expected {
+				t.Fatalf("expected allowed to be %v", test.expected)
+			}
+		})
+	}
+}
+
+func TestFilterFlowProviderListFirstPartyMethods(t *testing.T) {
+	tests := []struct {
+		name          string
+		aal           kClient.AuthenticatorAssuranceLevel
+		allowed       []string
+		denied        []string
+		expectedNodes []int
+	}{
+		{name: "allowed providers", allowed: []string{"github", "password"}, denied: []string{}, expectedNodes: []int{0, 1, 3, 5}},
+		{name: "second factors of an aal2 flow", aal: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, allowed: []string{"github", "password"}, denied: []string{}, expectedNodes: []int{0, 1, 3, 4, 5}},
+		{name: "denied providers", allowed: []string{}, denied: []string{"google", "webauthn"}, expectedNodes: []int{0, 1, 3, 5}},
+		{name: "allowed and denied providers", allowed: []string{"github", "password"}, denied: []string{"password"}, expectedNodes: []int{0, 1, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockHydra := NewMockHydraClientInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+			ctx := context.Background()
+
+			client_name := "foo"
+			client := kClient.NewOAuth2ClientWithDefaults()
+			client.ClientName = &client_name
+			loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+			loginReq.Client = client
+			ui := *kClient.NewUiContainerWithDefaults()
+			ui.Nodes = []kClient.UiNode{
+				newPolicyTestNode("default", "csrf"),
+				newPolicyTestNode("oidc", "github"),
+				newPolicyTestNode("oidc", "google"),
+				newPolicyTestNode("password", "password"),
+				newPolicyTestNode("webauthn", "webauthn"),
+				newPolicyTestNode("totp", "totp"),
+			}
+			flow := kClient.NewLoginFlowWithDefaults()
+			flow.Oauth2LoginRequest = loginReq
+			flow.Ui = ui
+			if test.aal != "" {
+				flow.SetRequestedAal(test.aal)
+			}
+			nodes := slices.Clone(ui.Nodes)
+
+			mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return(test.allowed, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return(test.denied, nil)
+
+			f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterFlowProviderList(ctx, flow, "")
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+
+			var expectedNodes []kClient.UiNode
+			for _, i := range test.expectedNodes {
+				expectedNodes = append(expectedNodes, nodes[i])
+			}
+			if !reflect.DeepEqual(f.Ui.Nodes, expectedNodes) {
+				t.Fatalf("expected nodes to be %v not  %v", expectedNodes, f.Ui.Nodes)
+			}
+		})
+	}
+}
+
+func TestFilterRegistrationFlowProviderList(t *testing.T) {
+	tests := []struct {
+		name          string
+		denied        []string
+		expectedErr   error
+		expectedNodes []int
+	}{
+		{name: "unrestricted", denied: []string{}, expectedNodes: []int{0, 1, 2, 3}},
+		{name: "password denied", denied: []string{"password"}, expectedNodes: []int{0, 1, 3}},
+		{name: "registration denied", denied: []string{RegistrationProvider}, expectedErr: ErrRegistrationNotAllowed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockHydra := NewMockHydraClientInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+			ctx := context.Background()
+
+			client_name := "foo"
+			client := kClient.NewOAuth2ClientWithDefaults()
+			client.ClientName = &client_name
+			loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+			loginReq.Client = client
+			flow := kClient.NewRegistrationFlowWithDefaults()
+			flow.Oauth2LoginRequest = loginReq
+			flow.Ui.Nodes = []kClient.UiNode{
+				newPolicyTestNode("default", "csrf"),
+				newPolicyTestNode("oidc", "github"),
+				newPolicyTestNode("password", "password"),
+				newPolicyTestNode("profile", "profile"),
+			}
+			nodes := slices.Clone(flow.Ui.Nodes)
+
+			mockTracer.EXPECT().Start(ctx, "kratos.Service.FilterRegistrationFlowProviderList").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return([]string{}, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return(test.denied, nil)
+
+			f, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).FilterRegistrationFlowProviderList(ctx, flow, "")
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("expected error to be %v not  %v", test.expectedErr, err)
+			}
+			if test.expectedErr != nil {
+				return
+			}
+
+			var expectedNodes []kClient.UiNode
+			for _, i := range test.expectedNodes {
+				expectedNodes = append(expectedNodes, nodes[i])
+			}
+			if !reflect.DeepEqual(f.Ui.Nodes, expectedNodes) {
+				t.Fatalf("expected nodes to be %v not  %v", expectedNodes, f.Ui.Nodes)
+			}
+		})
+	}
+}
+
+func TestCheckAllowedRegistration(t *testing.T) {
+	oidcBody := kClient.UpdateRegistrationFlowWithOidcMethodAsUpdateRegistrationFlowBody(kClient.NewUpdateRegistrationFlowWithOidcMethod("oidc", "github"))
+	passwordBody := kClient.UpdateRegistrationFlowWithPasswordMethodAsUpdateRegistrationFlowBody(kClient.NewUpdateRegistrationFlowWithPasswordMethod("password", "secret", map[string]interface{}{}))
+
+	tests := []struct {
+		name         string
+		body         *kClient.UpdateRegistrationFlowBody
+		tenantID     string
+		allowed      []string
+		denied       []string
+		tenantDenied []string
+		expected     bool
+	}{
+		{name: "unrestricted", body: &passwordBody, allowed: []string{}, denied: []string{}, expected: true},
+		{name: "registration denied", body: nil, allowed: []string{}, denied: []string{RegistrationProvider}, expected: false},
+		{name: "method denied", body: &passwordBody, allowed: []string{}, denied: []string{"password"}, expected: false},
+		{name: "provider not allowed", body: &oidcBody, allowed: []string{"google"}, denied: []string{}, expected: false},
+		{name: "provider allowed", body: &oidcBody, allowed: []string{"github"}, denied: []string{"password"}, expected: true},
+		{name: "registration denied for the tenant", body: &passwordBody, tenantID: "acme", allowed: []string{}, denied: []string{}, tenantDenied: []string{RegistrationProvider}, expected: false},
+		{name: "method denied for the tenant", body: &oidcBody, tenantID: "acme", allowed: []string{}, denied: []string{}, tenantDenied: []string{"github"}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockHydra := NewMockHydraClientInterface(ctrl)
+			mockKratos := NewMockKratosClientInterface(ctrl)
+			mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+			ctx := context.Background()
+
+			client_name := "foo"
+			client := kClient.NewOAuth2ClientWithDefaults()
+			client.ClientName = &client_name
+			loginReq := kClient.NewOAuth2LoginRequestWithDefaults()
+			loginReq.Client = client
+			flow := kClient.NewRegistrationFlowWithDefaults()
+			flow.Oauth2LoginRequest = loginReq
+
+			mockTracer.EXPECT().Start(ctx, "kratos.Service.CheckAllowedRegistration").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "allowed_access", "provider").Times(1).Return(test.allowed, nil)
+			mockAuthz.EXPECT().ListObjects(ctx, "app:foo", "denied_access", "provider").Times(1).Return(test.denied, nil)
+			if test.tenantID != "" {
+				mockAuthz.EXPECT().ListObjects(ctx, "tenant:"+test.tenantID, "allowed_access", "provider").Times(1).Return([]string{}, nil)
+				mockAuthz.EXPECT().ListObjects(ctx, "tenant:"+test.tenantID, "denied_access", "provider").Times(1).Return(test.tenantDenied, nil)
+			}
+
+			allowed, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CheckAllowedRegistration(ctx, flow, test.body, test.tenantID)
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+			if allowed != test.expected {
+				t.Fatalf("expected allowed to be %v", test.expected)
+			}
+		})
+	}
+}
+
+func TestParseLoginFlowOidcMethodBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	body := kClient.UpdateLoginFlowWithOidcMethodAsUpdateLoginFlowBody(kClient.NewUpdateLoginFlowWithOidcMethodWithDefaults())
+	jsonBody, _ := body.MarshalJSON()
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	actual, _ := b.MarshalJSON()
+	expected, _ := body.MarshalJSON()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected flow to be %v not %v", expected, actual)
+	}
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestParseLoginFlowPasswordMethodBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	flow := kClient.NewUpdateLoginFlowWithPasswordMethodWithDefaults()
+	flow.SetMethod("password")
+
+	body := kClient.UpdateLoginFlowWithPasswordMethodAsUpdateLoginFlowBody(flow)
+
+	jsonBody, _ := body.MarshalJSON()
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	actual, _ := b.MarshalJSON()
+	expected, _ := body.MarshalJSON()
+
+	if !reflect.DeepEqual(string(actual), string(expected)) {
+		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+	}
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestParseLoginFlowTotpMethodBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	flow := kClient.NewUpdateLoginFlowWithTotpMethodWithDefaults()
+	flow.SetMethod("totp")
+
+	body := kClient.UpdateLoginFlowWithTotpMethodAsUpdateLoginFlowBody(flow)
+
+	jsonBody, _ := body.MarshalJSON()
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	actual, _ := b.MarshalJSON()
+	expected, _ := body.MarshalJSON()
+
+	if !reflect.DeepEqual(string(actual), string(expected)) {
+		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+	}
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestParseLoginFlowLookupSecretMethodBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	flow := kClient.NewUpdateLoginFlowWithLookupSecretMethodWithDefaults()
+	flow.SetMethod("lookup_secret")
+
+	body := kClient.UpdateLoginFlowWithLookupSecretMethodAsUpdateLoginFlowBody(flow)
+
+	jsonBody, _ := body.MarshalJSON()
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	actual, _ := b.MarshalJSON()
+	expected, _ := body.MarshalJSON()
+
+	if !reflect.DeepEqual(string(actual), string(expected)) {
+		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
 	}
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
@@ -2553,7 +3198,7 @@ func TestParseLoginFlowWebAuthnMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -2594,7 +3239,7 @@ func TestParseLoginFlowWebAuthnMethodBodyPreservesSessionCookieForAAL2(t *testin
 	req.AddCookie(&http.Cookie{Name: KRATOS_SESSION_COOKIE_NAME, Value: "session_token"})
 
 	// oidcWebAuthnSequencingEnabled=false (standard MFA mode), requestedAAL="aal2" (2FA step)
-	_, cookies, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal2")
+	_, cookies, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal2")
 
 	if err != nil {
 		t.Fatalf("expected error to be nil not %v", err)
@@ -2613,11 +3258,175 @@ func TestParseLoginFlowWebAuthnMethodBodyPreservesSessionCookieForAAL2(t *testin
 	}
 }
 
+func TestParseLoginFlowPasskeyMethodBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	flow := kClient.NewUpdateLoginFlowWithPasskeyMethod("passkey")
+	flow.SetPasskeyLogin("assertion")
+
+	body := kClient.UpdateLoginFlowWithPasskeyMethodAsUpdateLoginFlowBody(flow)
+	jsonBody, _ := body.MarshalJSON()
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
+	req.AddCookie(&http.Cookie{Name: KRATOS_SESSION_COOKIE_NAME, Value: "session_token"})
+
+	b, cookies, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	actual, _ := b.MarshalJSON()
+	expected, _ := body.MarshalJSON()
+
+	if !reflect.DeepEqual(string(actual), string(expected)) {
+		t.Fatalf("expected flow to be %s not %s", string(expected), string(actual))
+	}
+
+	for _, c := range cookies {
+		if c.Name == KRATOS_SESSION_COOKIE_NAME {
+			t.Fatal("expected session cookie to be removed for passkey login")
+		}
+	}
+}
+
+func TestParseLoginFlowPasskeyMethodBodyFromForm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	form := url.Values{}
+	form.Set("csrf_token", "csrf")
+	form.Set("passkey_login", "assertion")
+
+	req := httptest.NewRequest(http.MethodPost, "http://some/path", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	b, _, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+	if b.UpdateLoginFlowWithPasskeyMethod == nil {
+		t.Fatal("expected passkey method body")
+	}
+	if b.UpdateLoginFlowWithPasskeyMethod.GetPasskeyLogin() != "assertion" || b.UpdateLoginFlowWithPasskeyMethod.GetCsrfToken() != "csrf" {
+		t.Fatalf("unexpected passkey method body %+v", b.UpdateLoginFlowWithPasskeyMethod)
+	}
+	if b.UpdateLoginFlowWithPasskeyMethod.GetMethod() != "passkey" {
+		t.Fatalf("expected method to be passkey, got %s", b.UpdateLoginFlowWithPasskeyMethod.GetMethod())
+	}
+}
+
+func TestGetPasskeyLoginOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	ctx := context.Background()
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetId("flow")
+	flow.Ui.Nodes = []kClient.UiNode{
+		{
+			Group: "default",
+			Type:  "input",
+			Attributes: kClient.UiNodeInputAttributesAsUiNodeAttributes(
+				&kClient.UiNodeInputAttributes{Name: "csrf_token", Type: "hidden", Value: "csrf"},
+			),
+		},
+		{
+			Group: "passkey",
+			Type:  "input",
+			Attributes: kClient.UiNodeInputAttributesAsUiNodeAttributes(
+				&kClient.UiNodeInputAttributes{
+					Name:  "passkey_challenge",
+					Type:  "hidden",
+					Value: `{"publicKey":{"challenge":"Y2hhbGxlbmdl","rpId":"example.com","allowCredentials":[{"type":"public-key","id":"aWQ"}],"userVerification":"required"}}`,
+				},
+			),
+		},
+	}
+
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetPasskeyLoginOptions").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+
+	options, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetPasskeyLoginOptions(ctx, flow)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+	if options.FlowID != "flow" || options.CsrfToken != "csrf" || options.Mediation != "conditional" {
+		t.Fatalf("unexpected passkey login options %+v", options)
+	}
+
+	publicKey := make(map[string]interface{})
+	if err := json.Unmarshal(options.PublicKey, &publicKey); err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+	if _, ok := publicKey["allowCredentials"]; ok {
+		t.Fatal("expected allowCredentials to be removed for discoverable credentials")
+	}
+	if publicKey["challenge"] != "Y2hhbGxlbmdl" || publicKey["rpId"] != "example.com" {
+		t.Fatalf("unexpected public key options %v", publicKey)
+	}
+}
+
+func TestGetPasskeyLoginOptionsWithoutPasskeyChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	ctx := context.Background()
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetId("flow")
+
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetPasskeyLoginOptions").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
+
+	options, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetPasskeyLoginOptions(ctx, flow)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+	if options != nil {
+		t.Fatalf("expected options to be nil not %+v", options)
+	}
+}
+
 func TestGetProviderNameWhenNotOidcMethod(t *testing.T) {
 	loginFlow := &kClient.UpdateLoginFlowBody{}
-	service := NewService(nil, nil, nil, nil, false, false, nil, nil, nil)
+	service := NewService(nil, nil, nil, nil, false, false, 3, nil, nil, nil)
 
-	actualProviderName := service.getProviderName(loginFlow)
+	actualProviderName := service.getProviderName(loginFlow, "aal1")
 
 	expectedProviderName := ""
 	if expectedProviderName != actualProviderName {
@@ -2645,7 +3454,7 @@ func TestGetProviderNameOidc(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, _, _ := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
+	b, _, _ := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseLoginFlowMethodBody(req, "aal1")
 
 	actualProviderName := b.UpdateLoginFlowWithOidcMethod.Provider
 	if expectedProviderName != actualProviderName {
@@ -2674,7 +3483,7 @@ func TestParseRecoveryFlowCodeMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseRecoveryFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseRecoveryFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -2729,7 +3538,7 @@ func TestGetRecoveryFlowSuccess(t *testing.T) {
 		},
 	)
 
-	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetRecoveryFlow(ctx, id, cookies)
+	s, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetRecoveryFlow(ctx, id, cookies)
 
 	if s != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, s)
@@ -2772,7 +3581,7 @@ func TestGetRecoveryFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().GetRecoveryFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().GetRecoveryFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetRecoveryFlow(ctx, id, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetRecoveryFlow(ctx, id, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -2825,7 +3634,7 @@ func TestCreateBrowserRecoveryFlowSuccess(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserRecoveryFlow(ctx, returnTo)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserRecoveryFlow(ctx, returnTo)
 
 	if f != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, f)
@@ -2865,7 +3674,7 @@ func TestCreateBrowserRecoveryFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().CreateBrowserRecoveryFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().CreateBrowserRecoveryFlowExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("error"))
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserRecoveryFlow(ctx, returnTo)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserRecoveryFlow(ctx, returnTo)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -2930,7 +3739,7 @@ func TestUpdateRecoveryFlowSuccess(t *testing.T) {
 		},
 	)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
 
 	if *f.RedirectTo != *flow.RedirectBrowserTo {
 		t.Fatalf("expected redirectTo to be %s not %s", *flow.RedirectBrowserTo, *f.RedirectTo)
@@ -2982,7 +3791,7 @@ func TestUpdateRecoveryFlowFailOnUpdateRecoveryFlowExecute(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateRecoveryFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not %+v", nil, f)
@@ -3040,7 +3849,7 @@ func TestUpdateRecoveryFlowFailOnInvalidRecoveryCode(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateRecoveryFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().UpdateRecoveryFlowExecute(gomock.Any()).Times(1).Return(flow, &resp, nil)
 
-	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
+	f, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateRecoveryFlow(ctx, flowId, *body, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not %+v", nil, f)
@@ -3078,7 +3887,7 @@ func TestParseSettingsFlowPasswordMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -3112,7 +3921,7 @@ func TestParseSettingsFlowOidcMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -3146,7 +3955,7 @@ func TestParseSettingsFlowTotpMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -3180,7 +3989,7 @@ func TestParseSettingsFlowLookupMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -3214,7 +4023,7 @@ func TestParseSettingsFlowWebAuthnMethodBody(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
+	b, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).ParseSettingsFlowMethodBody(req)
 
 	actual, _ := b.MarshalJSON()
 	expected, _ := body.MarshalJSON()
@@ -3264,7 +4073,7 @@ func TestGetSettingsFlowSuccess(t *testing.T) {
 		},
 	)
 
-	s, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
+	s, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
 
 	if s != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, s)
@@ -3324,7 +4133,7 @@ func TestGetSettingsFlowDuplicateIdentifier(t *testing.T) {
 		},
 	)
 
-	s, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
+	s, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
 
 	if err == nil {
 		t.Fatal("expected error but got nil")
@@ -3370,7 +4179,7 @@ func TestGetSettingsFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().GetSettingsFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().GetSettingsFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
+	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetSettingsFlow(ctx, id, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -3420,7 +4229,7 @@ func TestCreateBrowserSettingsFlowSuccess(t *testing.T) {
 		},
 	)
 
-	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserSettingsFlow(ctx, returnTo, cookies)
+	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserSettingsFlow(ctx, returnTo, cookies)
 
 	if f != flow {
 		t.Fatalf("expected flow to be %v not  %v", flow, f)
@@ -3463,7 +4272,7 @@ func TestCreateBrowserSettingsFlowFail(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().CreateBrowserSettingsFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().CreateBrowserSettingsFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf(""))
 
-	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).CreateBrowserSettingsFlow(ctx, returnTo, cookies)
+	f, r, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).CreateBrowserSettingsFlow(ctx, returnTo, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not  %v", nil, f)
@@ -3526,7 +4335,7 @@ func TestUpdateSettingsFlowSuccess(t *testing.T) {
 		},
 	)
 
-	_, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
+	_, _, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
 
 	if !reflect.DeepEqual(c, resp.Cookies()) {
 		t.Fatalf("expected cookies to be %v not  %v", resp.Cookies(), c)
@@ -3573,7 +4382,7 @@ func TestUpdateSettingsFlowFailOnUpdateSettingsFlowExecute(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateSettingsFlowExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
 
-	f, r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
+	f, r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v not %+v", nil, f)
@@ -3638,7 +4447,7 @@ func TestUpdateSettingsFlowForbiddenStatus(t *testing.T) {
 	mockKratosFrontendApi.EXPECT().UpdateSettingsFlow(ctx).Times(1).Return(request)
 	mockKratosFrontendApi.EXPECT().UpdateSettingsFlowExecute(gomock.Any()).Times(1).Return(nil, resp, fmt.Errorf("forbidden"))
 
-	f, r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
+	f, r, c, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).UpdateSettingsFlow(ctx, flowId, *body, cookies)
 
 	if f != nil {
 		t.Fatalf("expected flow to be %v, not %v", nil, f)
@@ -3705,7 +4514,7 @@ func TestParseSettingsFlowProfileMethodBodySuccess(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	svc := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger)
+	svc := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger)
 	b, err := svc.ParseSettingsFlowMethodBody(req)
 
 	if err != nil {
@@ -3772,7 +4581,7 @@ func TestParseSettingsFlowProfileMethodBodySessionFail(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "http://some/path", io.NopCloser(bytes.NewBuffer(jsonBody)))
 
-	svc := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger)
+	svc := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger)
 
 	_, err = svc.ParseSettingsFlowMethodBody(req)
 
@@ -3784,7 +4593,114 @@ func TestParseSettingsFlowProfileMethodBodySessionFail(t *testing.T) {
 	}
 }
 
-func TestHasNotEnoughLookupSecretsLeftSuccess(t *testing.T) {
+func TestGetBackupCodesSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockKratosIdentityApi := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+	identityRequest := kClient.IdentityAPIGetIdentityRequest{
+		ApiService: mockKratosIdentityApi,
+	}
+	usedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	identity := kClient.Identity{
+		Id: "test",
+		Credentials: &map[string]kClient.IdentityCredentials{
+			"lookup_secret": {
+				Config: map[string]interface{}{
+					"recovery_codes": []map[string]interface{}{
+						{"code": "a"},
+						{"code": "b", "used_at": usedAt},
+						{"code": "c"},
+						{"code": "d"},
+						{"code": "e"},
+					},
+				},
+			},
+		},
+	}
+
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetBackupCodes").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockAdminKratos.EXPECT().IdentityApi().Times(1).Return(mockKratosIdentityApi)
+	mockKratosIdentityApi.EXPECT().GetIdentity(ctx, "test").Times(1).Return(identityRequest)
+	mockKratosIdentityApi.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(&identity, new(http.Response), nil)
+
+	backupCodes, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetBackupCodes(ctx, "test")
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+
+	expected := &BackupCodes{
+		Configured: true,
+		Remaining:  4,
+		Used:       []UsedBackupCode{{UsedAt: usedAt}},
+		Threshold:  3,
+	}
+	if !reflect.DeepEqual(backupCodes, expected) {
+		t.Fatalf("expected backup codes to be %+v, got %+v", expected, backupCodes)
+	}
+
+	if backupCodes.Regenerate() {
+		t.Fatal("expected no regeneration above the threshold")
+	}
+}
+
+func TestGetBackupCodesBelowThreshold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockHydra := NewMockHydraClientInterface(ctrl)
+	mockKratos := NewMockKratosClientInterface(ctrl)
+	mockAdminKratos := NewMockKratosAdminClientInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockKratosIdentityApi := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+	identityRequest := kClient.IdentityAPIGetIdentityRequest{
+		ApiService: mockKratosIdentityApi,
+	}
+	identity := kClient.Identity{
+		Id: "test",
+		Credentials: &map[string]kClient.IdentityCredentials{
+			"lookup_secret": {
+				Config: map[string]interface{}{
+					"recovery_codes": []map[string]interface{}{
+						{"code": "a"},
+						{"code": "b", "used_at": time.Now()},
+					},
+				},
+			},
+		},
+	}
+
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetBackupCodes").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockAdminKratos.EXPECT().IdentityApi().Times(1).Return(mockKratosIdentityApi)
+	mockKratosIdentityApi.EXPECT().GetIdentity(ctx, "test").Times(1).Return(identityRequest)
+	mockKratosIdentityApi.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(&identity, new(http.Response), nil)
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
+
+	backupCodes, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 1, mockTracer, mockMonitor, mockLogger).GetBackupCodes(ctx, "test")
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+
+	if backupCodes.Remaining != 1 || !backupCodes.Regenerate() {
+		t.Fatalf("expected regeneration with 1 code left, got %+v", backupCodes)
+	}
+}
+
+func TestGetBackupCodesWithoutCredentials(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -3809,7 +4725,7 @@ func TestHasNotEnoughLookupSecretsLeftSuccess(t *testing.T) {
 		Id: "test",
 	}
 
-	mockTracer.EXPECT().Start(ctx, "kratos.Service.HasNotEnoughLookupSecretsLeft").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetBackupCodes").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 	mockAdminKratos.EXPECT().IdentityApi().Times(1).Return(mockKratosIdentityApi)
 
 	mockKratosIdentityApi.EXPECT().GetIdentity(ctx, gomock.Any()).Times(1).Return(identityRequest)
@@ -3820,17 +4736,17 @@ func TestHasNotEnoughLookupSecretsLeftSuccess(t *testing.T) {
 	)
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
 
-	hasNotEnoughLookupSecretsLeft, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).HasNotEnoughLookupSecretsLeft(ctx, "test")
+	backupCodes, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetBackupCodes(ctx, "test")
 
-	if hasNotEnoughLookupSecretsLeft != false {
-		t.Fatalf("expected return value to be false not %v", hasNotEnoughLookupSecretsLeft)
+	if backupCodes.Configured || backupCodes.Regenerate() {
+		t.Fatalf("expected backup codes not to be configured, got %+v", backupCodes)
 	}
 	if err != nil {
 		t.Fatalf("expected error to be nil not %v", err)
 	}
 }
 
-func TestHasNotEnoughLookupSecretsLeftFailonGetIdentityExecute(t *testing.T) {
+func TestGetBackupCodesFailOnGetIdentityExecute(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -3852,16 +4768,16 @@ func TestHasNotEnoughLookupSecretsLeftFailonGetIdentityExecute(t *testing.T) {
 		ApiService: mockKratosIdentityApi,
 	}
 
-	mockTracer.EXPECT().Start(ctx, "kratos.Service.HasNotEnoughLookupSecretsLeft").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockTracer.EXPECT().Start(ctx, "kratos.Service.GetBackupCodes").Times(1).Return(ctx, trace.SpanFromContext(ctx))
 	mockAdminKratos.EXPECT().IdentityApi().Times(1).Return(mockKratosIdentityApi)
 
 	mockKratosIdentityApi.EXPECT().GetIdentity(ctx, gomock.Any()).Times(1).Return(identityRequest)
 	mockKratosIdentityApi.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	hasNotEnoughLookupSecretsLeft, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).HasNotEnoughLookupSecretsLeft(ctx, "test")
+	backupCodes, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).GetBackupCodes(ctx, "test")
 
-	if hasNotEnoughLookupSecretsLeft != false {
-		t.Fatalf("expected return value to be false not %v", hasNotEnoughLookupSecretsLeft)
+	if backupCodes != nil {
+		t.Fatalf("expected return value to be nil not %v", backupCodes)
 	}
 	if err == nil {
 		t.Fatalf("expected error not nil")
@@ -4035,7 +4951,7 @@ func TestRequireVerificationForEmail(t *testing.T) {
 				mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
 			}
 
-			svc := NewService(nil, nil, nil, nil, false, false, mockTracer, mockMonitor, mockLogger)
+			svc := NewService(nil, nil, nil, nil, false, false, 3, mockTracer, mockMonitor, mockLogger)
 
 			enforce, email, err := svc.RequireVerificationForEmail(ctx, session)
 
@@ -4095,7 +5011,7 @@ func TestHasWebAuthnAvailableSuccess(t *testing.T) {
 	)
 	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
 
-	HasWebAuthnAvailable, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).HasWebAuthnAvailable(ctx, "test")
+	HasWebAuthnAvailable, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).HasWebAuthnAvailable(ctx, "test")
 
 	if HasWebAuthnAvailable != false {
 		t.Fatalf("expected return value to be false not %v", HasWebAuthnAvailable)
@@ -4132,7 +5048,7 @@ func TestHasWebAuthnAvailableFailOnGetIdentityExecute(t *testing.T) {
 	mockKratosIdentityApi.EXPECT().GetIdentity(ctx, gomock.Any()).Times(1).Return(identityRequest)
 	mockKratosIdentityApi.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(nil, &resp, fmt.Errorf("error"))
 
-	HasWebAuthnAvailable, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, mockTracer, mockMonitor, mockLogger).HasWebAuthnAvailable(ctx, "test")
+	HasWebAuthnAvailable, err := NewService(mockKratos, mockAdminKratos, mockHydra, mockAuthz, false, false, 3, mockTracer, mockMonitor, mockLogger).HasWebAuthnAvailable(ctx, "test")
 
 	if HasWebAuthnAvailable != false {
 		t.Fatalf("expected return value to be false not %v", HasWebAuthnAvailable)
@@ -4209,6 +5125,7 @@ func TestCreateVerificationFlowSuccess(t *testing.T) {
 		nil, // authz
 		false,
 		false,
+		3,
 		mockTracer,
 		mockMonitor,
 		mockLogger,
@@ -4279,6 +5196,7 @@ func TestCreateVerificationFlowError(t *testing.T) {
 		nil,
 		false,
 		false,
+		3,
 		mockTracer,
 		mockMonitor,
 		mockLogger,
@@ -4348,6 +5266,7 @@ func TestService_GetVerificationFlow(t *testing.T) {
 			nil,
 			false,
 			false,
+			3,
 			mockTracer,
 			mockMonitor,
 			mockLogger,
@@ -4421,6 +5340,7 @@ func TestService_GetVerificationFlow(t *testing.T) {
 			nil,
 			false,
 			false,
+			3,
 			mockTracer,
 			mockMonitor,
 			mockLogger,
@@ -4505,6 +5425,7 @@ func TestService_GetVerificationFlow(t *testing.T) {
 			nil,
 			false,
 			false,
+			3,
 			mockTracer,
 			mockMonitor,
 			mockLogger,
@@ -4582,7 +5503,7 @@ func TestService_UpdateVerificationFlow(t *testing.T) {
 			Errorf(gomock.Any(), gomock.Any()).
 			Times(1)
 
-		svc := NewService(mockKratos, nil, nil, nil, false, false, mockTracer, mockMonitor, mockLogger)
+		svc := NewService(mockKratos, nil, nil, nil, false, false, 3, mockTracer, mockMonitor, mockLogger)
 
 		f, c, err := svc.UpdateVerificationFlow(ctx, flowID, body, nil)
 
@@ -4640,7 +5561,7 @@ func TestService_UpdateVerificationFlow(t *testing.T) {
 			Times(1).
 			Return(flow, nil, nil)
 
-		svc := NewService(mockKratos, nil, nil, nil, false, false, mockTracer, mockMonitor, mockLogger)
+		svc := NewService(mockKratos, nil, nil, nil, false, false, 3, mockTracer, mockMonitor, mockLogger)
 
 		f, c, err := svc.UpdateVerificationFlow(ctx, flowID, body, nil)
 
@@ -4703,7 +5624,7 @@ func TestService_UpdateVerificationFlow(t *testing.T) {
 			Times(1).
 			Return(flow, resp, nil)
 
-		svc := NewService(mockKratos, nil, nil, nil, false, false, mockTracer, mockMonitor, mockLogger)
+		svc := NewService(mockKratos, nil, nil, nil, false, false, 3, mockTracer, mockMonitor, mockLogger)
 
 		f, c, err := svc.UpdateVerificationFlow(ctx, flowID, body, nil)
 
@@ -8,10 +8,13 @@ import (
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type API struct {
+	handler http.Handler
+
 	logger logging.LoggerInterface
 }
 
@@ -20,12 +23,17 @@ func (a *API) RegisterEndpoints(mux *chi.Mux) {
 }
 
 func (a *API) prometheusHTTP(w http.ResponseWriter, r *http.Request) {
-	promhttp.Handler().ServeHTTP(w, r)
+	a.handler.ServeHTTP(w, r)
 }
 
 func NewAPI(logger logging.LoggerInterface) *API {
 	a := new(API)
 
+	// OpenMetrics is negotiated when requested so exemplars get exposed
+	a.handler = promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
 	a.logger = logger
 
 	return a
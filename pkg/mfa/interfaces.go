@@ -0,0 +1,17 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mfa
+
+import (
+	"context"
+)
+
+// AuthorizerInterface is the subset of the authorizer used to resolve group membership.
+type AuthorizerInterface interface {
+	Check(context.Context, string, string, string) (bool, error)
+}
+
+type ServiceInterface interface {
+	Evaluate(context.Context, Subject) (*Requirement, error)
+}
@@ -0,0 +1,329 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mfa
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	kClient "github.com/ory/kratos-client-go/v25"
+	"go.yaml.in/yaml/v2"
+)
+
+const (
+	MethodTOTP         = "totp"
+	MethodWebAuthn     = "webauthn"
+	MethodLookupSecret = "lookup_secret"
+
+	methodOIDC    = "oidc"
+	methodPasskey = "passkey"
+)
+
+var supportedMethods = []string{MethodTOTP, MethodWebAuthn, MethodLookupSecret}
+
+// defaultACRValues are the ACR values understood without configuration, a
+// policy can override them. phr is the phishing resistant class defined by
+// the OpenID Extended Authentication Profile.
+var defaultACRValues = map[string]ACRValue{
+	"aal1": {AAL: string(kClient.AUTHENTICATORASSURANCELEVEL_AAL1)},
+	"aal2": {AAL: string(kClient.AUTHENTICATORASSURANCELEVEL_AAL2)},
+	"phr":  {AAL: string(kClient.AUTHENTICATORASSURANCELEVEL_AAL2), Methods: []string{MethodWebAuthn}},
+}
+
+// Subject holds the attributes a policy rule can be matched against.
+type Subject struct {
+	// ClientID and ClientName identify the OAuth2 client, either can be used in a rule
+	ClientID   string
+	ClientName string
+	TenantID   string
+	IdentityID string
+	SchemaID   string
+	// FirstFactor is the kratos method used for the first authentication step
+	FirstFactor string
+	// RequestedACR holds the acr_values sent by the OAuth2 client, in order of preference
+	RequestedACR []string
+}
+
+// Requirement is the outcome of a policy evaluation.
+type Requirement struct {
+	AAL kClient.AuthenticatorAssuranceLevel
+	// Methods lists the allowed second factors, empty means any of them is accepted
+	Methods []string
+	// Rule is the name of the rule that produced the requirement
+	Rule string
+	// ACR is the requested ACR value enforced on top of the rule, if any
+	ACR string
+}
+
+// Required returns true if a second factor is needed.
+func (r *Requirement) Required() bool {
+	return r.AAL >= kClient.AUTHENTICATORASSURANCELEVEL_AAL2
+}
+
+// Allows returns true if the method can be used as a second factor.
+// Backup codes are issued alongside TOTP, so they are accepted whenever TOTP is.
+func (r *Requirement) Allows(method string) bool {
+	if len(r.Methods) == 0 {
+		return slices.Contains(supportedMethods, method)
+	}
+
+	if method == MethodLookupSecret && slices.Contains(r.Methods, MethodTOTP) {
+		return true
+	}
+
+	return slices.Contains(r.Methods, method)
+}
+
+// SatisfiedBy returns true if the session meets the requirement.
+func (r *Requirement) SatisfiedBy(session *kClient.Session) bool {
+	if !r.Required() {
+		return true
+	}
+
+	if session.GetAuthenticatorAssuranceLevel() < r.AAL {
+		return false
+	}
+
+	if len(r.Methods) == 0 {
+		return true
+	}
+
+	methods := session.GetAuthenticationMethods()
+	if len(methods) < 2 {
+		return false
+	}
+
+	for _, m := range methods[1:] {
+		if r.Allows(m.GetMethod()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AchievedACR returns the enforced ACR value if the session meets the
+// requirement, or an empty string otherwise.
+func (r *Requirement) AchievedACR(session *kClient.Session) string {
+	if r.ACR == "" || !r.SatisfiedBy(session) {
+		return ""
+	}
+
+	return r.ACR
+}
+
+// stepUp returns a copy of the requirement raised to the ACR value, the
+// stricter AAL applies and the allowed methods are narrowed down to the ones
+// both the rule and the ACR value accept. When they have none in common the
+// rule methods are kept and the ACR value cannot be achieved.
+func (r *Requirement) stepUp(acr string, value ACRValue) *Requirement {
+	requirement := &Requirement{
+		AAL:     r.AAL,
+		Methods: r.Methods,
+		Rule:    r.Rule,
+		ACR:     acr,
+	}
+
+	if aal := kClient.AuthenticatorAssuranceLevel(value.AAL); aal > requirement.AAL {
+		requirement.AAL = aal
+	}
+
+	if len(value.Methods) == 0 {
+		return requirement
+	}
+
+	methods := make([]string, 0, len(value.Methods))
+	for _, m := range value.Methods {
+		if r.Allows(m) {
+			methods = append(methods, m)
+		}
+	}
+
+	// the operator policy is never weakened by the client
+	if len(methods) == 0 {
+		requirement.ACR = ""
+		return requirement
+	}
+
+	requirement.Methods = methods
+	return requirement
+}
+
+// Rule maps a set of conditions to an MFA requirement. All the non empty
+// conditions must match, within a condition any of the values is enough.
+type Rule struct {
+	Name         string   `yaml:"name"`
+	Clients      []string `yaml:"clients"`
+	Tenants      []string `yaml:"tenants"`
+	Schemas      []string `yaml:"schemas"`
+	Groups       []string `yaml:"groups"`
+	FirstFactors []string `yaml:"first_factors"`
+	AAL          string   `yaml:"aal"`
+	Methods      []string `yaml:"methods"`
+}
+
+func (r *Rule) requirement() *Requirement {
+	return &Requirement{
+		AAL:     kClient.AuthenticatorAssuranceLevel(r.AAL),
+		Methods: r.Methods,
+		Rule:    r.Name,
+	}
+}
+
+func (r *Rule) validate() error {
+	if err := validateRequirement(r.AAL, r.Methods); err != nil {
+		return fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+
+	return nil
+}
+
+// ACRValue maps an acr_values entry requested by an OAuth2 client to an MFA requirement.
+type ACRValue struct {
+	AAL     string   `yaml:"aal"`
+	Methods []string `yaml:"methods"`
+}
+
+func validateRequirement(aal string, methods []string) error {
+	switch kClient.AuthenticatorAssuranceLevel(aal) {
+	case kClient.AUTHENTICATORASSURANCELEVEL_AAL1, kClient.AUTHENTICATORASSURANCELEVEL_AAL2:
+	default:
+		return fmt.Errorf("unsupported aal %q", aal)
+	}
+
+	for _, m := range methods {
+		if !slices.Contains(supportedMethods, m) {
+			return fmt.Errorf("unsupported method %q", m)
+		}
+	}
+
+	return nil
+}
+
+// Policy is an ordered list of rules, the first matching rule wins.
+// Default applies when no rule matches. ACRValues can raise the outcome
+// when the OAuth2 client requests one of them.
+type Policy struct {
+	Rules     []Rule              `yaml:"rules"`
+	Default   Rule                `yaml:"default"`
+	ACRValues map[string]ACRValue `yaml:"acr_values"`
+}
+
+// requestedACR returns the first of the requested ACR values known to the policy.
+func (p *Policy) requestedACR(requested []string) (string, ACRValue, bool) {
+	for _, acr := range requested {
+		if value, ok := p.ACRValues[acr]; ok {
+			return acr, value, true
+		}
+	}
+
+	return "", ACRValue{}, false
+}
+
+func (p *Policy) withDefaultACRValues() {
+	if p.ACRValues == nil {
+		p.ACRValues = make(map[string]ACRValue, len(defaultACRValues))
+	}
+
+	for acr, value := range defaultACRValues {
+		if _, ok := p.ACRValues[acr]; !ok {
+			p.ACRValues[acr] = value
+		}
+	}
+}
+
+func (p *Policy) validate() error {
+	for _, r := range p.Rules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+
+	for acr, value := range p.ACRValues {
+		if err := validateRequirement(value.AAL, value.Methods); err != nil {
+			return fmt.Errorf("acr value %q: %w", acr, err)
+		}
+	}
+
+	p.withDefaultACRValues()
+
+	if p.Default.Name == "" {
+		p.Default.Name = "default"
+	}
+
+	if p.Default.AAL == "" {
+		p.Default.AAL = string(kClient.AUTHENTICATORASSURANCELEVEL_AAL1)
+	}
+
+	return p.Default.validate()
+}
+
+// LoadPolicy reads and validates a YAML policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mfa policy: %w", err)
+	}
+
+	p := new(Policy)
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse mfa policy: %w", err)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid mfa policy: %w", err)
+	}
+
+	return p, nil
+}
+
+// NewDefaultPolicy returns the policy matching the MFA_ENABLED and
+// OIDC_WEBAUTHN_SEQUENCING_ENABLED flags, used when no policy file is configured.
+func NewDefaultPolicy(mfaEnabled, oidcWebAuthnSequencingEnabled bool) *Policy {
+	p := new(Policy)
+
+	oidc := Rule{
+		Name:         "oidc",
+		FirstFactors: []string{methodOIDC},
+		AAL:          string(kClient.AUTHENTICATORASSURANCELEVEL_AAL1),
+	}
+
+	// with sequencing enabled, users signing in with an external provider
+	// must use a passkey as second factor
+	if oidcWebAuthnSequencingEnabled {
+		oidc.AAL = string(kClient.AUTHENTICATORASSURANCELEVEL_AAL2)
+		oidc.Methods = []string{MethodWebAuthn}
+	}
+
+	p.Rules = []Rule{oidc}
+
+	// only the password and webauthn first factors require a second one,
+	// like the consent checks did before policies were configurable, a
+	// passkey already verifies the user and is never asked for another one
+	if mfaEnabled {
+		p.Rules = append(
+			p.Rules,
+			Rule{
+				Name:         "passkey",
+				FirstFactors: []string{methodPasskey},
+				AAL:          string(kClient.AUTHENTICATORASSURANCELEVEL_AAL1),
+			},
+			Rule{
+				Name:         "mfa",
+				FirstFactors: []string{"password", MethodWebAuthn},
+				AAL:          string(kClient.AUTHENTICATORASSURANCELEVEL_AAL2),
+			},
+		)
+	}
+
+	p.Default = Rule{
+		Name: "default",
+		AAL:  string(kClient.AUTHENTICATORASSURANCELEVEL_AAL1),
+	}
+
+	p.withDefaultACRValues()
+
+	return p
+}
@@ -0,0 +1,283 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mfa
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	kClient "github.com/ory/kratos-client-go/v25"
+)
+
+func sessionWithMethods(aal kClient.AuthenticatorAssuranceLevel, methods ...string) *kClient.Session {
+	session := kClient.NewSession("test")
+	session.SetAuthenticatorAssuranceLevel(aal)
+
+	for _, m := range methods {
+		method := m
+		session.AuthenticationMethods = append(session.AuthenticationMethods, kClient.SessionAuthenticationMethod{Method: &method})
+	}
+
+	return session
+}
+
+func TestRequirementSatisfiedBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		requirement Requirement
+		session     *kClient.Session
+		expected    bool
+	}{
+		{
+			name:        "aal1 is satisfied by any session",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL1, "password"),
+			expected:    true,
+		},
+		{
+			name:        "aal2 is not satisfied by an aal1 session",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL1, "password"),
+			expected:    false,
+		},
+		{
+			name:        "aal2 without methods is satisfied by any second factor",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "password", "totp"),
+			expected:    true,
+		},
+		{
+			name:        "second factor not in the allowed methods",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodWebAuthn}},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "password", "totp"),
+			expected:    false,
+		},
+		{
+			name:        "second factor in the allowed methods",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodWebAuthn}},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "oidc", "webauthn"),
+			expected:    true,
+		},
+		{
+			name:        "backup codes are accepted when totp is allowed",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodTOTP}},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "password", "lookup_secret"),
+			expected:    true,
+		},
+		{
+			name:        "missing second factor",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodTOTP}},
+			session:     sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "password"),
+			expected:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.requirement.SatisfiedBy(test.session); got != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRequirementStepUp(t *testing.T) {
+	tests := []struct {
+		name            string
+		requirement     Requirement
+		value           ACRValue
+		expectedAAL     kClient.AuthenticatorAssuranceLevel
+		expectedMethods []string
+		expectedACR     string
+	}{
+		{
+			name:        "acr raises the aal",
+			requirement: Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1},
+			value:       ACRValue{AAL: "aal2"},
+			expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedACR: "acr",
+		},
+		{
+			name:            "acr does not lower the rule",
+			requirement:     Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodWebAuthn}},
+			value:           ACRValue{AAL: "aal1"},
+			expectedAAL:     kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedMethods: []string{MethodWebAuthn},
+			expectedACR:     "acr",
+		},
+		{
+			name:            "methods are narrowed down",
+			requirement:     Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodTOTP, MethodWebAuthn}},
+			value:           ACRValue{AAL: "aal2", Methods: []string{MethodWebAuthn}},
+			expectedAAL:     kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedMethods: []string{MethodWebAuthn},
+			expectedACR:     "acr",
+		},
+		{
+			name:            "rule methods win over disjoint acr methods",
+			requirement:     Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, Methods: []string{MethodTOTP}},
+			value:           ACRValue{AAL: "aal2", Methods: []string{MethodWebAuthn}},
+			expectedAAL:     kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+			expectedMethods: []string{MethodTOTP},
+			expectedACR:     "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.requirement.stepUp("acr", test.value)
+
+			if got.ACR != test.expectedACR || got.AAL != test.expectedAAL || !slices.Equal(got.Methods, test.expectedMethods) {
+				t.Fatalf("expected %s %v, got %+v", test.expectedAAL, test.expectedMethods, got)
+			}
+		})
+	}
+}
+
+func TestRequirementAchievedACR(t *testing.T) {
+	requirement := Requirement{AAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2, ACR: "aal2"}
+
+	if acr := requirement.AchievedACR(sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL1, "password")); acr != "" {
+		t.Fatalf("expected no acr for an aal1 session, got %s", acr)
+	}
+
+	if acr := requirement.AchievedACR(sessionWithMethods(kClient.AUTHENTICATORASSURANCELEVEL_AAL2, "password", "totp")); acr != "aal2" {
+		t.Fatalf("expected acr aal2, got %s", acr)
+	}
+}
+
+func TestNewDefaultPolicy(t *testing.T) {
+	p := NewDefaultPolicy(true, true)
+
+	if len(p.Rules) != 3 || p.Rules[0].AAL != "aal2" || len(p.Rules[0].Methods) != 1 || p.Rules[0].Methods[0] != MethodWebAuthn {
+		t.Fatalf("expected oidc rule to require webauthn, got %+v", p.Rules)
+	}
+
+	if p.Rules[1].AAL != "aal1" || !slices.Equal(p.Rules[1].FirstFactors, []string{"passkey"}) {
+		t.Fatalf("expected passkey to require aal1, got %+v", p.Rules[1])
+	}
+
+	if p.Rules[2].AAL != "aal2" || !slices.Equal(p.Rules[2].FirstFactors, []string{"password", MethodWebAuthn}) {
+		t.Fatalf("expected password and webauthn to require aal2, got %+v", p.Rules[2])
+	}
+
+	if p.Default.AAL != "aal1" {
+		t.Fatalf("expected default to require aal1, got %s", p.Default.AAL)
+	}
+
+	if _, ok := p.ACRValues["phr"]; !ok {
+		t.Fatalf("expected default acr values, got %+v", p.ACRValues)
+	}
+
+	p = NewDefaultPolicy(false, false)
+
+	if len(p.Rules) != 1 || p.Rules[0].AAL != "aal1" || p.Default.AAL != "aal1" {
+		t.Fatalf("expected policy not to require mfa, got %+v", p)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name: "valid policy",
+			content: `
+rules:
+  - name: admin-console
+    clients: ["admin-console"]
+    aal: aal2
+    methods: ["webauthn"]
+  - name: wiki
+    clients: ["wiki"]
+    aal: aal1
+default:
+  aal: aal2
+acr_values:
+  aal2:
+    aal: aal2
+    methods: ["totp"]
+`,
+		},
+		{
+			name: "unsupported aal",
+			content: `
+rules:
+  - name: admin-console
+    aal: aal3
+`,
+			expectedErr: "unsupported aal",
+		},
+		{
+			name: "unsupported method",
+			content: `
+rules:
+  - name: admin-console
+    aal: aal2
+    methods: ["sms"]
+`,
+			expectedErr: "unsupported method",
+		},
+		{
+			name: "unsupported acr value",
+			content: `
+acr_values:
+  urn:example:mfa:
+    aal: aal3
+`,
+			expectedErr: "acr value",
+		},
+		{
+			name: "unknown field",
+			content: `
+rules:
+  - name: admin-console
+    aal: aal2
+    client: ["admin-console"]
+`,
+			expectedErr: "failed to parse mfa policy",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+				t.Fatalf("failed to write policy: %v", err)
+			}
+
+			p, err := LoadPolicy(path)
+
+			if test.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(p.Rules) != 2 || p.Default.Name != "default" || p.Default.AAL != "aal2" {
+				t.Fatalf("unexpected policy %+v", p)
+			}
+
+			if len(p.ACRValues["aal2"].Methods) != 1 || len(p.ACRValues) != len(defaultACRValues) {
+				t.Fatalf("expected acr values to be merged with the defaults, got %+v", p.ACRValues)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyMissingFile(t *testing.T) {
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
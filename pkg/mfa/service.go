@@ -0,0 +1,120 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mfa
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+// Service evaluates the MFA policy for a subject.
+type Service struct {
+	policy *Policy
+
+	authz AuthorizerInterface
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+// Evaluate returns the requirement of the first rule matching the subject,
+// or the policy default if none does, raised to the first requested ACR
+// value known to the policy.
+func (s *Service) Evaluate(ctx context.Context, subject Subject) (*Requirement, error) {
+	ctx, span := s.tracer.Start(ctx, "mfa.Service.Evaluate")
+	defer span.End()
+
+	requirement, err := s.evaluateRules(ctx, subject)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if acr, value, ok := s.policy.requestedACR(subject.RequestedACR); ok {
+		requirement = requirement.stepUp(acr, value)
+		span.SetAttributes(attribute.String("mfa.acr", acr))
+	}
+
+	span.SetAttributes(attribute.String("mfa.rule", requirement.Rule))
+	span.SetStatus(codes.Ok, "")
+	return requirement, nil
+}
+
+func (s *Service) evaluateRules(ctx context.Context, subject Subject) (*Requirement, error) {
+	for _, rule := range s.policy.Rules {
+		match, err := s.matches(ctx, rule, subject)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			return rule.requirement(), nil
+		}
+	}
+
+	return s.policy.Default.requirement(), nil
+}
+
+func (s *Service) matches(ctx context.Context, rule Rule, subject Subject) (bool, error) {
+	if len(rule.Clients) > 0 && !slices.Contains(rule.Clients, subject.ClientID) && !slices.Contains(rule.Clients, subject.ClientName) {
+		return false, nil
+	}
+
+	if len(rule.Tenants) > 0 && !slices.Contains(rule.Tenants, subject.TenantID) {
+		return false, nil
+	}
+
+	if len(rule.Schemas) > 0 && !slices.Contains(rule.Schemas, subject.SchemaID) {
+		return false, nil
+	}
+
+	if len(rule.FirstFactors) > 0 && !slices.Contains(rule.FirstFactors, subject.FirstFactor) {
+		return false, nil
+	}
+
+	if len(rule.Groups) == 0 {
+		return true, nil
+	}
+
+	// group membership is checked last as it needs a call to OpenFGA
+	if subject.IdentityID == "" {
+		return false, nil
+	}
+
+	for _, group := range rule.Groups {
+		member, err := s.authz.Check(ctx, fmt.Sprintf("user:%s", subject.IdentityID), "member", fmt.Sprintf("group:%s", group))
+		if err != nil {
+			return false, fmt.Errorf("failed to check group membership: %w", err)
+		}
+
+		if member {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func NewService(policy *Policy, authz AuthorizerInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+	s := new(Service)
+
+	s.policy = policy
+	s.authz = authz
+
+	s.tracer = tracer
+	s.monitor = monitor
+	s.logger = logger
+
+	return s
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package mfa
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	kClient "github.com/ory/kratos-client-go/v25"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package mfa -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package mfa -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package mfa -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package mfa -destination ./mock_tracing.go -source=../../internal/tracing/interfaces.go
+
+func testPolicy() *Policy {
+	return &Policy{
+		Rules: []Rule{
+			{Name: "admin-console", Clients: []string{"admin-console"}, AAL: "aal2", Methods: []string{MethodWebAuthn}},
+			{Name: "wiki", Clients: []string{"wiki"}, AAL: "aal1"},
+			{Name: "acme", Tenants: []string{"acme"}, Schemas: []string{"employee"}, AAL: "aal2", Methods: []string{MethodTOTP}},
+			{Name: "admins", Groups: []string{"admins"}, AAL: "aal2", Methods: []string{MethodWebAuthn}},
+		},
+		Default: Rule{Name: "default", AAL: "aal2"},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		subject      Subject
+		setupMocks   func(*MockAuthorizerInterface)
+		expectedRule string
+		expectedAAL  kClient.AuthenticatorAssuranceLevel
+	}{
+		{
+			name:         "client rule matches by name",
+			subject:      Subject{ClientID: "1234", ClientName: "admin-console"},
+			expectedRule: "admin-console",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+		},
+		{
+			name:         "client rule matches by id",
+			subject:      Subject{ClientID: "wiki"},
+			expectedRule: "wiki",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL1,
+		},
+		{
+			name:         "all conditions of a rule must match",
+			subject:      Subject{ClientID: "other", TenantID: "acme", SchemaID: "employee"},
+			expectedRule: "acme",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+		},
+		{
+			name:    "group membership is checked through the authorizer",
+			subject: Subject{ClientID: "other", TenantID: "acme", SchemaID: "customer", IdentityID: "identity"},
+			setupMocks: func(authz *MockAuthorizerInterface) {
+				authz.EXPECT().Check(gomock.Any(), "user:identity", "member", "group:admins").Return(true, nil)
+			},
+			expectedRule: "admins",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+		},
+		{
+			name:    "default applies when no rule matches",
+			subject: Subject{ClientID: "other", IdentityID: "identity"},
+			setupMocks: func(authz *MockAuthorizerInterface) {
+				authz.EXPECT().Check(gomock.Any(), "user:identity", "member", "group:admins").Return(false, nil)
+			},
+			expectedRule: "default",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+		},
+		{
+			name:         "group rules are skipped without an identity",
+			subject:      Subject{ClientID: "other"},
+			expectedRule: "default",
+			expectedAAL:  kClient.AUTHENTICATORASSURANCELEVEL_AAL2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockTracer.EXPECT().Start(ctx, "mfa.Service.Evaluate").Return(ctx, trace.SpanFromContext(ctx))
+
+			if test.setupMocks != nil {
+				test.setupMocks(mockAuthz)
+			}
+
+			requirement, err := NewService(testPolicy(), mockAuthz, mockTracer, mockMonitor, mockLogger).Evaluate(ctx, test.subject)
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if requirement.Rule != test.expectedRule {
+				t.Fatalf("expected rule %s, got %s", test.expectedRule, requirement.Rule)
+			}
+
+			if requirement.AAL != test.expectedAAL {
+				t.Fatalf("expected aal %s, got %s", test.expectedAAL, requirement.AAL)
+			}
+		})
+	}
+}
+
+func TestEvaluateFailsOnGroupCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+
+	ctx := context.Background()
+
+	mockTracer.EXPECT().Start(ctx, "mfa.Service.Evaluate").Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().Check(gomock.Any(), "user:identity", "member", "group:admins").Return(false, fmt.Errorf("error"))
+
+	_, err := NewService(testPolicy(), mockAuthz, mockTracer, mockMonitor, mockLogger).Evaluate(ctx, Subject{IdentityID: "identity"})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestEvaluateDefaultPolicyFirstFactors(t *testing.T) {
+	tests := []struct {
+		firstFactor  string
+		expectedRule string
+		expectedAAL  kClient.AuthenticatorAssuranceLevel
+	}{
+		{firstFactor: "password", expectedRule: "mfa", expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2},
+		{firstFactor: "webauthn", expectedRule: "mfa", expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL2},
+		{firstFactor: "passkey", expectedRule: "passkey", expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1},
+		{firstFactor: "oidc", expectedRule: "oidc", expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1},
+		{firstFactor: "code", expectedRule: "default", expectedAAL: kClient.AUTHENTICATORASSURANCELEVEL_AAL1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.firstFactor, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockTracer.EXPECT().Start(ctx, "mfa.Service.Evaluate").Return(ctx, trace.SpanFromContext(ctx))
+
+			requirement, err := NewService(NewDefaultPolicy(true, false), mockAuthz, mockTracer, mockMonitor, mockLogger).Evaluate(ctx, Subject{ClientID: "1234", FirstFactor: test.firstFactor})
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if requirement.Rule != test.expectedRule || requirement.AAL != test.expectedAAL {
+				t.Fatalf("expected rule %s requiring %s, got %+v", test.expectedRule, test.expectedAAL, requirement)
+			}
+		})
+	}
+}
+
+func TestEvaluateStepsUpToRequestedACR(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+
+	ctx := context.Background()
+
+	mockTracer.EXPECT().Start(ctx, "mfa.Service.Evaluate").Return(ctx, trace.SpanFromContext(ctx))
+
+	p := testPolicy()
+	p.withDefaultACRValues()
+
+	requirement, err := NewService(p, mockAuthz, mockTracer, mockMonitor, mockLogger).Evaluate(ctx, Subject{ClientID: "wiki", RequestedACR: []string{"urn:unknown", "phr"}})
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if requirement.Rule != "wiki" || requirement.ACR != "phr" {
+		t.Fatalf("expected wiki rule raised to phr, got %+v", requirement)
+	}
+
+	if requirement.AAL != kClient.AUTHENTICATORASSURANCELEVEL_AAL2 || len(requirement.Methods) != 1 || requirement.Methods[0] != MethodWebAuthn {
+		t.Fatalf("expected aal2 with webauthn, got %+v", requirement)
+	}
+}
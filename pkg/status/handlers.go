@@ -6,12 +6,14 @@ package status
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
 	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 )
 
 const okValue = "ok"
@@ -21,18 +23,24 @@ type Status struct {
 	BuildInfo *BuildInfo `json:"buildInfo"`
 }
 
+// Health reports the availability of the dependencies, Tenants is only set
+// when multi-tenancy is enabled.
 type Health struct {
-	Kratos bool `json:"kratos"`
-	Hydra  bool `json:"hydra"`
+	Kratos  bool  `json:"kratos"`
+	Hydra   bool  `json:"hydra"`
+	Tenants *bool `json:"tenants,omitempty"`
 }
 
+// DeploymentInfo is the configuration of the UI, Tenant is set once the
+// tenant of the login is resolved and its settings are merged in.
 type DeploymentInfo struct {
-	OidcSequencingEnabled  bool     `json:"oidc_webauthn_sequencing_enabled"`
-	BaseURL                string   `json:"base_url"`
-	IdentifierFirstEnabled bool     `json:"identifier_first_enabled"`
-	MultiTenancyEnabled    bool     `json:"multi_tenancy_enabled"`
-	SupportEmail           string   `json:"support_email"`
-	Flags                  []string `json:"flags"`
+	OidcSequencingEnabled  bool               `json:"oidc_webauthn_sequencing_enabled"`
+	BaseURL                string             `json:"base_url"`
+	IdentifierFirstEnabled bool               `json:"identifier_first_enabled"`
+	MultiTenancyEnabled    bool               `json:"multi_tenancy_enabled"`
+	SupportEmail           string             `json:"support_email"`
+	Flags                  []string           `json:"flags"`
+	Tenant                 *tenants.AppConfig `json:"tenant,omitempty"`
 }
 
 type API struct {
@@ -43,6 +51,7 @@ type API struct {
 	multiTenancyEnabled           bool
 	flags                         []string
 	service                       ServiceInterface
+	tenantConfig                  TenantAppConfigInterface
 
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
@@ -91,6 +100,11 @@ func (a *API) ready(w http.ResponseWriter, r *http.Request) {
 	health.Hydra = a.service.HydraStatus(r.Context())
 	health.Kratos = a.service.KratosStatus(r.Context())
 
+	if a.multiTenancyEnabled {
+		tenants := a.service.TenantsStatus(r.Context())
+		health.Tenants = &tenants
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(health)
 }
@@ -106,11 +120,41 @@ func (a *API) appConfig(w http.ResponseWriter, r *http.Request) {
 	info.SupportEmail = a.supportEmail
 	info.Flags = a.flags
 
+	if a.tenantConfig != nil {
+		a.applyTenantConfig(r, info)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(info)
 }
 
-func NewAPI(baseURL, supportEmail string, oidcWebAuthnSequencingEnabled, identifierFirstEnabled, multiTenancyEnabled bool, flags []string, service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
+// applyTenantConfig overrides info with the settings of the tenant selected
+// for the login, the global settings are kept when they cannot be fetched.
+func (a *API) applyTenantConfig(r *http.Request, info *DeploymentInfo) {
+	config, err := a.tenantConfig.AppConfig(r)
+	if err != nil {
+		a.logger.Errorf("failed to fetch tenant app config: %v", err)
+		return
+	}
+	if config == nil {
+		return
+	}
+
+	info.Tenant = config
+	if config.SupportEmail != "" {
+		info.SupportEmail = config.SupportEmail
+	}
+	// a tenant can only turn off globally enabled flags, never enable others
+	if config.Flags != nil {
+		info.Flags = slices.DeleteFunc(slices.Clone(info.Flags), func(flag string) bool {
+			return !slices.Contains(config.Flags, flag)
+		})
+	}
+}
+
+// NewAPI returns the status API, tenantConfig is nil when no tenant
+// configuration is available.
+func NewAPI(baseURL, supportEmail string, oidcWebAuthnSequencingEnabled, identifierFirstEnabled, multiTenancyEnabled bool, flags []string, service ServiceInterface, tenantConfig TenantAppConfigInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
 	a := new(API)
 
 	a.BaseURL = baseURL
@@ -119,6 +163,7 @@ func NewAPI(baseURL, supportEmail string, oidcWebAuthnSequencingEnabled, identif
 	a.identifierFirstEnabled = identifierFirstEnabled
 	a.multiTenancyEnabled = multiTenancyEnabled
 	a.flags = flags
+	a.tenantConfig = tenantConfig
 	a.service = service
 	a.tracer = tracer
 	a.monitor = monitor
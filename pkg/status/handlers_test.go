@@ -5,13 +5,17 @@ package status
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
@@ -36,7 +40,7 @@ func TestAliveOK(t *testing.T) {
 	mockService.EXPECT().BuildInfo(gomock.Any()).Times(1).Return(&BuildInfo{Version: "xyz", Name: "application"})
 
 	mux := chi.NewMux()
-	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, nil, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 	res := w.Result()
@@ -71,7 +75,7 @@ func TestHealthSuccess(t *testing.T) {
 	mockService.EXPECT().HydraStatus(gomock.Any()).Times(1).Return(true)
 
 	mux := chi.NewMux()
-	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, nil, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 	res := w.Result()
@@ -90,6 +94,9 @@ func TestHealthSuccess(t *testing.T) {
 	if !receivedStatus.Hydra {
 		t.Fatalf("expected HydraStatus to be true not  %v", receivedStatus.Hydra)
 	}
+	if receivedStatus.Tenants != nil {
+		t.Fatalf("expected Tenants to be omitted not  %v", *receivedStatus.Tenants)
+	}
 }
 
 func TestHealthFailure(t *testing.T) {
@@ -107,7 +114,7 @@ func TestHealthFailure(t *testing.T) {
 	mockService.EXPECT().KratosStatus(gomock.Any()).Times(1).Return(false)
 	mockService.EXPECT().HydraStatus(gomock.Any()).Times(1).Return(false)
 	mux := chi.NewMux()
-	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+	NewAPI("", "support@email.com", false, true, false, featureFlags, mockService, nil, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	mux.ServeHTTP(w, req)
 	res := w.Result()
@@ -128,6 +135,40 @@ func TestHealthFailure(t *testing.T) {
 	}
 }
 
+func TestHealthWithTenants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/ready", nil)
+	w := httptest.NewRecorder()
+
+	mockService.EXPECT().KratosStatus(gomock.Any()).Times(1).Return(true)
+	mockService.EXPECT().HydraStatus(gomock.Any()).Times(1).Return(true)
+	mockService.EXPECT().TenantsStatus(gomock.Any()).Times(1).Return(false)
+	mux := chi.NewMux()
+	NewAPI("", "support@email.com", false, true, true, featureFlags, mockService, nil, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	receivedStatus := new(Health)
+	if err := json.Unmarshal(data, receivedStatus); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	if receivedStatus.Tenants == nil || *receivedStatus.Tenants {
+		t.Fatalf("expected Tenants to be false not  %v", receivedStatus.Tenants)
+	}
+}
+
 func TestGetDeploymentInfo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -138,7 +179,7 @@ func TestGetDeploymentInfo(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 
 	supportEmail := "support@email.com"
-	a := NewAPI("", supportEmail, false, true, false, featureFlags, mockService, mockTracer, mockMonitor, mockLogger)
+	a := NewAPI("", supportEmail, false, true, false, featureFlags, mockService, nil, mockTracer, mockMonitor, mockLogger)
 
 	req, _ := http.NewRequest(http.MethodGet, "/api/v0/app-config", nil)
 	w := httptest.NewRecorder()
@@ -167,3 +208,78 @@ func TestGetDeploymentInfo(t *testing.T) {
 		t.Errorf("expected response code %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+func TestGetDeploymentInfoWithTenantConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockTenantConfig := NewMockTenantAppConfigInterface(ctrl)
+
+	tenantConfig := &tenants.AppConfig{
+		TenantID:     "t1",
+		DisplayName:  "Acme",
+		SupportEmail: "help@acme.com",
+		Flags:        []string{"password", "passkey"},
+	}
+	mockTenantConfig.EXPECT().AppConfig(gomock.Any()).Return(tenantConfig, nil)
+
+	a := NewAPI("", "support@email.com", false, true, true, featureFlags, mockService, mockTenantConfig, mockTracer, mockMonitor, mockLogger)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v0/app-config?login_challenge=challenge", nil)
+	w := httptest.NewRecorder()
+	a.appConfig(w, req)
+
+	receivedInfo := new(DeploymentInfo)
+	if err := json.NewDecoder(w.Result().Body).Decode(receivedInfo); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	if receivedInfo.Tenant == nil || receivedInfo.Tenant.DisplayName != "Acme" {
+		t.Fatalf("expected tenant config to be returned not %v", receivedInfo.Tenant)
+	}
+	if receivedInfo.SupportEmail != "help@acme.com" {
+		t.Fatalf("expected tenant support_email not %s", receivedInfo.SupportEmail)
+	}
+	// passkey is not enabled globally, the tenant cannot turn it on
+	if !reflect.DeepEqual(receivedInfo.Flags, []string{"password"}) {
+		t.Fatalf("expected tenant flags within the global ones not %v", receivedInfo.Flags)
+	}
+}
+
+func TestGetDeploymentInfoTenantConfigError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockTenantConfig := NewMockTenantAppConfigInterface(ctrl)
+
+	mockTenantConfig.EXPECT().AppConfig(gomock.Any()).Return(nil, fmt.Errorf("error"))
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+
+	supportEmail := "support@email.com"
+	a := NewAPI("", supportEmail, false, true, true, featureFlags, mockService, mockTenantConfig, mockTracer, mockMonitor, mockLogger)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v0/app-config", nil)
+	w := httptest.NewRecorder()
+	a.appConfig(w, req)
+
+	receivedInfo := new(DeploymentInfo)
+	if err := json.NewDecoder(w.Result().Body).Decode(receivedInfo); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	if receivedInfo.Tenant != nil {
+		t.Fatalf("expected no tenant config not %v", receivedInfo.Tenant)
+	}
+	if receivedInfo.SupportEmail != supportEmail {
+		t.Fatalf("expected support_email to be %s not %s", supportEmail, receivedInfo.SupportEmail)
+	}
+	if !reflect.DeepEqual(receivedInfo.Flags, featureFlags) {
+		t.Fatalf("expected global flags not %v", receivedInfo.Flags)
+	}
+}
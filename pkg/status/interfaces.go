@@ -5,10 +5,20 @@ package status
 
 import (
 	"context"
+	"net/http"
+
+	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 )
 
 type ServiceInterface interface {
 	KratosStatus(context.Context) bool
 	HydraStatus(context.Context) bool
+	TenantsStatus(context.Context) bool
 	BuildInfo(context.Context) *BuildInfo
 }
+
+// TenantAppConfigInterface returns the configuration of the tenant selected
+// for the login of a request, nil when there is none.
+type TenantAppConfigInterface interface {
+	AppConfig(*http.Request) (*tenants.AppConfig, error)
+}
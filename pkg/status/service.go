@@ -17,6 +17,7 @@ import (
 	kClient "github.com/ory/kratos-client-go/v25"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type BuildInfo struct {
@@ -26,11 +27,13 @@ type BuildInfo struct {
 }
 
 type Service struct {
-	kratos kClient.MetadataAPI
-	hydra  hClient.MetadataAPI
+	kratos  kClient.MetadataAPI
+	hydra   hClient.MetadataAPI
+	tenants healthpb.HealthClient
 
-	hydraStatus  healthcheck.CheckerInterface
-	kratosStatus healthcheck.CheckerInterface
+	hydraStatus   healthcheck.CheckerInterface
+	kratosStatus  healthcheck.CheckerInterface
+	tenantsStatus healthcheck.CheckerInterface
 
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
@@ -73,6 +76,19 @@ func (s *Service) HydraStatus(ctx context.Context) bool {
 	return s.hydraStatus.Status()
 }
 
+// TenantsStatus reports whether the tenant service is serving, it is always
+// false when no tenant service is configured.
+func (s *Service) TenantsStatus(ctx context.Context) bool {
+	ctx, span := s.tracer.Start(ctx, "status.Service.TenantsStatus")
+	defer span.End()
+
+	span.SetStatus(codes.Ok, "")
+	if s.tenantsStatus == nil {
+		return false
+	}
+	return s.tenantsStatus.Status()
+}
+
 func (s *Service) kratosReady(ctx context.Context) (bool, error) {
 	ctx, span := s.tracer.Start(ctx, "status.Service.kratosReady")
 	defer span.End()
@@ -135,6 +151,37 @@ func (s *Service) hydraReady(ctx context.Context) (bool, error) {
 	return ok != nil, err
 }
 
+// tenantsReady uses the gRPC health checking protocol to query the overall
+// health of the tenant service.
+func (s *Service) tenantsReady(ctx context.Context) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "status.Service.tenantsReady")
+	defer span.End()
+
+	resp, err := s.tenants.Check(ctx, &healthpb.HealthCheckRequest{})
+
+	serving := err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+
+	var available float64
+
+	if serving {
+		available = 1.0
+	}
+
+	tags := map[string]string{"component": "tenant-service"}
+
+	s.monitor.SetDependencyAvailability(tags, available)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("grpc.health.status", resp.GetStatus().String()))
+		span.SetStatus(codes.Ok, "")
+	}
+
+	return serving, err
+}
+
 func (s *Service) gitRevision(ctx context.Context, settings []debug.BuildSetting) string {
 	ctx, span := s.tracer.Start(ctx, "status.Service.gitRevision")
 	defer span.End()
@@ -150,11 +197,14 @@ func (s *Service) gitRevision(ctx context.Context, settings []debug.BuildSetting
 	return "n/a"
 }
 
-func NewService(kratos kClient.MetadataAPI, hydra hClient.MetadataAPI, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+// NewService starts the health checkers of the dependencies, tenants is nil
+// when multi-tenancy is disabled.
+func NewService(kratos kClient.MetadataAPI, hydra hClient.MetadataAPI, tenants healthpb.HealthClient, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
 	s := new(Service)
 
 	s.kratos = kratos
 	s.hydra = hydra
+	s.tenants = tenants
 
 	s.hydraStatus = healthcheck.NewChecker(s.hydraReady, tracer, logger)
 	s.kratosStatus = healthcheck.NewChecker(s.kratosReady, tracer, logger)
@@ -167,5 +217,10 @@ func NewService(kratos kClient.MetadataAPI, hydra hClient.MetadataAPI, tracer tr
 	s.hydraStatus.Start()
 	s.kratosStatus.Start()
 
+	if tenants != nil {
+		s.tenantsStatus = healthcheck.NewChecker(s.tenantsReady, tracer, logger)
+		s.tenantsStatus.Start()
+	}
+
 	return s
 }
@@ -15,6 +15,7 @@ import (
 	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 	"go.opentelemetry.io/otel/trace"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
@@ -23,6 +24,7 @@ import (
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_tracing.go -source=../../internal/tracing/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_kratos.go -mock_names MetadataApi=MockKratosMetadataAPI github.com/ory/kratos-client-go/v25 MetadataAPI
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_hydra.go -mock_names MetadataAPI=MockHydraMetadataAPI "github.com/ory/hydra-client-go/v2" MetadataAPI
+//go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_grpc_health.go google.golang.org/grpc/health/grpc_health_v1 HealthClient
 
 func TestKratosReadySuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -53,7 +55,7 @@ func TestKratosReadySuccess(t *testing.T) {
 		},
 	)
 
-	status, err := NewService(mockKratos, mockHydra, mockTracer, mockMonitor, mockLogger).kratosReady(ctx)
+	status, err := NewService(mockKratos, mockHydra, nil, mockTracer, mockMonitor, mockLogger).kratosReady(ctx)
 
 	if !status {
 		t.Fatalf("expected status to be %v not  %v", true, status)
@@ -93,7 +95,7 @@ func TestHydraReadySuccess(t *testing.T) {
 		},
 	)
 
-	status, err := NewService(mockKratos, mockHydra, mockTracer, mockMonitor, mockLogger).hydraReady(ctx)
+	status, err := NewService(mockKratos, mockHydra, nil, mockTracer, mockMonitor, mockLogger).hydraReady(ctx)
 
 	if !status {
 		t.Fatalf("expected status to be %v not  %v", true, status)
@@ -132,7 +134,7 @@ func TestKratosReadyFailure(t *testing.T) {
 		},
 	)
 
-	status, err := NewService(mockKratos, mockHydra, mockTracer, mockMonitor, mockLogger).kratosReady(ctx)
+	status, err := NewService(mockKratos, mockHydra, nil, mockTracer, mockMonitor, mockLogger).kratosReady(ctx)
 
 	if status {
 		t.Fatalf("expected status to be %v not  %v", false, status)
@@ -171,7 +173,96 @@ func TestHydraReadyFailure(t *testing.T) {
 		},
 	)
 
-	status, err := NewService(mockKratos, mockHydra, mockTracer, mockMonitor, mockLogger).hydraReady(ctx)
+	status, err := NewService(mockKratos, mockHydra, nil, mockTracer, mockMonitor, mockLogger).hydraReady(ctx)
+
+	if status {
+		t.Fatalf("expected status to be %v not  %v", false, status)
+	}
+
+	if err == nil {
+		t.Fatalf("expected error not to be nil")
+	}
+}
+
+func TestTenantsReadySuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockKratos := NewMockMetadataAPI(ctrl)
+	mockHydra := NewMockHydraMetadataAPI(ctrl)
+	mockTenants := NewMockHealthClient(ctrl)
+
+	ctx := context.Background()
+
+	mockMonitor.EXPECT().SetDependencyAvailability(map[string]string{"component": "tenant-service"}, float64(1.0)).Times(1)
+	mockTracer.EXPECT().Start(gomock.Any(), "status.Service.tenantsReady").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockTenants.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1).Return(
+		&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil,
+	)
+
+	status, err := NewService(mockKratos, mockHydra, mockTenants, mockTracer, mockMonitor, mockLogger).tenantsReady(ctx)
+
+	if !status {
+		t.Fatalf("expected status to be %v not  %v", true, status)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestTenantsReadyNotServing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockKratos := NewMockMetadataAPI(ctrl)
+	mockHydra := NewMockHydraMetadataAPI(ctrl)
+	mockTenants := NewMockHealthClient(ctrl)
+
+	ctx := context.Background()
+
+	mockMonitor.EXPECT().SetDependencyAvailability(map[string]string{"component": "tenant-service"}, float64(0.0)).Times(1)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockTenants.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1).Return(
+		&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil,
+	)
+
+	status, err := NewService(mockKratos, mockHydra, mockTenants, mockTracer, mockMonitor, mockLogger).tenantsReady(ctx)
+
+	if status {
+		t.Fatalf("expected status to be %v not  %v", false, status)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestTenantsReadyFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockKratos := NewMockMetadataAPI(ctrl)
+	mockHydra := NewMockHydraMetadataAPI(ctrl)
+	mockTenants := NewMockHealthClient(ctrl)
+
+	ctx := context.Background()
+
+	mockMonitor.EXPECT().SetDependencyAvailability(map[string]string{"component": "tenant-service"}, float64(0.0)).Times(1)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockTenants.EXPECT().Check(gomock.Any(), gomock.Any()).Times(1).Return(nil, fmt.Errorf("error"))
+
+	status, err := NewService(mockKratos, mockHydra, mockTenants, mockTracer, mockMonitor, mockLogger).tenantsReady(ctx)
 
 	if status {
 		t.Fatalf("expected status to be %v not  %v", false, status)
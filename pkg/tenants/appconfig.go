@@ -0,0 +1,203 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.yaml.in/yaml/v2"
+
+	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+var (
+	// supportedFlags mirrors the values accepted by FEATURE_FLAGS
+	supportedFlags = []string{"password", "webauthn", "backup_codes", "totp", "account_linking", "passkey"}
+	// supportedAuthMethods are the login methods the UI can show
+	supportedAuthMethods = []string{"password", "oidc", "webauthn", "passkey", "code"}
+
+	colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+// AppConfig holds the branding and settings of a tenant. Once the tenant of a
+// login is resolved it is returned by the app-config endpoint and overrides
+// the deployment wide settings: a non empty SupportEmail replaces the global
+// one and Flags, when set, restrict the global feature flags to those listed.
+//
+// AuthMethods only drives which login methods the UI shows, it is not
+// enforced by the server.
+type AppConfig struct {
+	TenantID        string   `json:"tenant_id" yaml:"-"`
+	DisplayName     string   `json:"display_name,omitempty" yaml:"display_name"`
+	LogoURL         string   `json:"logo_url,omitempty" yaml:"logo_url"`
+	PrimaryColor    string   `json:"primary_color,omitempty" yaml:"primary_color"`
+	BackgroundColor string   `json:"background_color,omitempty" yaml:"background_color"`
+	SupportEmail    string   `json:"support_email,omitempty" yaml:"support_email"`
+	AuthMethods     []string `json:"auth_methods,omitempty" yaml:"auth_methods"`
+	Flags           []string `json:"flags,omitempty" yaml:"flags"`
+	TermsURL        string   `json:"terms_url,omitempty" yaml:"terms_url"`
+	PrivacyURL      string   `json:"privacy_url,omitempty" yaml:"privacy_url"`
+}
+
+func (c *AppConfig) validate() error {
+	for _, color := range []string{c.PrimaryColor, c.BackgroundColor} {
+		if color != "" && !colorPattern.MatchString(color) {
+			return fmt.Errorf("invalid color %q, expected #rrggbb", color)
+		}
+	}
+
+	// the links are rendered as is, only web URLs are accepted
+	for _, link := range []string{c.LogoURL, c.TermsURL, c.PrivacyURL} {
+		if link == "" {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid URL %q", link)
+		}
+	}
+
+	for _, method := range c.AuthMethods {
+		if !slices.Contains(supportedAuthMethods, method) {
+			return fmt.Errorf("unknown auth method %q", method)
+		}
+	}
+
+	for _, flag := range c.Flags {
+		if !slices.Contains(supportedFlags, flag) {
+			return fmt.Errorf("unknown feature flag %q", flag)
+		}
+	}
+
+	return nil
+}
+
+// FileAppConfigSource serves the tenant configurations of a YAML file
+// mapping tenant IDs to their AppConfig under a tenants key. It is the only
+// source, the tenant service API does not expose the settings of a tenant.
+type FileAppConfigSource struct {
+	Tenants map[string]*AppConfig `yaml:"tenants"`
+}
+
+func (s *FileAppConfigSource) AppConfig(_ context.Context, tenantID string) (*AppConfig, error) {
+	c, ok := s.Tenants[tenantID]
+	if !ok {
+		return nil, nil
+	}
+
+	config := *c
+	config.TenantID = tenantID
+	return &config, nil
+}
+
+// LoadAppConfigs reads and validates the tenant configuration file at path.
+func LoadAppConfigs(path string) (*FileAppConfigSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant config: %w", err)
+	}
+
+	s := new(FileAppConfigSource)
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse tenant config: %w", err)
+	}
+
+	for id, c := range s.Tenants {
+		if c == nil {
+			return nil, fmt.Errorf("invalid tenant config: tenant %s has no settings", id)
+		}
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid tenant config for tenant %s: %w", id, err)
+		}
+	}
+
+	return s, nil
+}
+
+// AppConfigResolver finds the tenant selected for the login of a request and
+// returns its configuration. The login is identified by the login_challenge
+// query parameter or, on the pages of a login flow, by the flow parameter.
+type AppConfigResolver struct {
+	source        AppConfigSourceInterface
+	cookieManager CookieManagerInterface
+	flowFetcher   FlowFetcherInterface
+
+	tracer tracing.TracingInterface
+	logger logging.LoggerInterface
+}
+
+// AppConfig returns the configuration of the tenant selected for the login
+// of r, nil when no tenant has been selected yet or it has no configuration.
+func (a *AppConfigResolver) AppConfig(r *http.Request) (*AppConfig, error) {
+	ctx, span := a.tracer.Start(r.Context(), "tenants.AppConfigResolver.AppConfig")
+	defer span.End()
+
+	tenantID := a.tenantID(ctx, r)
+	if tenantID == "" {
+		span.SetStatus(codes.Ok, "")
+		return nil, nil
+	}
+
+	span.SetAttributes(attribute.String("tenant.id", tenantID))
+
+	config, err := a.source.AppConfig(ctx, tenantID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "cannot fetch tenant config")
+		return nil, fmt.Errorf("cannot fetch config of tenant %s: %w", tenantID, err)
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return config, nil
+}
+
+// tenantID returns the tenant stored in the state cookie for the login of r,
+// a selection bound to another login challenge is ignored.
+func (a *AppConfigResolver) tenantID(ctx context.Context, r *http.Request) string {
+	loginChallenge := r.URL.Query().Get("login_challenge")
+	if flowID := r.URL.Query().Get("flow"); loginChallenge == "" && flowID != "" {
+		// the flow may not be a login flow, it then has no tenant
+		flow, _, err := a.flowFetcher.GetLoginFlow(ctx, flowID, r.Cookies())
+		if err != nil {
+			a.logger.Debugf("cannot fetch login flow %s: %v", flowID, err)
+			return ""
+		}
+		loginChallenge = flow.GetOauth2LoginChallenge()
+	}
+
+	if loginChallenge == "" {
+		return ""
+	}
+
+	cookie, err := a.cookieManager.GetStateCookie(r)
+	if err != nil {
+		a.logger.Debugf("cannot read state cookie: %v", err)
+		return ""
+	}
+
+	if cookie.TenantID == cookies.NoTenantAvailable || cookie.LoginChallengeHash != cookies.ChallengeHash(loginChallenge) {
+		return ""
+	}
+	return cookie.TenantID
+}
+
+func NewAppConfigResolver(source AppConfigSourceInterface, cm CookieManagerInterface, flowFetcher FlowFetcherInterface, tracer tracing.TracingInterface, logger logging.LoggerInterface) *AppConfigResolver {
+	return &AppConfigResolver{
+		source:        source,
+		cookieManager: cm,
+		flowFetcher:   flowFetcher,
+		tracer:        tracer,
+		logger:        logger,
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kClient "github.com/ory/kratos-client-go/v25"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+)
+
+func writeAppConfigs(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("cannot write tenant config: %v", err)
+	}
+	return path
+}
+
+func TestLoadAppConfigs(t *testing.T) {
+	path := writeAppConfigs(t, `
+tenants:
+  t1:
+    display_name: Acme
+    logo_url: https://acme.example.com/logo.svg
+    primary_color: "#E95420"
+    support_email: help@acme.example.com
+    auth_methods: [password, oidc]
+    flags: [password, totp]
+    terms_url: https://acme.example.com/terms
+`)
+
+	source, err := LoadAppConfigs(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config, err := source.AppConfig(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config == nil || config.TenantID != "t1" || config.DisplayName != "Acme" || len(config.Flags) != 2 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	config, err = source.AppConfig(context.Background(), "unknown")
+	if err != nil || config != nil {
+		t.Fatalf("expected no config, got %+v, %v", config, err)
+	}
+}
+
+func TestLoadAppConfigsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown key", content: "tenants:\n  t1:\n    logo: https://acme.example.com/logo.svg\n"},
+		{name: "invalid color", content: "tenants:\n  t1:\n    primary_color: \"red;}\"\n"},
+		{name: "script URL", content: "tenants:\n  t1:\n    terms_url: javascript:alert(1)\n"},
+		{name: "unknown flag", content: "tenants:\n  t1:\n    flags: [sso]\n"},
+		{name: "unknown auth method", content: "tenants:\n  t1:\n    auth_methods: [magic]\n"},
+		{name: "empty tenant", content: "tenants:\n  t1:\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := LoadAppConfigs(writeAppConfigs(t, test.content)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadAppConfigsMissingFile(t *testing.T) {
+	if _, err := LoadAppConfigs(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAppConfigResolverByLoginChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	mockSource := NewMockAppConfigSourceInterface(ctrl)
+	r := NewAppConfigResolver(mockSource, mockCM, nil, &noopTracer{}, nil)
+
+	expected := &AppConfig{TenantID: "t1", DisplayName: "Acme"}
+	mockCM.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{TenantID: "t1", LoginChallengeHash: cookies.ChallengeHash("challenge")}, nil)
+	mockSource.EXPECT().AppConfig(gomock.Any(), "t1").Return(expected, nil)
+
+	config, err := r.AppConfig(httptest.NewRequest("GET", "/api/v0/app-config?login_challenge=challenge", nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config != expected {
+		t.Fatalf("unexpected config %+v", config)
+	}
+}
+
+func TestAppConfigResolverByFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	mockSource := NewMockAppConfigSourceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	r := NewAppConfigResolver(mockSource, mockCM, mockFetcher, &noopTracer{}, nil)
+
+	flow := kClient.NewLoginFlowWithDefaults()
+	flow.SetOauth2LoginChallenge("challenge")
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), "flow-1", gomock.Any()).Return(flow, nil, nil)
+	mockCM.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{TenantID: "t1", LoginChallengeHash: cookies.ChallengeHash("challenge")}, nil)
+	mockSource.EXPECT().AppConfig(gomock.Any(), "t1").Return(&AppConfig{TenantID: "t1"}, nil)
+
+	config, err := r.AppConfig(httptest.NewRequest("GET", "/api/v0/app-config?flow=flow-1", nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config == nil {
+		t.Fatal("expected a config")
+	}
+}
+
+func TestAppConfigResolverIgnoresNonLoginFlow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	r := NewAppConfigResolver(NewMockAppConfigSourceInterface(ctrl), NewMockCookieManagerInterface(ctrl), mockFetcher, &noopTracer{}, mockLogger)
+
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), "recovery-flow", gomock.Any()).Return(nil, nil, errors.New("not found"))
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any())
+
+	config, err := r.AppConfig(httptest.NewRequest("GET", "/api/v0/app-config?flow=recovery-flow", nil))
+	if err != nil || config != nil {
+		t.Fatalf("expected no config, got %+v, %v", config, err)
+	}
+}
+
+func TestAppConfigResolverIgnoresUnresolvedTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie cookies.FlowStateCookie
+	}{
+		{name: "no tenant", cookie: cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash("challenge")}},
+		{name: "no tenant available", cookie: cookies.FlowStateCookie{TenantID: cookies.NoTenantAvailable, LoginChallengeHash: cookies.ChallengeHash("challenge")}},
+		{name: "other challenge", cookie: cookies.FlowStateCookie{TenantID: "t1", LoginChallengeHash: cookies.ChallengeHash("previous")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockCM := NewMockCookieManagerInterface(ctrl)
+			r := NewAppConfigResolver(NewMockAppConfigSourceInterface(ctrl), mockCM, nil, &noopTracer{}, nil)
+
+			mockCM.EXPECT().GetStateCookie(gomock.Any()).Return(test.cookie, nil)
+
+			config, err := r.AppConfig(httptest.NewRequest("GET", "/api/v0/app-config?login_challenge=challenge", nil))
+			if err != nil || config != nil {
+				t.Fatalf("expected no config, got %+v, %v", config, err)
+			}
+		})
+	}
+}
+
+func TestAppConfigResolverSourceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	mockSource := NewMockAppConfigSourceInterface(ctrl)
+	r := NewAppConfigResolver(mockSource, mockCM, nil, &noopTracer{}, nil)
+
+	mockCM.EXPECT().GetStateCookie(gomock.Any()).Return(cookies.FlowStateCookie{TenantID: "t1", LoginChallengeHash: cookies.ChallengeHash("challenge")}, nil)
+	mockSource.EXPECT().AppConfig(gomock.Any(), "t1").Return(nil, errors.New("unavailable"))
+
+	if _, err := r.AppConfig(httptest.NewRequest("GET", "/api/v0/app-config?login_challenge=challenge", nil)); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+const (
+	cacheHit         = "hit"
+	cacheNegativeHit = "negative_hit"
+	cacheMiss        = "miss"
+)
+
+type cacheEntry struct {
+	key       string
+	tenants   []*Tenant
+	expiresAt time.Time
+}
+
+// CachedService decorates a ServiceInterface with a bounded, least recently
+// used cache of tenant lookups. Empty results are cached for negativeTTL,
+// concurrent lookups of the same key share a single upstream call.
+//
+// Lookups by flow resolve the email from the Kratos flow and go through the
+// email cache, so a flow whose identifier changed never gets a stale result.
+type CachedService struct {
+	service     ServiceInterface
+	flowFetcher FlowFetcherInterface
+
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	group   singleflight.Group
+	now     func() time.Time
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (c *CachedService) LookupTenantsByEmail(ctx context.Context, email string) ([]*Tenant, error) {
+	return c.lookup(ctx, emailKey(email), func(ctx context.Context) ([]*Tenant, error) {
+		return c.service.LookupTenantsByEmail(ctx, email)
+	})
+}
+
+func (c *CachedService) LookupTenantsByIdentityID(ctx context.Context, identityID string) ([]*Tenant, error) {
+	return c.lookup(ctx, "identity:"+identityID, func(ctx context.Context) ([]*Tenant, error) {
+		return c.service.LookupTenantsByIdentityID(ctx, identityID)
+	})
+}
+
+func (c *CachedService) LookupTenantsByFlow(ctx context.Context, flowID string, cookies []*http.Cookie) ([]*Tenant, error) {
+	ctx, span := c.tracer.Start(ctx, "tenants.CachedService.LookupTenantsByFlow")
+	defer span.End()
+
+	email, err := c.emailFromFlowID(ctx, flowID, cookies)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return c.LookupTenantsByEmail(ctx, email)
+}
+
+// InvalidateByFlow drops the cached tenants of the email entered in the flow.
+func (c *CachedService) InvalidateByFlow(ctx context.Context, flowID string, cookies []*http.Cookie) error {
+	email, err := c.emailFromFlowID(ctx, flowID, cookies)
+	if err != nil {
+		return err
+	}
+
+	c.remove(emailKey(email))
+	return nil
+}
+
+// InvalidateByIdentityID drops the cached tenants of the identity.
+func (c *CachedService) InvalidateByIdentityID(_ context.Context, identityID string) {
+	c.remove("identity:" + identityID)
+}
+
+// emailKey lowercases the email like cookies.SubjectHash, so the same
+// address typed with a different case shares a cache entry.
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(email)
+}
+
+func (c *CachedService) emailFromFlowID(ctx context.Context, flowID string, cookies []*http.Cookie) (string, error) {
+	flow, _, err := c.flowFetcher.GetLoginFlow(ctx, flowID, cookies)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch login flow: %w", err)
+	}
+
+	return emailFromFlow(flow)
+}
+
+func (c *CachedService) lookup(ctx context.Context, key string, fetch func(context.Context) ([]*Tenant, error)) ([]*Tenant, error) {
+	ctx, span := c.tracer.Start(ctx, "tenants.CachedService.lookup")
+	defer span.End()
+
+	if tenants, ok := c.get(key); ok {
+		result := cacheHit
+		if len(tenants) == 0 {
+			result = cacheNegativeHit
+		}
+		c.observe(result)
+		span.SetAttributes(attribute.String("cache.result", result))
+		span.SetStatus(codes.Ok, "")
+		return tenants, nil
+	}
+
+	c.observe(cacheMiss)
+	span.SetAttributes(attribute.String("cache.result", cacheMiss))
+
+	// the shared call must not be cancelled when the first caller goes away,
+	// the upstream timeout still applies
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		tenants, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		c.set(key, tenants)
+		return tenants, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		err := ctx.Err()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	case res := <-ch:
+		if res.Err != nil {
+			span.RecordError(res.Err)
+			span.SetStatus(codes.Error, res.Err.Error())
+			return nil, res.Err
+		}
+
+		span.SetAttributes(attribute.Bool("cache.shared", res.Shared))
+		span.SetStatus(codes.Ok, "")
+		return res.Val.([]*Tenant), nil
+	}
+}
+
+func (c *CachedService) get(key string) ([]*Tenant, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(e)
+	return entry.tenants, true
+}
+
+func (c *CachedService) set(key string, tenants []*Tenant) {
+	ttl := c.ttl
+	if len(tenants) == 0 {
+		ttl = c.negativeTTL
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, tenants: tenants, expiresAt: c.now().Add(ttl)}
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *CachedService) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
+
+func (c *CachedService) observe(result string) {
+	tags := map[string]string{"cache": "tenants", "result": result}
+	if err := c.monitor.SetCacheLookupMetric(tags, 1); err != nil {
+		c.logger.Debugf("failed to record cache lookup metric: %v", err)
+	}
+}
+
+const defaultCacheMaxEntries = 1000
+
+func NewCachedService(service ServiceInterface, flowFetcher FlowFetcherInterface, ttl, negativeTTL time.Duration, maxEntries int, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *CachedService {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return &CachedService{
+		service:     service,
+		flowFetcher: flowFetcher,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		maxEntries:  maxEntries,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+		tracer:      tracer,
+		monitor:     monitor,
+		logger:      logger,
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/misc/clock"
+)
+
+func TestCachedServiceLookupTenantsByEmailHit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+
+	expected := []*Tenant{{ID: "t1", Enabled: true}}
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return(expected, nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheHit}, float64(1)).Return(nil).Times(1)
+
+	// the second lookup differs only in case and shares the cache entry
+	for _, email := range []string{"user@example.com", "User@Example.COM"} {
+		got, err := c.LookupTenantsByEmail(ctx, email)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(got) != 1 || got[0].ID != "t1" {
+			t.Fatalf("unexpected tenants %v", got)
+		}
+	}
+}
+
+func TestCachedServiceExpiresEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	fakeClock := clock.NewFake()
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+	c.now = fakeClock.Now
+
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "identity").Return([]*Tenant{{ID: "t1"}}, nil).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(2)
+
+	if _, err := c.LookupTenantsByIdentityID(ctx, "identity"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	fakeClock.Advance(time.Minute)
+
+	if _, err := c.LookupTenantsByIdentityID(ctx, "identity"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCachedServiceNegativeCaching(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	fakeClock := clock.NewFake()
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+	c.now = fakeClock.Now
+
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return([]*Tenant{}, nil).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheNegativeHit}, float64(1)).Return(nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// the negative TTL is shorter than the TTL
+	fakeClock.Advance(10 * time.Second)
+
+	if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCachedServiceDoesNotCacheErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return(nil, errors.New("unavailable")).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+}
+
+func TestCachedServiceEvictsLeastRecentlyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 2, &noopTracer{}, mockMonitor, nil)
+
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "a").Return([]*Tenant{{ID: "t1"}}, nil).Times(1)
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "b").Return([]*Tenant{{ID: "t1"}}, nil).Times(2)
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "c").Return([]*Tenant{{ID: "t1"}}, nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(4)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheHit}, float64(1)).Return(nil).Times(1)
+
+	// a is used again before c is added, b is the one evicted
+	for _, id := range []string{"a", "b", "a", "c", "b"} {
+		if _, err := c.LookupTenantsByIdentityID(ctx, id); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if len(c.entries) != 2 || c.order.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(c.entries))
+	}
+}
+
+func TestCachedServiceCollapsesConcurrentLookups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, nil, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+
+	const callers = 5
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").DoAndReturn(
+		func(context.Context, string) ([]*Tenant, error) {
+			close(started)
+			<-release
+			return []*Tenant{{ID: "t1"}}, nil
+		},
+	).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(gomock.Any(), float64(1)).Return(nil).Times(callers)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	lookup := func() {
+		defer wg.Done()
+		_, err := c.LookupTenantsByEmail(ctx, "user@example.com")
+		errs <- err
+	}
+
+	wg.Add(1)
+	go lookup()
+	<-started
+
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go lookup()
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestCachedServiceLookupTenantsByFlowUsesEmailCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, mockFetcher, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), "flow-1", gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil)
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return([]*Tenant{{ID: "t1"}}, nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(1)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheHit}, float64(1)).Return(nil).Times(1)
+
+	if _, err := c.LookupTenantsByFlow(ctx, "flow-1", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCachedServiceInvalidate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	c := NewCachedService(mockService, mockFetcher, time.Minute, 10*time.Second, 10, &noopTracer{}, mockMonitor, nil)
+
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return([]*Tenant{{ID: "t1"}}, nil).Times(2)
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "identity").Return([]*Tenant{{ID: "t1"}}, nil).Times(2)
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), "flow-1", gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil)
+	mockMonitor.EXPECT().SetCacheLookupMetric(map[string]string{"cache": "tenants", "result": cacheMiss}, float64(1)).Return(nil).Times(4)
+
+	if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := c.LookupTenantsByIdentityID(ctx, "identity"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := c.InvalidateByFlow(ctx, "flow-1", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	c.InvalidateByIdentityID(ctx, "identity")
+
+	if _, err := c.LookupTenantsByEmail(ctx, "user@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := c.LookupTenantsByIdentityID(ctx, "identity"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestNewCachedServiceDefaultsMaxEntries(t *testing.T) {
+	c := NewCachedService(nil, nil, time.Minute, time.Second, 0, nil, nil, nil)
+	if c.maxEntries != defaultCacheMaxEntries {
+		t.Fatalf("expected max entries %d, got %d", defaultCacheMaxEntries, c.maxEntries)
+	}
+}
@@ -4,7 +4,6 @@
 package tenants
 
 import (
-	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -12,6 +11,10 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
 )
 
 const (
@@ -20,25 +23,45 @@ const (
 )
 
 // NewGRPCConn creates a gRPC client connection to address, configured with
-// keepalive parameters and optionally TLS transport credentials.
+// keepalive parameters and optionally TLS transport credentials described by
+// tlsConfig. When bearerToken is set it is sent with every call, which
+// requires TLS. The TLS files are loaded here so that a misconfiguration is
+// reported at startup. Every call is traced and recorded in the outbound
+// metrics of the tenant-service client.
 // The caller is responsible for closing the returned connection.
-func NewGRPCConn(address string, tlsEnabled bool) (*grpc.ClientConn, error) {
+func NewGRPCConn(address string, tlsConfig TLSConfig, bearerToken string, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) (*grpc.ClientConn, error) {
 	var creds credentials.TransportCredentials
-	if tlsEnabled {
-		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if tlsConfig.Enabled {
+		c, err := newTLSCredentials(tlsConfig, logger)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration for tenant-service at %s: %w", address, err)
+		}
+		creds = c
 	} else {
+		if err := tlsConfig.Validate(); err != nil {
+			return nil, err
+		}
 		creds = insecure.NewCredentials()
 	}
 
-	conn, err := grpc.NewClient(
-		address,
+	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(creds),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                keepaliveTime,
 			Timeout:             keepaliveTimeout,
 			PermitWithoutStream: true,
 		}),
-	)
+		grpc.WithUnaryInterceptor(monitoring.UnaryClientInterceptor(tenantServiceClientName, tracer, monitor, logger)),
+	}
+
+	if bearerToken != "" {
+		if !tlsConfig.Enabled {
+			return nil, fmt.Errorf("cannot send a bearer token to tenant-service at %s without TLS", address)
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerTokenCredentials(bearerToken)))
+	}
+
+	conn, err := grpc.NewClient(address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gRPC client for tenant-service at %s: %v", address, err)
 	}
@@ -6,15 +6,20 @@ package tenants
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	kClient "github.com/ory/kratos-client-go/v25"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
 	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 	"github.com/canonical/identity-platform-login-ui/internal/tracing"
 )
 
@@ -22,16 +27,22 @@ import (
 type API struct {
 	service        ServiceInterface
 	sessionChecker SessionCheckerInterface
+	flowFetcher    FlowFetcherInterface
+	clientFetcher  ClientFetcherInterface
 	storer         TenantStorerInterface
 	baseURL        string
 
-	tracer tracing.TracingInterface
-	logger logging.LoggerInterface
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
 }
 
 func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/tenants", a.handleLookupTenants)
 	mux.Post("/api/v0/auth/tenant", a.handleTenantSelection)
+	mux.Get("/api/v0/auth/tenant/remembered", a.handleGetRememberedTenant)
+	mux.Delete("/api/v0/auth/tenant/remembered", a.handleForgetTenant)
+	mux.Post("/api/v0/auth/tenant/switch", a.handleTenantSwitch)
 }
 
 // handleLookupTenants accepts an optional ?flow= query parameter. When flow is
@@ -65,13 +76,68 @@ func (a *API) handleLookupTenants(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		a.logger.Errorf("failed to look up tenants: %v", err)
-		http.Error(w, "failed to look up tenants", http.StatusInternalServerError)
+		http.Error(w, "failed to look up tenants", lookupErrorStatus(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(map[string]any{"tenants": tenants})
+	_ = json.NewEncoder(w).Encode(map[string]any{"tenants": pickerTenants(tenants)})
+}
+
+// TenantUnavailableDisabled is the unavailable_reason of a disabled tenant in
+// the tenant picker.
+const TenantUnavailableDisabled = "tenant_disabled"
+
+// pickerTenant is a tenant as listed in the tenant picker. A tenant that
+// cannot be selected is still listed with the reason, so users are not left
+// wondering where it went.
+type pickerTenant struct {
+	Tenant
+	Selectable        bool   `json:"selectable"`
+	UnavailableReason string `json:"unavailable_reason,omitempty"`
+	DisplayOrder      int    `json:"display_order"`
+}
+
+// pickerTenants sorts the tenants for display, selectable tenants first and
+// then by name, and flags the ones the user cannot select. The tenants are
+// copied, they may be shared with the tenant cache.
+func pickerTenants(tenants []*Tenant) []*pickerTenant {
+	result := make([]*pickerTenant, 0, len(tenants))
+	for _, t := range tenants {
+		p := &pickerTenant{Tenant: *t, Selectable: t.Enabled}
+		if !t.Enabled {
+			p.UnavailableReason = TenantUnavailableDisabled
+		}
+		result = append(result, p)
+	}
+
+	slices.SortStableFunc(result, func(a, b *pickerTenant) int {
+		if a.Selectable != b.Selectable {
+			if a.Selectable {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	for i, p := range result {
+		p.DisplayOrder = i
+	}
+	return result
+}
+
+// lookupErrorStatus maps a failed tenant lookup to 503 when the tenant service
+// is unavailable so that clients know the request can be retried.
+func lookupErrorStatus(err error) int {
+	if errors.Is(err, ErrTenantServiceUnavailable) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
 }
 
 // tenantSelectionRequest is the JSON body for POST /api/v0/auth/tenant.
@@ -79,6 +145,11 @@ type tenantSelectionRequest struct {
 	LoginChallenge string `json:"login_challenge"`
 	TenantID       string `json:"tenant_id"`
 	Flow           string `json:"flow"`
+	Remember       bool   `json:"remember"`
+}
+
+type tenantSwitchRequest struct {
+	ClientID string `json:"client_id"`
 }
 
 // handleTenantSelection receives a JSON body with login_challenge, tenant_id,
@@ -86,10 +157,15 @@ type tenantSelectionRequest struct {
 // and returns a JSON response redirecting to /ui/login?flow=<flow> so the user
 // lands on the already-advanced Kratos flow (choose_method step).
 //
-// If tenant_id is empty the handler performs a server-side tenant lookup to
-// verify the user genuinely has no tenants available, then stores the
-// no-tenant sentinel on their behalf. The sentinel value itself is never
-// accepted directly from the client to prevent bypassing tenant selection.
+// The submitted tenant_id is checked against a server-side tenant lookup, a
+// tenant the user is not a member of or that is disabled is rejected with 403.
+// If tenant_id is empty the lookup must confirm the user genuinely has no
+// tenants available, then the no-tenant sentinel is stored on their behalf.
+// The sentinel value itself is never accepted directly from the client to
+// prevent bypassing tenant selection.
+//
+// When remember is set the tenant is picked without asking on the next logins
+// of the user from this browser, as long as they are still a member of it.
 func (a *API) handleTenantSelection(w http.ResponseWriter, r *http.Request) {
 	var body tenantSelectionRequest
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -109,27 +185,42 @@ func (a *API) handleTenantSelection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tenants, subject, err := a.lookupTenants(r.Context(), body.Flow, r.Cookies())
+	if err != nil {
+		a.logger.Errorf("failed to look up tenants for selection check: %v", err)
+		http.Error(w, "failed to verify tenant list", lookupErrorStatus(err))
+		return
+	}
+
 	// Determine the tenant ID to persist. An empty submission is allowed only
 	// when server-side verification confirms the user has no tenants.
-	// A non-empty tenant_id is stored without membership validation here.
-	// Defense-in-depth is provided by two server-side gates in the Tenant
-	// Service: the Kratos login hook and the Hydra token hook both call
-	// GetActiveMemberByTenantAndUserID and reject non-members with 403,
-	// preventing any forged tenant_id from reaching the final OAuth2 tokens.
+	// The Kratos login hook and the Hydra token hook of the Tenant Service
+	// still reject non-members, this check surfaces forgeries early.
 	tenantToStore := body.TenantID
 	if tenantToStore == "" {
-		tenants, err := a.lookupTenants(r.Context(), body.Flow, r.Cookies())
-		if err != nil {
-			a.logger.Errorf("failed to look up tenants for empty-selection check: %v", err)
-			http.Error(w, "failed to verify tenant list", http.StatusInternalServerError)
-			return
-		}
-
 		if len(tenants) != 0 {
 			http.Error(w, "tenant_id is required", http.StatusBadRequest)
 			return
 		}
 		tenantToStore = cookies.NoTenantAvailable
+		a.observeTenantSelection(monitoring.OutcomeSkipped)
+	} else {
+		selected := findTenant(tenants, tenantToStore)
+		if selected == nil {
+			a.logger.Security().AuthzFailure(subject, "tenant:"+tenantToStore, logging.WithRequest(r), logging.WithLabel("reason", "not_member"))
+			a.observeTenantSelection(monitoring.OutcomeRejected)
+			http.Error(w, "tenant not available", http.StatusForbidden)
+			return
+		}
+
+		if !selected.Enabled {
+			a.logger.Security().AuthzFailure(subject, "tenant:"+tenantToStore, logging.WithRequest(r), logging.WithLabel("reason", "tenant_disabled"))
+			a.observeTenantSelection(monitoring.OutcomeRejected)
+			http.Error(w, "tenant is disabled", http.StatusForbidden)
+			return
+		}
+
+		a.observeTenantSelection(monitoring.OutcomeSuccess)
 	}
 
 	if err := a.storer.StoreTenant(w, r, tenantToStore, body.LoginChallenge); err != nil {
@@ -138,6 +229,12 @@ func (a *API) handleTenantSelection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	a.invalidateTenants(r.Context(), body.Flow, subject, r.Cookies())
+
+	if body.Remember && tenantToStore != cookies.NoTenantAvailable {
+		a.rememberTenant(w, r, body.Flow, tenantToStore)
+	}
+
 	// When flow is absent (active-session path) redirect back to the login page
 	// with only the login_challenge so the backend can accept the Hydra challenge
 	// using the existing Kratos session, without starting a new credentials flow.
@@ -154,23 +251,215 @@ func (a *API) handleTenantSelection(w http.ResponseWriter, r *http.Request) {
 }
 
 // lookupTenants returns the caller's tenant list using the Kratos flow when
-// provided, falling back to the active Kratos session's identity ID.
-func (a *API) lookupTenants(ctx context.Context, flowID string, httpCookies []*http.Cookie) ([]*Tenant, error) {
+// provided, falling back to the active Kratos session's identity ID. The
+// returned subject identifies the caller in security events.
+func (a *API) lookupTenants(ctx context.Context, flowID string, httpCookies []*http.Cookie) ([]*Tenant, string, error) {
 	if flowID != "" {
-		return a.service.LookupTenantsByFlow(ctx, flowID, httpCookies)
+		tenants, err := a.service.LookupTenantsByFlow(ctx, flowID, httpCookies)
+		return tenants, "flow:" + flowID, err
 	}
 
 	session, _, err := a.sessionChecker.CheckSession(ctx, httpCookies)
 	if err != nil {
-		return nil, fmt.Errorf("cannot check session: %v", err)
+		return nil, "", fmt.Errorf("cannot check session: %v", err)
 	}
 
 	identityID := identityIDFromSession(session)
 	if identityID == "" {
-		return nil, fmt.Errorf("cannot determine identity from session")
+		return nil, "", fmt.Errorf("cannot determine identity from session")
+	}
+
+	tenants, err := a.service.LookupTenantsByIdentityID(ctx, identityID)
+	return tenants, identityID, err
+}
+
+// handleGetRememberedTenant returns the tenant remembered for the caller,
+// identified by the ?flow= query parameter or the active Kratos session, so
+// the UI can offer to switch to another one. It returns 404 when no tenant is
+// remembered or the caller is no longer a member of it.
+func (a *API) handleGetRememberedTenant(w http.ResponseWriter, r *http.Request) {
+	flowID := r.URL.Query().Get("flow")
+
+	email, err := a.callerEmail(r.Context(), flowID, r.Cookies())
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tenantID := tenantPreferenceFromContext(r.Context()).TenantID(email)
+	if tenantID == "" {
+		http.Error(w, "no tenant remembered", http.StatusNotFound)
+		return
+	}
+
+	tenants, _, err := a.lookupTenants(r.Context(), flowID, r.Cookies())
+	if err != nil {
+		a.logger.Errorf("failed to look up tenants: %v", err)
+		http.Error(w, "failed to look up tenants", lookupErrorStatus(err))
+		return
 	}
 
-	return a.service.LookupTenantsByIdentityID(ctx, identityID)
+	remembered := findTenant(tenants, tenantID)
+	if remembered == nil || !remembered.Enabled {
+		http.Error(w, "no tenant remembered", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]any{"tenant": remembered})
+}
+
+// handleForgetTenant drops the tenant remembered for the caller, identified
+// by the ?flow= query parameter or the active Kratos session. The user is
+// asked to pick a tenant again on the next tenant selection.
+func (a *API) handleForgetTenant(w http.ResponseWriter, r *http.Request) {
+	email, err := a.callerEmail(r.Context(), r.URL.Query().Get("flow"), r.Cookies())
+	if err != nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := a.storer.ForgetTenant(w, r, email); err != nil {
+		a.logger.Errorf("failed to forget tenant: %v", err)
+		http.Error(w, "failed to forget tenant", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTenantSwitch lets a logged-in user switch the tenant of the client
+// given by client_id in the JSON body. The tenant of a login is fixed in the
+// tokens once consent completes, so the switch marks the state cookie and
+// returns the initiate_login_uri of the client, which starts a new
+// authorization request. That login reuses the session and asks the user to
+// pick a tenant through POST /api/v0/auth/tenant, it is then accepted without
+// re-authentication and the client receives tokens for the new tenant.
+//
+// The switch changes the state of the session, so it is only accepted as a
+// JSON POST. A cross-site page cannot send that body without a CORS preflight,
+// which is not granted credentials, and the cookies are SameSite=Lax, so other
+// sites cannot start a switch on behalf of the user.
+func (a *API) handleTenantSwitch(w http.ResponseWriter, r *http.Request) {
+	if !isJSONRequest(r) {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var body tenantSwitchRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	clientID := body.ClientID
+	if clientID == "" {
+		http.Error(w, "client_id is required", http.StatusBadRequest)
+		return
+	}
+
+	session, _, err := a.sessionChecker.CheckSession(r.Context(), r.Cookies())
+	if err != nil || identityIDFromSession(session) == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	client, err := a.clientFetcher.GetOAuth2Client(r.Context(), clientID)
+	if err != nil {
+		a.logger.Errorf("failed to fetch client %s: %v", clientID, err)
+		http.Error(w, "failed to fetch client", http.StatusInternalServerError)
+		return
+	}
+
+	loginURI, err := initiateLoginURI(client)
+	if err != nil {
+		a.logger.Debugf("cannot switch tenant: %v", err)
+		http.Error(w, "client does not support tenant switching", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.storer.StartTenantSwitch(w, r); err != nil {
+		a.logger.Errorf("failed to start tenant switch: %v", err)
+		http.Error(w, "failed to start tenant switch", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]string{"redirect_to": loginURI})
+}
+
+// isJSONRequest reports whether the request body is declared as JSON, the
+// content types of HTML forms are rejected.
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// rememberTenant stores the selection for the next logins, a failure only
+// means the user is asked again next time.
+func (a *API) rememberTenant(w http.ResponseWriter, r *http.Request, flowID, tenantID string) {
+	email, err := a.callerEmail(r.Context(), flowID, r.Cookies())
+	if err != nil {
+		a.logger.Warnf("failed to determine the email to remember the tenant for: %v", err)
+		return
+	}
+
+	if err := a.storer.RememberTenant(w, r, email, tenantID); err != nil {
+		a.logger.Warnf("failed to remember tenant: %v", err)
+	}
+}
+
+// callerEmail returns the email entered in the Kratos flow when provided,
+// falling back to the email of the active Kratos session.
+func (a *API) callerEmail(ctx context.Context, flowID string, httpCookies []*http.Cookie) (string, error) {
+	if flowID != "" {
+		flow, _, err := a.flowFetcher.GetLoginFlow(ctx, flowID, httpCookies)
+		if err != nil {
+			return "", fmt.Errorf("cannot fetch login flow: %v", err)
+		}
+		return emailFromFlow(flow)
+	}
+
+	session, _, err := a.sessionChecker.CheckSession(ctx, httpCookies)
+	if err != nil {
+		return "", fmt.Errorf("cannot check session: %v", err)
+	}
+
+	email := emailFromSession(session)
+	if email == "" {
+		return "", fmt.Errorf("cannot determine email from session")
+	}
+	return email, nil
+}
+
+// invalidateTenants drops the cached tenant list of the caller once the
+// selection is stored, membership changes are then picked up on next login.
+func (a *API) invalidateTenants(ctx context.Context, flowID, identityID string, httpCookies []*http.Cookie) {
+	cache, ok := a.service.(TenantCacheInterface)
+	if !ok {
+		return
+	}
+
+	if flowID == "" {
+		cache.InvalidateByIdentityID(ctx, identityID)
+		return
+	}
+
+	if err := cache.InvalidateByFlow(ctx, flowID, httpCookies); err != nil {
+		a.logger.Warnf("failed to invalidate cached tenants: %v", err)
+	}
+}
+
+// findTenant returns the tenant with the given ID, nil if it is not listed.
+func findTenant(tenants []*Tenant, tenantID string) *Tenant {
+	for _, t := range tenants {
+		if t.ID == tenantID {
+			return t
+		}
+	}
+	return nil
 }
 
 // loginChallengeURL builds the /ui/login?login_challenge=<challenge> URL used
@@ -196,20 +485,34 @@ func (a *API) loginURL(flowID string) string {
 	return u.String()
 }
 
+// observeTenantSelection records the outcome of a tenant selection, the
+// tenant is not a label to keep the number of series bounded.
+func (a *API) observeTenantSelection(outcome string) {
+	if err := a.monitor.SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventTenantSelection, "", "", outcome), 1); err != nil {
+		a.logger.Debugf("cannot record auth event metric: %v", err)
+	}
+}
+
 func NewAPI(
 	service ServiceInterface,
 	storer TenantStorerInterface,
 	sessionChecker SessionCheckerInterface,
+	flowFetcher FlowFetcherInterface,
+	clientFetcher ClientFetcherInterface,
 	baseURL string,
 	tracer tracing.TracingInterface,
+	monitor monitoring.MonitorInterface,
 	logger logging.LoggerInterface,
 ) *API {
 	return &API{
 		service:        service,
 		sessionChecker: sessionChecker,
+		flowFetcher:    flowFetcher,
+		clientFetcher:  clientFetcher,
 		storer:         storer,
 		baseURL:        baseURL,
 		tracer:         tracer,
+		monitor:        monitor,
 		logger:         logger,
 	}
 }
@@ -5,15 +5,21 @@ package tenants
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package tenants -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
@@ -33,7 +39,7 @@ func TestHandleLookupTenantsBySessionUnauthorized(t *testing.T) {
 	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(nil, nil, errors.New("no session"))
 
 	mux := chi.NewMux()
-	NewAPI(mockService, nil, mockSessionChecker, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, nil, mockSessionChecker, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants", nil)
 	rec := httptest.NewRecorder()
@@ -66,7 +72,7 @@ func TestHandleLookupTenantsBySessionSuccess(t *testing.T) {
 	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), identityID).Return(expected, nil)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, nil, mockSessionChecker, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, nil, mockSessionChecker, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants", nil)
 	rec := httptest.NewRecorder()
@@ -91,7 +97,7 @@ func TestHandleLookupTenantsSuccess(t *testing.T) {
 	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return(expected, nil)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, nil, nil, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants?flow="+flowID, nil)
 	rec := httptest.NewRecorder()
@@ -116,7 +122,7 @@ func TestHandleLookupTenantsServiceError(t *testing.T) {
 	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any())
 
 	mux := chi.NewMux()
-	NewAPI(mockService, nil, nil, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants?flow="+flowID, nil)
 	rec := httptest.NewRecorder()
@@ -127,6 +133,31 @@ func TestHandleLookupTenantsServiceError(t *testing.T) {
 	}
 }
 
+func TestHandleLookupTenantsServiceUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return(nil, fmt.Errorf("cannot lookup tenants by email: %w", ErrTenantServiceUnavailable))
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any())
+
+	mux := chi.NewMux()
+	NewAPI(mockService, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants?flow="+flowID, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
+
 func TestHandleTenantSelectionRejectsSentinel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -135,7 +166,7 @@ func TestHandleTenantSelectionRejectsSentinel(t *testing.T) {
 	mockTracer := NewMockTracingInterface(ctrl)
 
 	mux := chi.NewMux()
-	NewAPI(nil, nil, nil, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(nil, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	body, _ := json.Marshal(tenantSelectionRequest{
 		LoginChallenge: "lc-1",
@@ -160,6 +191,7 @@ func TestHandleTenantSelectionEmptyVerifiedNoTenants(t *testing.T) {
 	mockService := NewMockServiceInterface(ctrl)
 	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
 	mockStorer := NewMockTenantStorerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
 
 	identityID := "identity-123"
 	session := &kClient.Session{
@@ -174,8 +206,10 @@ func TestHandleTenantSelectionEmptyVerifiedNoTenants(t *testing.T) {
 	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), identityID).Return([]*Tenant{}, nil)
 	mockStorer.EXPECT().StoreTenant(gomock.Any(), gomock.Any(), "_none", "lc-1").Return(nil)
 
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventTenantSelection, "", "", monitoring.OutcomeSkipped), 1.0).Return(nil)
+
 	mux := chi.NewMux()
-	NewAPI(mockService, mockStorer, mockSessionChecker, "http://localhost", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, mockStorer, mockSessionChecker, nil, nil, "http://localhost", mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
 
 	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1"})
 	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
@@ -209,7 +243,7 @@ func TestHandleTenantSelectionEmptyRejectedWhenTenantsExist(t *testing.T) {
 	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), identityID).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil)
 
 	mux := chi.NewMux()
-	NewAPI(mockService, nil, mockSessionChecker, "", mockTracer, mockLogger).RegisterEndpoints(mux)
+	NewAPI(mockService, nil, mockSessionChecker, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
 
 	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1"})
 	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
@@ -221,3 +255,564 @@ func TestHandleTenantSelectionEmptyRejectedWhenTenantsExist(t *testing.T) {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestHandleTenantSelectionStoresMemberTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil)
+	mockStorer.EXPECT().StoreTenant(gomock.Any(), gomock.Any(), "t1", "lc-1").Return(nil)
+
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventTenantSelection, "", "", monitoring.OutcomeSuccess), 1.0).Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockStorer, nil, nil, nil, "http://localhost", mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t1", Flow: flowID})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	if resp["redirect_to"] != "http://localhost/ui/login?flow="+flowID {
+		t.Fatalf("unexpected redirect %s", resp["redirect_to"])
+	}
+}
+
+func TestHandleTenantSelectionInvalidatesCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	flowID := "flow-123"
+	cachedService := NewCachedService(mockService, mockFetcher, time.Minute, time.Second, 10, &noopTracer{}, mockMonitor, mockLogger)
+
+	// the first lookup validates the selection, the second one follows the
+	// invalidation and must reach the tenant service again
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), flowID, gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil).Times(3)
+	mockService.EXPECT().LookupTenantsByEmail(gomock.Any(), "user@example.com").Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil).Times(2)
+	mockMonitor.EXPECT().SetCacheLookupMetric(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	mockStorer.EXPECT().StoreTenant(gomock.Any(), gomock.Any(), "t1", "lc-1").Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(cachedService, mockStorer, nil, nil, nil, "http://localhost", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t1", Flow: flowID})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	if _, err := cachedService.LookupTenantsByFlow(context.Background(), flowID, nil); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+}
+
+func TestHandleTenantSelectionRejectsNonMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockSecurityLogger := NewMockSecurityLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+
+	identityID := "identity-123"
+	session := &kClient.Session{
+		Identity: &kClient.Identity{Id: identityID},
+	}
+
+	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), identityID).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil)
+	mockLogger.EXPECT().Security().Return(mockSecurityLogger)
+	mockSecurityLogger.EXPECT().AuthzFailure(identityID, "tenant:t2", gomock.Any(), gomock.Any())
+
+	mockMonitor.EXPECT().SetAuthEventMetric(monitoring.AuthEventTags(monitoring.EventTenantSelection, "", "", monitoring.OutcomeRejected), 1.0).Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockStorer, mockSessionChecker, nil, nil, "", mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t2"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSelectionRejectsDisabledTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockSecurityLogger := NewMockSecurityLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: false}}, nil)
+	mockLogger.EXPECT().Security().Return(mockSecurityLogger)
+	mockSecurityLogger.EXPECT().AuthzFailure("flow:"+flowID, "tenant:t1", gomock.Any(), gomock.Any())
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockStorer, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t1", Flow: flowID})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSelectionLookupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return(nil, errors.New("upstream error"))
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any())
+
+	mux := chi.NewMux()
+	NewAPI(mockService, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t1", Flow: flowID})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSelectionRemembersTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}, nil)
+	mockStorer.EXPECT().StoreTenant(gomock.Any(), gomock.Any(), "t2", "lc-1").Return(nil)
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), flowID, gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil)
+	mockStorer.EXPECT().RememberTenant(gomock.Any(), gomock.Any(), "user@example.com", "t2").Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockStorer, nil, mockFetcher, nil, "http://localhost", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t2", Flow: flowID, Remember: true})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSelectionRememberFailureIsNotFatal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	session := kClient.NewSession("s")
+	session.Identity = kClient.NewIdentity("identity-1", "default", "", map[string]interface{}{"email": "user@example.com"})
+
+	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil).Times(2)
+	mockService.EXPECT().LookupTenantsByIdentityID(gomock.Any(), "identity-1").Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil)
+	mockStorer.EXPECT().StoreTenant(gomock.Any(), gomock.Any(), "t1", "lc-1").Return(nil)
+	mockStorer.EXPECT().RememberTenant(gomock.Any(), gomock.Any(), "user@example.com", "t1").Return(errors.New("cannot encrypt"))
+	mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any())
+
+	mux := chi.NewMux()
+	NewAPI(mockService, mockStorer, mockSessionChecker, nil, nil, "http://localhost", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	body, _ := json.Marshal(tenantSelectionRequest{LoginChallenge: "lc-1", TenantID: "t1", Remember: true})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestHandleGetRememberedTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), flowID, gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil)
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}, nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, nil, nil, mockFetcher, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/auth/tenant/remembered?flow="+flowID, nil)
+	req = req.WithContext(contextWithRememberedTenant("user@example.com", "t2"))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp struct {
+		Tenant Tenant `json:"tenant"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+	if resp.Tenant.ID != "t2" {
+		t.Fatalf("expected remembered tenant t2, got %s", resp.Tenant.ID)
+	}
+}
+
+func TestHandleGetRememberedTenantNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), flowID, gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil).Times(2)
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return([]*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}, nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, nil, nil, mockFetcher, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	// nothing remembered, then a tenant the user is no longer a member of
+	for _, ctx := range []context.Context{context.Background(), contextWithRememberedTenant("user@example.com", "t2")} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/auth/tenant/remembered?flow="+flowID, nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404, got %d", rec.Code)
+		}
+	}
+}
+
+func TestHandleForgetTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockFetcher := NewMockFlowFetcherInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	flowID := "flow-123"
+
+	mockFetcher.EXPECT().GetLoginFlow(gomock.Any(), flowID, gomock.Any()).Return(buildFlowWithIdentifier("user@example.com"), nil, nil)
+	mockStorer.EXPECT().ForgetTenant(gomock.Any(), gomock.Any(), "user@example.com").Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(nil, mockStorer, nil, mockFetcher, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v0/auth/tenant/remembered?flow="+flowID, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+}
+
+func TestHandleForgetTenantUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(nil, nil, errors.New("no session"))
+
+	mux := chi.NewMux()
+	NewAPI(nil, mockStorer, mockSessionChecker, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v0/auth/tenant/remembered", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSwitch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+	mockClientFetcher := NewMockClientFetcherInterface(ctrl)
+	mockStorer := NewMockTenantStorerInterface(ctrl)
+
+	session := &kClient.Session{
+		Identity: &kClient.Identity{Id: "identity-1"},
+	}
+	client := hClient.NewOAuth2Client()
+	client.SetClientId("app")
+	client.SetMetadata(map[string]interface{}{InitiateLoginURIMetadataKey: "https://app.example.com/login"})
+
+	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(session, nil, nil)
+	mockClientFetcher.EXPECT().GetOAuth2Client(gomock.Any(), "app").Return(client, nil)
+	mockStorer.EXPECT().StartTenantSwitch(gomock.Any(), gomock.Any()).Return(nil)
+
+	mux := chi.NewMux()
+	NewAPI(nil, mockStorer, mockSessionChecker, nil, mockClientFetcher, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant/switch", bytes.NewReader([]byte(`{"client_id":"app"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["redirect_to"] != "https://app.example.com/login" {
+		t.Fatalf("unexpected redirect %q", resp["redirect_to"])
+	}
+}
+
+func TestHandleTenantSwitchRejectsCrossSiteRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{name: "GET", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "form POST", method: http.MethodPost, contentType: "application/x-www-form-urlencoded", body: "client_id=app", status: http.StatusUnsupportedMediaType},
+		{name: "text POST", method: http.MethodPost, contentType: "text/plain", body: `{"client_id":"app"}`, status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mux := chi.NewMux()
+			NewAPI(nil, NewMockTenantStorerInterface(ctrl), NewMockSessionCheckerInterface(ctrl), nil, NewMockClientFetcherInterface(ctrl), "", NewMockTracingInterface(ctrl), monitoring.NewNoopMonitor("", nil), NewMockLoggerInterface(ctrl)).RegisterEndpoints(mux)
+
+			req := httptest.NewRequest(test.method, "/api/v0/auth/tenant/switch", bytes.NewReader([]byte(test.body)))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Fatalf("expected %d, got %d", test.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleTenantSwitchUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+
+	mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(nil, nil, errors.New("no session"))
+
+	mux := chi.NewMux()
+	NewAPI(nil, NewMockTenantStorerInterface(ctrl), mockSessionChecker, nil, NewMockClientFetcherInterface(ctrl), "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant/switch", bytes.NewReader([]byte(`{"client_id":"app"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHandleTenantSwitchRejectsClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+	}{
+		{name: "no initiate login URI", metadata: map[string]interface{}{}},
+		{name: "script URI", metadata: map[string]interface{}{InitiateLoginURIMetadataKey: "javascript:alert(1)"}},
+		{name: "relative URI", metadata: map[string]interface{}{InitiateLoginURIMetadataKey: "/login"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockSessionChecker := NewMockSessionCheckerInterface(ctrl)
+			mockClientFetcher := NewMockClientFetcherInterface(ctrl)
+
+			client := hClient.NewOAuth2Client()
+			client.SetClientId("app")
+			client.SetMetadata(test.metadata)
+
+			mockSessionChecker.EXPECT().CheckSession(gomock.Any(), gomock.Any()).Return(&kClient.Session{Identity: &kClient.Identity{Id: "identity-1"}}, nil, nil)
+			mockClientFetcher.EXPECT().GetOAuth2Client(gomock.Any(), "app").Return(client, nil)
+			mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any())
+
+			mux := chi.NewMux()
+			NewAPI(nil, NewMockTenantStorerInterface(ctrl), mockSessionChecker, nil, mockClientFetcher, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/auth/tenant/switch", bytes.NewReader([]byte(`{"client_id":"app"}`)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleLookupTenantsFlagsAndSortsTenants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	flowID := "flow-123"
+	tenants := []*Tenant{
+		{ID: "t1", Name: "zeta", Enabled: true},
+		{ID: "t2", Name: "Acme", Enabled: false},
+		{ID: "t3", Name: "beta", Enabled: true},
+	}
+
+	mockService.EXPECT().LookupTenantsByFlow(gomock.Any(), flowID, gomock.Any()).Return(tenants, nil)
+
+	mux := chi.NewMux()
+	NewAPI(mockService, nil, nil, nil, nil, "", mockTracer, monitoring.NewNoopMonitor("", nil), mockLogger).RegisterEndpoints(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/tenants?flow="+flowID, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		Tenants []struct {
+			ID                string `json:"id"`
+			Selectable        bool   `json:"selectable"`
+			UnavailableReason string `json:"unavailable_reason"`
+			DisplayOrder      int    `json:"display_order"`
+		} `json:"tenants"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+
+	// selectable tenants come first, sorted by name regardless of case
+	expected := []struct {
+		id         string
+		selectable bool
+		reason     string
+	}{
+		{id: "t3", selectable: true},
+		{id: "t1", selectable: true},
+		{id: "t2", selectable: false, reason: TenantUnavailableDisabled},
+	}
+	if len(body.Tenants) != len(expected) {
+		t.Fatalf("expected %d tenants, got %+v", len(expected), body.Tenants)
+	}
+	for i, e := range expected {
+		got := body.Tenants[i]
+		if got.ID != e.id || got.Selectable != e.selectable || got.UnavailableReason != e.reason || got.DisplayOrder != i {
+			t.Fatalf("unexpected tenant at %d: %+v", i, got)
+		}
+	}
+
+	// the tenants returned by the service are left untouched
+	if tenants[0].ID != "t1" {
+		t.Fatal("expected the service tenants not to be reordered")
+	}
+}
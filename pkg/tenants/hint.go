@@ -0,0 +1,64 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"net/url"
+
+	hClient "github.com/ory/hydra-client-go/v2"
+)
+
+const (
+	// TenantHintParam is the authorization request parameter a relying party
+	// sets to ask for a tenant, it is read from the Hydra request_url.
+	TenantHintParam = "tenant"
+	// DefaultTenantMetadataKey is the OAuth2 client metadata key holding the
+	// tenant to use when the authorization request does not ask for one.
+	DefaultTenantMetadataKey = "default_tenant"
+
+	// TenantHintNotMember is reported when the user is not a member of the
+	// hinted tenant.
+	TenantHintNotMember = "tenant_hint_not_member"
+	// TenantHintDisabled is reported when the hinted tenant is disabled.
+	TenantHintDisabled = "tenant_hint_disabled"
+)
+
+// tenantHintFromLoginRequest returns the tenant requested by the relying
+// party, the tenant parameter of the authorization request takes precedence
+// over the default tenant of the client.
+func tenantHintFromLoginRequest(loginRequest *hClient.OAuth2LoginRequest) string {
+	if loginRequest == nil {
+		return ""
+	}
+
+	if u, err := url.Parse(loginRequest.GetRequestUrl()); err == nil {
+		if hint := u.Query().Get(TenantHintParam); hint != "" {
+			return hint
+		}
+	}
+
+	client := loginRequest.GetClient()
+	metadata, ok := client.GetMetadata().(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	hint, _ := metadata[DefaultTenantMetadataKey].(string)
+	return hint
+}
+
+// checkTenantHint validates the hinted tenant against the user's tenants, it
+// returns the tenant to select or the reason the hint is rejected.
+func checkTenantHint(tenants []*Tenant, hint string) (*Tenant, string) {
+	t := findTenant(tenants, hint)
+	if t == nil {
+		return nil, TenantHintNotMember
+	}
+
+	if !t.Enabled {
+		return nil, TenantHintDisabled
+	}
+
+	return t, ""
+}
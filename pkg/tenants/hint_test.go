@@ -0,0 +1,74 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"testing"
+
+	hClient "github.com/ory/hydra-client-go/v2"
+)
+
+func loginRequestWithHint(requestURL string, metadata interface{}) *hClient.OAuth2LoginRequest {
+	client := hClient.NewOAuth2Client()
+	client.SetMetadata(metadata)
+	return hClient.NewOAuth2LoginRequest("challenge", *client, requestURL, false, "subject")
+}
+
+func TestTenantHintFromLoginRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		loginRequest *hClient.OAuth2LoginRequest
+		expected     string
+	}{
+		{
+			name:         "request parameter",
+			loginRequest: loginRequestWithHint("https://hydra/oauth2/auth?client_id=app&tenant=t1", nil),
+			expected:     "t1",
+		},
+		{
+			name:         "client default tenant",
+			loginRequest: loginRequestWithHint("https://hydra/oauth2/auth?client_id=app", map[string]interface{}{"default_tenant": "t2"}),
+			expected:     "t2",
+		},
+		{
+			name:         "request parameter takes precedence",
+			loginRequest: loginRequestWithHint("https://hydra/oauth2/auth?tenant=t1", map[string]interface{}{"default_tenant": "t2"}),
+			expected:     "t1",
+		},
+		{
+			name:         "no hint",
+			loginRequest: loginRequestWithHint("https://hydra/oauth2/auth?client_id=app", map[string]interface{}{"other": "t2"}),
+			expected:     "",
+		},
+		{
+			name:         "no login request",
+			loginRequest: nil,
+			expected:     "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if hint := tenantHintFromLoginRequest(test.loginRequest); hint != test.expected {
+				t.Fatalf("expected hint %q, got %q", test.expected, hint)
+			}
+		})
+	}
+}
+
+func TestCheckTenantHint(t *testing.T) {
+	tenants := []*Tenant{{ID: "t1", Enabled: true}, {ID: "t2", Enabled: false}}
+
+	if selected, reason := checkTenantHint(tenants, "t1"); selected == nil || selected.ID != "t1" || reason != "" {
+		t.Fatalf("expected t1 to be selected, got %v %q", selected, reason)
+	}
+
+	if selected, reason := checkTenantHint(tenants, "t2"); selected != nil || reason != TenantHintDisabled {
+		t.Fatalf("expected disabled tenant to be rejected, got %v %q", selected, reason)
+	}
+
+	if selected, reason := checkTenantHint(tenants, "t3"); selected != nil || reason != TenantHintNotMember {
+		t.Fatalf("expected unknown tenant to be rejected, got %v %q", selected, reason)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"net/http"
 
+	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 	"google.golang.org/grpc"
 
@@ -27,12 +28,18 @@ type TenantServiceClientInterface interface {
 type CookieManagerInterface interface {
 	SetStateCookie(http.ResponseWriter, cookies.FlowStateCookie) error
 	GetStateCookie(*http.Request) (cookies.FlowStateCookie, error)
+	SetTenantPreferenceCookie(http.ResponseWriter, cookies.TenantPreferenceCookie) error
+	GetTenantPreferenceCookie(*http.Request) (cookies.TenantPreferenceCookie, error)
 }
 
-// TenantStorerInterface is the subset of TenantResolverInterface needed by the
-// tenant-redirect handler to persist the user's tenant selection.
+// TenantStorerInterface is the subset of CookieTenantResolver needed by the
+// tenant-redirect handler to persist the user's tenant selection, to
+// remember or forget it across logins, and to start a tenant switch.
 type TenantStorerInterface interface {
 	StoreTenant(w http.ResponseWriter, r *http.Request, tenantID, loginChallenge string) error
+	RememberTenant(w http.ResponseWriter, r *http.Request, email, tenantID string) error
+	ForgetTenant(w http.ResponseWriter, r *http.Request, email string) error
+	StartTenantSwitch(w http.ResponseWriter, r *http.Request) error
 }
 
 type ServiceInterface interface {
@@ -41,12 +48,31 @@ type ServiceInterface interface {
 	LookupTenantsByIdentityID(ctx context.Context, identityID string) ([]*Tenant, error)
 }
 
+// TenantCacheInterface is implemented by ServiceInterface decorators caching
+// tenant lookups, it allows dropping the cached tenants of a user.
+type TenantCacheInterface interface {
+	InvalidateByFlow(ctx context.Context, flowID string, cookies []*http.Cookie) error
+	InvalidateByIdentityID(ctx context.Context, identityID string)
+}
+
 // FlowFetcherInterface is the subset of kratos.ServiceInterface needed by the
 // service to retrieve a login flow and extract the email.
 type FlowFetcherInterface interface {
 	GetLoginFlow(ctx context.Context, id string, cookies []*http.Cookie) (*kClient.LoginFlow, []*http.Cookie, error)
 }
 
+// ClientFetcherInterface is the subset of kratos.ServiceInterface needed by
+// the tenant switch handler to read the registration of an OAuth2 client.
+type ClientFetcherInterface interface {
+	GetOAuth2Client(ctx context.Context, clientID string) (*hClient.OAuth2Client, error)
+}
+
+// AppConfigSourceInterface provides the branding and settings of a tenant,
+// nil is returned for a tenant without configuration.
+type AppConfigSourceInterface interface {
+	AppConfig(ctx context.Context, tenantID string) (*AppConfig, error)
+}
+
 // SessionCheckerInterface is the subset of kratos.ServiceInterface needed to
 // verify the caller's Kratos session.
 type SessionCheckerInterface interface {
@@ -0,0 +1,60 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+)
+
+type tenantPreferenceKey struct{}
+
+// Middleware exposes the tenants remembered in the browser to the resolver,
+// which only receives the request context.
+type Middleware struct {
+	cookieManager CookieManagerInterface
+
+	logger logging.LoggerInterface
+}
+
+// TenantPreference reads the remembered tenants cookie and stores it in the
+// request context, an unreadable cookie is ignored.
+func (mdw *Middleware) TenantPreference() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				preference, err := mdw.cookieManager.GetTenantPreferenceCookie(r)
+				if err != nil {
+					mdw.logger.Debugf("ignoring unreadable tenant preference cookie: %v", err)
+				}
+
+				if len(preference.Tenants) > 0 {
+					r = r.WithContext(context.WithValue(r.Context(), tenantPreferenceKey{}, preference))
+				}
+
+				next.ServeHTTP(w, r)
+			},
+		)
+	}
+}
+
+// tenantPreferenceFromContext returns the remembered tenants stored by the
+// TenantPreference middleware, an empty value when there are none.
+func tenantPreferenceFromContext(ctx context.Context) cookies.TenantPreferenceCookie {
+	preference, _ := ctx.Value(tenantPreferenceKey{}).(cookies.TenantPreferenceCookie)
+	return preference
+}
+
+// NewMiddleware returns a Middleware reading cookies with the cookie manager
+func NewMiddleware(cookieManager CookieManagerInterface, logger logging.LoggerInterface) *Middleware {
+	mdw := new(Middleware)
+
+	mdw.cookieManager = cookieManager
+	mdw.logger = logger
+
+	return mdw
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+)
+
+func TestMiddlewareTenantPreference(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	mockLogger := NewMockLoggerInterface(ctrl)
+	preference := cookies.TenantPreferenceCookie{}.Remember("u@e.com", "t1")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	mockCM.EXPECT().GetTenantPreferenceCookie(req).Return(preference, nil)
+
+	var got cookies.TenantPreferenceCookie
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = tenantPreferenceFromContext(r.Context())
+	})
+
+	NewMiddleware(mockCM, mockLogger).TenantPreference()(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.TenantID("u@e.com") != "t1" {
+		t.Fatalf("expected remembered tenant in the request context, got %+v", got)
+	}
+}
+
+func TestMiddlewareTenantPreferenceIgnoresUnreadableCookie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	mockLogger := NewMockLoggerInterface(ctrl)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	mockCM.EXPECT().GetTenantPreferenceCookie(req).Return(cookies.TenantPreferenceCookie{}, fmt.Errorf("cannot decrypt"))
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).Times(1)
+
+	called := false
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		called = true
+		if len(tenantPreferenceFromContext(r.Context()).Tenants) != 0 {
+			t.Fatal("expected no remembered tenants")
+		}
+	})
+
+	NewMiddleware(mockCM, mockLogger).TenantPreference()(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	if !called {
+		t.Fatal("expected the request to be served")
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	tenant "github.com/canonical/identity-platform-api/v0/tenant"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+	"github.com/canonical/identity-platform-login-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-login-ui/internal/tracing"
+)
+
+// ErrTenantServiceUnavailable is returned when the tenant service could not be
+// reached, either because every attempt failed with a transient error or
+// because the circuit breaker is open.
+var ErrTenantServiceUnavailable = errors.New("tenant service unavailable")
+
+const (
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+
+	tenantServiceClientName = "tenant-service"
+)
+
+// RetryPolicy configures how idempotent tenant-service calls are retried.
+// Attempts are spaced by an exponential backoff with jitter, starting at
+// InitialBackoff and capped at MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker opens after threshold consecutive failed calls and rejects
+// calls for cooldown. A single probe call is then let through, its outcome
+// closes the breaker or opens it again. A threshold of 0 disables it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func (b *circuitBreaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// a probe is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a call let through by allow.
+// Calls cancelled by the caller say nothing about the tenant service, a probe
+// cancelled this way lets the next call probe again.
+func (b *circuitBreaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case status.Code(err) == codes.Canceled || errors.Is(err, context.Canceled):
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+	case isTransient(err):
+		b.failures++
+		if b.state == breakerHalfOpen || b.failures >= b.threshold {
+			b.state = breakerOpen
+			b.openedAt = b.now()
+		}
+	default:
+		b.state = breakerClosed
+		b.failures = 0
+	}
+}
+
+// isTransient reports whether err is a gRPC error worth retrying, LookupTenants
+// is idempotent so retrying never has side effects.
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// ResilientClient decorates a TenantServiceClientInterface with retries of
+// transient failures and a circuit breaker, and records the retries and the
+// calls rejected by the breaker. Every attempt is recorded by the interceptor
+// of the gRPC connection, see NewGRPCConn.
+//
+// Failures caused by the tenant service being unreachable wrap
+// ErrTenantServiceUnavailable so callers can apply their fail-open policy.
+type ResilientClient struct {
+	client  TenantServiceClientInterface
+	retry   RetryPolicy
+	breaker *circuitBreaker
+	sleep   func(context.Context, time.Duration) error
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (c *ResilientClient) LookupTenants(ctx context.Context, in *tenant.LookupTenantsRequest, opts ...grpc.CallOption) (*tenant.LookupTenantsResponse, error) {
+	ctx, span := c.tracer.Start(ctx, "tenants.ResilientClient.LookupTenants")
+	defer span.End()
+
+	if !c.breaker.allow() {
+		c.observeCircuitOpen("LookupTenants")
+
+		err := fmt.Errorf("circuit breaker is open: %w", ErrTenantServiceUnavailable)
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return nil, err
+	}
+
+	var (
+		resp    *tenant.LookupTenantsResponse
+		err     error
+		attempt int
+	)
+
+	for attempt = 1; ; attempt++ {
+		resp, err = c.client.LookupTenants(ctx, in, opts...)
+		if err == nil || !isTransient(err) || attempt >= c.retry.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		backoff := c.backoff(attempt)
+		c.logger.Debugf("tenant service call failed on attempt %d/%d, retrying in %s: %v", attempt, c.retry.MaxAttempts, backoff, err)
+
+		if c.sleep(ctx, backoff) != nil {
+			break
+		}
+
+		c.observeRetry("LookupTenants")
+	}
+
+	c.breaker.record(err)
+	span.SetAttributes(attribute.Int("tenants.attempts", attempt))
+
+	if err != nil {
+		if isTransient(err) {
+			err = fmt.Errorf("%w: %w", ErrTenantServiceUnavailable, err)
+		}
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetStatus(otelcodes.Ok, "")
+	return resp, nil
+}
+
+// backoff returns the delay before the attempt following attempt, half of it
+// is randomised so that clients do not retry in lockstep.
+func (c *ResilientClient) backoff(attempt int) time.Duration {
+	d := c.retry.InitialBackoff
+	for i := 1; i < attempt && d < c.retry.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, c.retry.MaxBackoff)
+
+	return d/2 + rand.N(d/2+1)
+}
+
+func (c *ResilientClient) observeRetry(method string) {
+	tags := map[string]string{
+		"client": tenantServiceClientName,
+		"method": method,
+	}
+
+	if err := c.monitor.SetOutboundRetryMetric(tags, 1); err != nil {
+		c.logger.Debugf("cannot record tenant service retry metric: %v", err)
+	}
+}
+
+func (c *ResilientClient) observeCircuitOpen(method string) {
+	tags := map[string]string{
+		"client": tenantServiceClientName,
+		"method": method,
+		"class":  monitoring.StatusClassCircuitOpen,
+	}
+
+	if err := c.monitor.SetOutboundStatusMetric(tags, 1); err != nil {
+		c.logger.Debugf("cannot record tenant service call metric: %v", err)
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NewResilientClient wraps client with the given retry policy and a circuit
+// breaker opening after breakerThreshold consecutive failures for
+// breakerCooldown. A breakerThreshold of 0 disables the circuit breaker.
+func NewResilientClient(client TenantServiceClientInterface, retry RetryPolicy, breakerThreshold int, breakerCooldown time.Duration, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *ResilientClient {
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+	if retry.InitialBackoff <= 0 {
+		retry.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = defaultRetryMaxBackoff
+	}
+	retry.MaxBackoff = max(retry.MaxBackoff, retry.InitialBackoff)
+
+	return &ResilientClient{
+		client: client,
+		retry:  retry,
+		breaker: &circuitBreaker{
+			threshold: breakerThreshold,
+			cooldown:  breakerCooldown,
+			now:       time.Now,
+		},
+		sleep:   sleep,
+		tracer:  tracer,
+		monitor: monitor,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tenant "github.com/canonical/identity-platform-api/v0/tenant"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/canonical/identity-platform-login-ui/internal/misc/clock"
+)
+
+func TestResilientClientRetries(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name              string
+		errs              []error
+		expectedRetries   int
+		expectedErr       bool
+		expectUnavailable bool
+	}{
+		{
+			name:            "transient errors are retried",
+			errs:            []error{unavailable, status.Error(codes.ResourceExhausted, "throttled"), nil},
+			expectedRetries: 2,
+		},
+		{
+			name:        "permanent errors are not retried",
+			errs:        []error{status.Error(codes.InvalidArgument, "bad email")},
+			expectedErr: true,
+		},
+		{
+			name:              "retries are exhausted",
+			errs:              []error{unavailable, unavailable, unavailable},
+			expectedRetries:   2,
+			expectedErr:       true,
+			expectUnavailable: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient := NewMockTenantServiceClientInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			fakeClock := clock.NewFake()
+
+			c := NewResilientClient(mockClient, RetryPolicy{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}, 5, 30*time.Second, &noopTracer{}, mockMonitor, mockLogger)
+			c.breaker.now = fakeClock.Now
+			c.sleep = fakeClock.Sleep
+
+			calls := make([]any, 0, len(test.errs))
+			for _, err := range test.errs {
+				resp := &tenant.LookupTenantsResponse{}
+				if err != nil {
+					resp = nil
+				}
+				calls = append(calls, mockClient.EXPECT().LookupTenants(gomock.Any(), gomock.Any()).Return(resp, err))
+			}
+			gomock.InOrder(calls...)
+
+			mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockMonitor.EXPECT().SetOutboundRetryMetric(map[string]string{"client": "tenant-service", "method": "LookupTenants"}, 1.0).Return(nil).Times(test.expectedRetries)
+
+			_, err := c.LookupTenants(context.Background(), &tenant.LookupTenantsRequest{Email: "user@example.com"})
+			if (err != nil) != test.expectedErr {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if errors.Is(err, ErrTenantServiceUnavailable) != test.expectUnavailable {
+				t.Fatalf("expected unavailable %v, got %v", test.expectUnavailable, err)
+			}
+			if test.expectUnavailable && status.Code(err) != codes.Unavailable {
+				t.Fatalf("expected the gRPC code to be preserved, got %v", status.Code(err))
+			}
+
+			sleeps := fakeClock.Sleeps()
+			if len(sleeps) != test.expectedRetries {
+				t.Fatalf("expected %d backoffs, got %v", test.expectedRetries, sleeps)
+			}
+			// each backoff doubles the previous one, half of it is jitter
+			for i, d := range sleeps {
+				backoff := min(100*time.Millisecond<<i, 300*time.Millisecond)
+				if d < backoff/2 || d > backoff {
+					t.Fatalf("unexpected backoff %d: %s", i, d)
+				}
+			}
+		})
+	}
+}
+
+func TestResilientClientStopsRetryingWhenContextIsDone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockTenantServiceClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	fakeClock := clock.NewFake()
+
+	c := NewResilientClient(mockClient, RetryPolicy{MaxAttempts: 3}, 5, 30*time.Second, &noopTracer{}, mockMonitor, NewMockLoggerInterface(ctrl))
+	c.sleep = fakeClock.Sleep
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient.EXPECT().LookupTenants(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *tenant.LookupTenantsRequest, ...grpc.CallOption) (*tenant.LookupTenantsResponse, error) {
+			cancel()
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		},
+	).Times(1)
+
+	if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := NewMockTenantServiceClientInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	fakeClock := clock.NewFake()
+
+	c := NewResilientClient(mockClient, RetryPolicy{MaxAttempts: 1}, 2, 30*time.Second, &noopTracer{}, mockMonitor, NewMockLoggerInterface(ctrl))
+	c.breaker.now = fakeClock.Now
+
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	// two consecutive failures open the breaker
+	mockClient.EXPECT().LookupTenants(gomock.Any(), gomock.Any()).Return(nil, unavailable).Times(2)
+	for i := 0; i < 2; i++ {
+		if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	mockMonitor.EXPECT().SetOutboundStatusMetric(map[string]string{"client": "tenant-service", "method": "LookupTenants", "class": "circuit_open"}, 1.0).Return(nil)
+	if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); !errors.Is(err, ErrTenantServiceUnavailable) {
+		t.Fatalf("expected ErrTenantServiceUnavailable, got %v", err)
+	}
+
+	// a failed probe after the cooldown opens the breaker again
+	fakeClock.Advance(30 * time.Second)
+	mockClient.EXPECT().LookupTenants(gomock.Any(), gomock.Any()).Return(nil, unavailable).Times(1)
+	if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	mockMonitor.EXPECT().SetOutboundStatusMetric(map[string]string{"client": "tenant-service", "method": "LookupTenants", "class": "circuit_open"}, 1.0).Return(nil)
+	if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// a successful probe closes it
+	fakeClock.Advance(30 * time.Second)
+	mockClient.EXPECT().LookupTenants(gomock.Any(), gomock.Any()).Return(&tenant.LookupTenantsResponse{}, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		if _, err := c.LookupTenants(ctx, &tenant.LookupTenantsRequest{}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	fakeClock := clock.NewFake()
+	b := &circuitBreaker{threshold: 1, cooldown: time.Second, now: fakeClock.Now}
+
+	b.record(status.Error(codes.Unavailable, "unavailable"))
+	if b.allow() {
+		t.Fatal("expected the breaker to be open")
+	}
+
+	fakeClock.Advance(time.Second)
+	if !b.allow() {
+		t.Fatal("expected a probe to be allowed")
+	}
+	if b.allow() {
+		t.Fatal("expected a single probe to be allowed")
+	}
+
+	// a cancelled probe lets the next call probe again
+	b.record(context.Canceled)
+	if !b.allow() {
+		t.Fatal("expected a new probe to be allowed")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := &circuitBreaker{threshold: 0, now: time.Now}
+
+	for i := 0; i < 10; i++ {
+		b.record(status.Error(codes.Unavailable, "unavailable"))
+	}
+	if !b.allow() {
+		t.Fatal("expected a disabled breaker to allow every call")
+	}
+}
+
+func TestNewResilientClientDefaults(t *testing.T) {
+	c := NewResilientClient(nil, RetryPolicy{MaxBackoff: time.Millisecond}, 0, 0, nil, nil, nil)
+
+	if c.retry.MaxAttempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", c.retry.MaxAttempts)
+	}
+	if c.retry.InitialBackoff != defaultRetryInitialBackoff {
+		t.Fatalf("expected initial backoff %s, got %s", defaultRetryInitialBackoff, c.retry.InitialBackoff)
+	}
+	if c.retry.MaxBackoff != defaultRetryInitialBackoff {
+		t.Fatalf("expected max backoff not below the initial one, got %s", c.retry.MaxBackoff)
+	}
+}
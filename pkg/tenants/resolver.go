@@ -5,12 +5,15 @@ package tenants
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
+	hClient "github.com/ory/hydra-client-go/v2"
 	kClient "github.com/ory/kratos-client-go/v25"
 
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
 )
 
 // tenantLookupService is the subset of ServiceInterface needed to check
@@ -67,28 +70,37 @@ func (n *NoOpTenantResolver) IsAuthenticatedForChallenge(_ cookies.FlowStateCook
 	return true
 }
 
-func (n *NoOpTenantResolver) NeedsTenantSelection(_ context.Context, _ *kClient.Session, c cookies.FlowStateCookie, _ string) (bool, cookies.FlowStateCookie, error) {
+func (n *NoOpTenantResolver) NeedsTenantSelection(_ context.Context, _ *kClient.Session, c cookies.FlowStateCookie, _ string, _ *hClient.OAuth2LoginRequest) (bool, cookies.FlowStateCookie, error) {
 	return false, c, nil
 }
 
-func (n *NoOpTenantResolver) NeedsTenantSelectionByEmail(_ context.Context, _ string, c cookies.FlowStateCookie, _ string) (bool, cookies.FlowStateCookie, error) {
+func (n *NoOpTenantResolver) NeedsTenantSelectionByEmail(_ context.Context, _ string, c cookies.FlowStateCookie, _ string, _ *hClient.OAuth2LoginRequest) (bool, cookies.FlowStateCookie, error) {
 	return false, c, nil
 }
 
-func (n *NoOpTenantResolver) InterceptLogin(_ context.Context, _ *kClient.Session, cookie cookies.FlowStateCookie, _ string) (LoginInterception, error) {
+func (n *NoOpTenantResolver) InterceptLogin(_ context.Context, _ *kClient.Session, cookie cookies.FlowStateCookie, _ string, _ *hClient.OAuth2LoginRequest) (LoginInterception, error) {
 	return LoginInterception{Cookie: cookie}, nil
 }
 
 // CookieTenantResolver stores and retrieves the selected tenant from the
 // encrypted FlowStateCookie. The selection is bound to a login challenge via
 // LoginChallengeHash so it cannot be replayed across different challenges.
+//
+// The tenant hinted by the relying party in the Hydra login request passed by
+// the caller is selected, provided the user is a member of it.
+//
+// When failOpen is set a login goes on without a tenant while the tenant
+// service is unavailable, otherwise the lookup error is returned.
 type CookieTenantResolver struct {
 	cookieManager CookieManagerInterface
 	service       tenantLookupService
+	failOpen      bool
+
+	logger logging.LoggerInterface
 }
 
-func NewCookieTenantResolver(cm CookieManagerInterface, svc tenantLookupService) *CookieTenantResolver {
-	return &CookieTenantResolver{cookieManager: cm, service: svc}
+func NewCookieTenantResolver(cm CookieManagerInterface, svc tenantLookupService, failOpen bool, logger logging.LoggerInterface) *CookieTenantResolver {
+	return &CookieTenantResolver{cookieManager: cm, service: svc, failOpen: failOpen, logger: logger}
 }
 
 func (c *CookieTenantResolver) Enabled() bool { return true }
@@ -107,6 +119,8 @@ func (c *CookieTenantResolver) StoreTenant(w http.ResponseWriter, r *http.Reques
 	}
 	stateCookie.TenantID = tenantID
 	stateCookie.LoginChallengeHash = cookies.ChallengeHash(loginChallenge)
+	// the tenant switch is complete once a tenant is picked
+	stateCookie.TenantSwitch = false
 	return c.cookieManager.SetStateCookie(w, stateCookie)
 }
 
@@ -116,6 +130,9 @@ func (c *CookieTenantResolver) HasTenants(ctx context.Context, session *kClient.
 		return false, nil
 	}
 	tenants, err := c.service.LookupTenantsByIdentityID(ctx, identityID)
+	if err != nil && c.unavailable(err) {
+		return false, nil
+	}
 	if err != nil {
 		return false, fmt.Errorf("cannot look up tenants: %w", err)
 	}
@@ -126,15 +143,15 @@ func (c *CookieTenantResolver) IsAuthenticatedForChallenge(cookie cookies.FlowSt
 	return loginChallenge == "" || cookie.LoginChallengeHash == cookies.ChallengeHash(loginChallenge)
 }
 
-func (c *CookieTenantResolver) NeedsTenantSelection(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string) (bool, cookies.FlowStateCookie, error) {
+func (c *CookieTenantResolver) NeedsTenantSelection(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (bool, cookies.FlowStateCookie, error) {
 	identityID := identityIDFromSession(session)
 	if identityID != "" {
-		return c.needsTenantSelectionByIdentityID(ctx, identityID, cookie, loginChallenge)
+		return c.needsTenantSelectionByIdentityID(ctx, identityID, emailFromSession(session), cookie, loginChallenge, loginRequest)
 	}
-	return c.NeedsTenantSelectionByEmail(ctx, emailFromSession(session), cookie, loginChallenge)
+	return c.NeedsTenantSelectionByEmail(ctx, emailFromSession(session), cookie, loginChallenge, loginRequest)
 }
 
-func (c *CookieTenantResolver) NeedsTenantSelectionByEmail(ctx context.Context, email string, cookie cookies.FlowStateCookie, loginChallenge string) (bool, cookies.FlowStateCookie, error) {
+func (c *CookieTenantResolver) NeedsTenantSelectionByEmail(ctx context.Context, email string, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (bool, cookies.FlowStateCookie, error) {
 	if c.TenantID(cookie, loginChallenge) != "" {
 		return false, cookie, nil
 	}
@@ -142,43 +159,110 @@ func (c *CookieTenantResolver) NeedsTenantSelectionByEmail(ctx context.Context,
 		return false, cookie, nil
 	}
 	tenants, err := c.service.LookupTenantsByEmail(ctx, email)
-	if err != nil {
-		return false, cookie, fmt.Errorf("cannot look up tenants: %w", err)
-	}
-	if len(tenants) == 1 {
-		cookie.TenantID = tenants[0].ID
+	if err != nil && c.unavailable(err) {
+		cookie.TenantID = cookies.NoTenantAvailable
 		return false, cookie, nil
 	}
-	if len(tenants) > 1 {
-		return true, cookie, nil
+	if err != nil {
+		return false, cookie, fmt.Errorf("cannot look up tenants: %w", err)
 	}
-	cookie.TenantID = cookies.NoTenantAvailable
-	return false, cookie, nil
+	return c.selectTenant(ctx, email, loginRequest, tenants, cookie)
 }
 
 // needsTenantSelectionByIdentityID is the identity_id-based equivalent of
 // NeedsTenantSelectionByEmail. It skips the Kratos email resolution on the
 // tenant-service side, resulting in faster lookups.
-func (c *CookieTenantResolver) needsTenantSelectionByIdentityID(ctx context.Context, identityID string, cookie cookies.FlowStateCookie, loginChallenge string) (bool, cookies.FlowStateCookie, error) {
+func (c *CookieTenantResolver) needsTenantSelectionByIdentityID(ctx context.Context, identityID, email string, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (bool, cookies.FlowStateCookie, error) {
 	if c.TenantID(cookie, loginChallenge) != "" {
 		return false, cookie, nil
 	}
 	tenants, err := c.service.LookupTenantsByIdentityID(ctx, identityID)
+	if err != nil && c.unavailable(err) {
+		cookie.TenantID = cookies.NoTenantAvailable
+		return false, cookie, nil
+	}
 	if err != nil {
 		return false, cookie, fmt.Errorf("cannot look up tenants: %w", err)
 	}
+	return c.selectTenant(ctx, email, loginRequest, tenants, cookie)
+}
+
+// selectTenant picks the tenant hinted by the relying party, the only tenant
+// of the user, or the tenant the user remembered when they are still a member
+// of it. It returns true when the user has to pick one, a rejected hint is
+// reported in the TenantHintError of the cookie. During a tenant switch a
+// tenant is only picked when it is the only one of the user.
+func (c *CookieTenantResolver) selectTenant(ctx context.Context, email string, loginRequest *hClient.OAuth2LoginRequest, tenants []*Tenant, cookie cookies.FlowStateCookie) (bool, cookies.FlowStateCookie, error) {
+	if len(tenants) == 0 {
+		cookie.TenantID = cookies.NoTenantAvailable
+		return false, cookie, nil
+	}
+
+	// a user switching tenant picks it themselves, neither the hinted nor
+	// the remembered tenant is picked on their behalf
+	if !cookie.TenantSwitch {
+		if hint := tenantHintFromLoginRequest(loginRequest); hint != "" {
+			hinted, reason := checkTenantHint(tenants, hint)
+			if hinted == nil {
+				cookie.TenantHintError = reason
+				return true, cookie, nil
+			}
+			cookie.TenantID = hinted.ID
+			return false, cookie, nil
+		}
+	}
+
 	if len(tenants) == 1 {
 		cookie.TenantID = tenants[0].ID
 		return false, cookie, nil
 	}
-	if len(tenants) > 1 {
+	if cookie.TenantSwitch {
 		return true, cookie, nil
 	}
-	cookie.TenantID = cookies.NoTenantAvailable
-	return false, cookie, nil
+	if remembered := findTenant(tenants, tenantPreferenceFromContext(ctx).TenantID(email)); remembered != nil && remembered.Enabled {
+		cookie.TenantID = remembered.ID
+		return false, cookie, nil
+	}
+	return true, cookie, nil
+}
+
+// unavailable reports whether the failed lookup should be ignored under the
+// fail-open policy, the user then logs in without a tenant.
+func (c *CookieTenantResolver) unavailable(err error) bool {
+	if !c.failOpen || !errors.Is(err, ErrTenantServiceUnavailable) {
+		return false
+	}
+	c.logger.Warnf("tenant service unavailable, continuing without a tenant: %v", err)
+	return true
+}
+
+// RememberTenant stores tenantID as the tenant to pick for email on the next
+// logins from this browser.
+func (c *CookieTenantResolver) RememberTenant(w http.ResponseWriter, r *http.Request, email, tenantID string) error {
+	if email == "" {
+		return fmt.Errorf("cannot remember tenant without an email")
+	}
+	// an unreadable cookie is replaced
+	preference, _ := c.cookieManager.GetTenantPreferenceCookie(r)
+	return c.cookieManager.SetTenantPreferenceCookie(w, preference.Remember(email, tenantID))
+}
+
+// StartTenantSwitch resets the state cookie so that the next login from this
+// browser asks the user to pick a tenant again. The login is accepted with
+// the existing session once the tenant is picked, the relying party then
+// receives tokens for the new tenant. The switch lapses with the state cookie.
+func (c *CookieTenantResolver) StartTenantSwitch(w http.ResponseWriter, _ *http.Request) error {
+	return c.cookieManager.SetStateCookie(w, cookies.FlowStateCookie{TenantSwitch: true})
+}
+
+// ForgetTenant drops the tenant remembered for email, the user is then asked
+// to pick a tenant again.
+func (c *CookieTenantResolver) ForgetTenant(w http.ResponseWriter, r *http.Request, email string) error {
+	preference, _ := c.cookieManager.GetTenantPreferenceCookie(r)
+	return c.cookieManager.SetTenantPreferenceCookie(w, preference.Forget(email))
 }
 
-func (c *CookieTenantResolver) InterceptLogin(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string) (LoginInterception, error) {
+func (c *CookieTenantResolver) InterceptLogin(ctx context.Context, session *kClient.Session, cookie cookies.FlowStateCookie, loginChallenge string, loginRequest *hClient.OAuth2LoginRequest) (LoginInterception, error) {
 	// No Hydra challenge present. This happens on the aal2 continuation
 	// redirect from Kratos after OIDC callback (challenge is encoded inside
 	// return_to, not a top-level query param). Do not attempt to accept a
@@ -202,12 +286,12 @@ func (c *CookieTenantResolver) InterceptLogin(ctx context.Context, session *kCli
 		// TenantID so tenant selection is re-evaluated for this flow.
 		cookie.LoginChallengeHash = cookies.ChallengeHash(loginChallenge)
 		cookie.TenantID = ""
-		needsSelection, updatedCookie, err := c.NeedsTenantSelection(ctx, session, cookie, loginChallenge)
+		needsSelection, updatedCookie, err := c.NeedsTenantSelection(ctx, session, cookie, loginChallenge, loginRequest)
 		if err != nil {
 			return LoginInterception{}, err
 		}
 		if needsSelection {
-			return LoginInterception{DeferMFAChecks: true, SelectTenant: true, Cookie: cookie}, nil
+			return LoginInterception{DeferMFAChecks: true, SelectTenant: true, Cookie: updatedCookie}, nil
 		}
 		return LoginInterception{DeferMFAChecks: true, AcceptLogin: true, Cookie: updatedCookie}, nil
 	}
@@ -216,12 +300,12 @@ func (c *CookieTenantResolver) InterceptLogin(ctx context.Context, session *kCli
 		return LoginInterception{Cookie: cookie}, nil
 	}
 
-	needsSelection, updatedCookie, err := c.NeedsTenantSelection(ctx, session, cookie, loginChallenge)
+	needsSelection, updatedCookie, err := c.NeedsTenantSelection(ctx, session, cookie, loginChallenge, loginRequest)
 	if err != nil {
 		return LoginInterception{}, err
 	}
 	if needsSelection {
-		return LoginInterception{SelectTenant: true, Cookie: cookie}, nil
+		return LoginInterception{SelectTenant: true, Cookie: updatedCookie}, nil
 	}
 
 	return LoginInterception{AcceptLogin: true, Cookie: updatedCookie}, nil
@@ -5,6 +5,7 @@ package tenants
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http/httptest"
 	"testing"
@@ -81,7 +82,7 @@ func TestCookieTenantResolverEnabled(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
-	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{})
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
 	if !r.Enabled() {
 		t.Fatal("expected Enabled() to return true")
 	}
@@ -92,7 +93,7 @@ func TestCookieTenantResolverTenantIDMatchingChallenge(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
-	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{})
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
 
 	challenge := "test-challenge-xyz"
 	cookie := cookies.FlowStateCookie{
@@ -111,7 +112,7 @@ func TestCookieTenantResolverTenantIDWrongChallenge(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
-	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{})
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
 
 	cookie := cookies.FlowStateCookie{
 		TenantID:           "t1",
@@ -129,7 +130,7 @@ func TestCookieTenantResolverTenantIDEmptyTenantID(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
-	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{})
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
 
 	challenge := "test-challenge"
 	cookie := cookies.FlowStateCookie{
@@ -148,7 +149,7 @@ func TestCookieTenantResolverStoreTenant(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
-	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{})
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
 
 	challenge := "store-challenge"
 	tenantID := "tenant-42"
@@ -184,7 +185,7 @@ func TestCookieTenantResolverHasTenantsByEmailTrue(t *testing.T) {
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(mockCM, svc)
+	r := NewCookieTenantResolver(mockCM, svc, false, nil)
 
 	got, err := r.HasTenants(context.Background(), sessionWithEmail("user@example.com"))
 	if err != nil {
@@ -201,7 +202,7 @@ func TestCookieTenantResolverHasTenantsByEmailFalse(t *testing.T) {
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
 	svc := &mockTenantLookup{tenants: []*Tenant{}}
-	r := NewCookieTenantResolver(mockCM, svc)
+	r := NewCookieTenantResolver(mockCM, svc, false, nil)
 
 	got, err := r.HasTenants(context.Background(), sessionWithEmail("user@example.com"))
 	if err != nil {
@@ -218,7 +219,7 @@ func TestCookieTenantResolverHasTenantsByEmailServiceError(t *testing.T) {
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
 	svc := &mockTenantLookup{err: fmt.Errorf("network error")}
-	r := NewCookieTenantResolver(mockCM, svc)
+	r := NewCookieTenantResolver(mockCM, svc, false, nil)
 
 	_, err := r.HasTenants(context.Background(), sessionWithEmail("user@example.com"))
 	if err == nil {
@@ -232,7 +233,7 @@ func TestCookieTenantResolverHasTenantsByEmailEmpty(t *testing.T) {
 
 	mockCM := NewMockCookieManagerInterface(ctrl)
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(mockCM, svc)
+	r := NewCookieTenantResolver(mockCM, svc, false, nil)
 
 	got, err := r.HasTenants(context.Background(), nil)
 	if err != nil {
@@ -252,7 +253,7 @@ func TestNoOpIsAuthenticatedForChallenge(t *testing.T) {
 
 func TestNoOpNeedsTenantSelection(t *testing.T) {
 	r := NewNoOpTenantResolver()
-	need, c, err := r.NeedsTenantSelection(context.Background(), nil, cookies.FlowStateCookie{TenantID: "x"}, "ch")
+	need, c, err := r.NeedsTenantSelection(context.Background(), nil, cookies.FlowStateCookie{TenantID: "x"}, "ch", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -268,7 +269,7 @@ func TestCookieTenantResolverIsAuthenticatedMatching(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	if !r.IsAuthenticatedForChallenge(c, challenge) {
@@ -280,7 +281,7 @@ func TestCookieTenantResolverIsAuthenticatedMismatch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash("ch-1")}
 	if r.IsAuthenticatedForChallenge(c, "ch-2") {
 		t.Fatal("expected false for mismatched challenge")
@@ -291,7 +292,7 @@ func TestCookieTenantResolverIsAuthenticatedEmptyChallenge(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 	if !r.IsAuthenticatedForChallenge(cookies.FlowStateCookie{}, "") {
 		t.Fatal("expected true for empty challenge")
 	}
@@ -306,8 +307,8 @@ func TestNeedsTenantSelectionAlreadySelected(t *testing.T) {
 		TenantID:           "t1",
 		LoginChallengeHash: cookies.ChallengeHash(challenge),
 	}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
-	need, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
+	need, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -323,9 +324,9 @@ func TestNeedsTenantSelectionAutoSelectsSingleTenant(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	need, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -344,9 +345,9 @@ func TestNeedsTenantSelectionMultipleTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}, {ID: "t2", Name: "Beta"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	need, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -362,9 +363,9 @@ func TestNeedsTenantSelectionNoTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	need, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -383,19 +384,73 @@ func TestNeedsTenantSelectionLookupError(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{err: fmt.Errorf("network error")}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	_, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	_, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
 
+func TestNeedsTenantSelectionFailOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{err: fmt.Errorf("cannot lookup tenants: %w", ErrTenantServiceUnavailable)}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, true, mockLogger)
+
+	needs, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if needs {
+		t.Fatal("expected no tenant selection")
+	}
+	if updated.TenantID != cookies.NoTenantAvailable {
+		t.Fatalf("expected the no-tenant sentinel, got %q", updated.TenantID)
+	}
+}
+
+func TestNeedsTenantSelectionFailOpenKeepsOtherErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{err: fmt.Errorf("network error")}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, true, nil)
+
+	_, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestNeedsTenantSelectionFailClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{err: fmt.Errorf("cannot lookup tenants: %w", ErrTenantServiceUnavailable)}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	_, _, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
+	if !errors.Is(err, ErrTenantServiceUnavailable) {
+		t.Fatalf("expected ErrTenantServiceUnavailable, got %v", err)
+	}
+}
+
 // --- InterceptLogin tests ---
 
 func TestNoOpInterceptLogin(t *testing.T) {
 	r := NewNoOpTenantResolver()
-	result, err := r.InterceptLogin(context.Background(), nil, cookies.FlowStateCookie{TenantID: "x"}, "ch")
+	result, err := r.InterceptLogin(context.Background(), nil, cookies.FlowStateCookie{TenantID: "x"}, "ch", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -413,10 +468,10 @@ func TestInterceptLoginDefersMFAWhenNotAuthenticated(t *testing.T) {
 
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{} // no LoginChallengeHash — not authenticated
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 
 	// No session → identifier-first in progress, defer everything.
-	result, err := r.InterceptLogin(context.Background(), nil, c, challenge)
+	result, err := r.InterceptLogin(context.Background(), nil, c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -434,9 +489,9 @@ func TestInterceptLoginDoesNotAcceptWithoutSessionEvenWhenChallengeMatches(t *te
 
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), nil, c, challenge)
+	result, err := r.InterceptLogin(context.Background(), nil, c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -461,10 +516,10 @@ func TestInterceptLoginNoOpWhenChallengeEmpty(t *testing.T) {
 	// Cookie has a stored challenge hash that would match "" via the wildcard.
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash("some-previous-challenge")}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
 	// Service must NOT be called — early return before any tenant lookup.
-	result, err := r.InterceptLogin(context.Background(), session, c, "")
+	result, err := r.InterceptLogin(context.Background(), session, c, "", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -483,10 +538,10 @@ func TestInterceptLoginSessionReuseAutoSelectsSingleTenant(t *testing.T) {
 	challenge := "ch-new" // new challenge, cookie has no matching hash
 	c := cookies.FlowStateCookie{}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
 	// Existing session + single tenant → defer MFA + accept (auto-select).
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -511,10 +566,10 @@ func TestInterceptLoginSessionReuseSelectsTenantWhenMultiTenant(t *testing.T) {
 	challenge := "ch-new"
 	c := cookies.FlowStateCookie{}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}, {ID: "t2", Name: "Beta"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
 	// Existing session + multi-tenant → defer MFA + select tenant.
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -536,10 +591,10 @@ func TestInterceptLoginSessionReuseAcceptsWhenNoTenants(t *testing.T) {
 	challenge := "ch-new"
 	c := cookies.FlowStateCookie{}
 	svc := &mockTenantLookup{tenants: []*Tenant{}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
 	// Existing session + zero tenants → defer MFA + accept immediately.
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -567,9 +622,9 @@ func TestInterceptLoginAutoSelectsSingleTenant(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -591,9 +646,9 @@ func TestInterceptLoginSelectsTenantWhenMultipleTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}, {ID: "t2", Name: "Beta"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -614,9 +669,9 @@ func TestInterceptLoginAcceptsWhenTenantAlreadySelected(t *testing.T) {
 		LoginChallengeHash: cookies.ChallengeHash(challenge),
 		TenantID:           "t1",
 	}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -635,9 +690,9 @@ func TestInterceptLoginAcceptsWithSentinelWhenNoTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -656,9 +711,9 @@ func TestInterceptLoginPropagatesLookupError(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{err: fmt.Errorf("network error")}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	_, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge)
+	_, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -678,9 +733,9 @@ func TestInterceptLoginSessionReuseClearsStaleTenantIDSingleTenant(t *testing.T)
 	}
 	// User has since been provisioned into exactly one tenant → auto-select.
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, newChallenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, newChallenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -709,9 +764,9 @@ func TestInterceptLoginSessionReuseClearsStaleTenantIDMultipleTenants(t *testing
 	}
 	// User has since been provisioned into multiple tenants → needs selection.
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}, {ID: "t2", Name: "Beta"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, newChallenge)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, newChallenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -727,7 +782,7 @@ func TestInterceptLoginSessionReuseClearsStaleTenantIDMultipleTenants(t *testing
 
 func TestNoOpNeedsTenantSelectionByEmail(t *testing.T) {
 	r := NewNoOpTenantResolver()
-	need, c, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", cookies.FlowStateCookie{TenantID: "x"}, "ch")
+	need, c, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", cookies.FlowStateCookie{TenantID: "x"}, "ch", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -748,8 +803,8 @@ func TestNeedsTenantSelectionByEmailAlreadySelected(t *testing.T) {
 		TenantID:           "t1",
 		LoginChallengeHash: cookies.ChallengeHash(challenge),
 	}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{})
-	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
+	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -765,9 +820,9 @@ func TestNeedsTenantSelectionByEmailAutoSelectsSingleTenant(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, updated, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge)
+	need, updated, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -786,9 +841,9 @@ func TestNeedsTenantSelectionByEmailMultipleTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme"}, {ID: "t2", Name: "Beta"}}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge)
+	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -804,9 +859,9 @@ func TestNeedsTenantSelectionByEmailNoTenants(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{tenants: []*Tenant{}}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	need, updated, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge)
+	need, updated, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -824,9 +879,9 @@ func TestNeedsTenantSelectionByEmailEmptyEmail(t *testing.T) {
 
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{tenants: []*Tenant{{ID: "t1"}}})
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{tenants: []*Tenant{{ID: "t1"}}}, false, nil)
 
-	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "", c, challenge)
+	need, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "", c, challenge, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -842,10 +897,303 @@ func TestNeedsTenantSelectionByEmailLookupError(t *testing.T) {
 	challenge := "ch-1"
 	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
 	svc := &mockTenantLookup{err: fmt.Errorf("network error")}
-	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc)
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
 
-	_, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge)
+	_, _, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+// --- remembered tenant tests ---
+
+func contextWithRememberedTenant(email, tenantID string) context.Context {
+	return context.WithValue(context.Background(), tenantPreferenceKey{}, cookies.TenantPreferenceCookie{}.Remember(email, tenantID))
+}
+
+func TestNeedsTenantSelectionByEmailPicksRememberedTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	need, updated, err := r.NeedsTenantSelectionByEmail(contextWithRememberedTenant("u@e.com", "t2"), "u@e.com", c, challenge, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if need {
+		t.Fatal("should not need selection when the user remembered a tenant")
+	}
+	if updated.TenantID != "t2" {
+		t.Fatalf("expected remembered tenant %q, got %q", "t2", updated.TenantID)
+	}
+}
+
+func TestNeedsTenantSelectionPicksRememberedTenantForSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	need, updated, err := r.NeedsTenantSelection(contextWithRememberedTenant("u@e.com", "t1"), sessionWithEmail("u@e.com"), c, challenge, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if need || updated.TenantID != "t1" {
+		t.Fatalf("expected remembered tenant %q to be picked, got need=%v tenant=%q", "t1", need, updated.TenantID)
+	}
+}
+
+func TestNeedsTenantSelectionIgnoresStaleRememberedTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: false}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	for _, tenantID := range []string{"t2", "t3"} {
+		c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+		need, updated, err := r.NeedsTenantSelectionByEmail(contextWithRememberedTenant("u@e.com", tenantID), "u@e.com", c, challenge, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !need || updated.TenantID != "" {
+			t.Fatalf("expected selection for disabled or removed tenant %q, got need=%v tenant=%q", tenantID, need, updated.TenantID)
+		}
+	}
+
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	need, _, err := r.NeedsTenantSelectionByEmail(contextWithRememberedTenant("other@e.com", "t1"), "u@e.com", c, challenge, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !need {
+		t.Fatal("expected selection when the tenant was remembered for another user")
+	}
+}
+
+func TestCookieTenantResolverRememberTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	existing := cookies.TenantPreferenceCookie{}.Remember("other@e.com", "t9")
+
+	mockCM.EXPECT().GetTenantPreferenceCookie(req).Return(existing, nil)
+	mockCM.EXPECT().SetTenantPreferenceCookie(w, existing.Remember("u@e.com", "t1")).Return(nil)
+
+	if err := r.RememberTenant(w, req, "u@e.com", "t1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.RememberTenant(w, req, "", "t1"); err == nil {
+		t.Fatal("expected error when the email is unknown")
+	}
+}
+
+func TestCookieTenantResolverForgetTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	existing := cookies.TenantPreferenceCookie{}.Remember("other@e.com", "t9").Remember("u@e.com", "t1")
+
+	mockCM.EXPECT().GetTenantPreferenceCookie(req).Return(existing, nil)
+	mockCM.EXPECT().SetTenantPreferenceCookie(w, existing.Forget("u@e.com")).Return(nil)
+
+	if err := r.ForgetTenant(w, req, "u@e.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// --- tenant hint tests ---
+
+func TestNeedsTenantSelectionByEmailSelectsHintedTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	// the hint takes precedence over the remembered tenant
+	loginRequest := loginRequestWithHint("https://hydra/oauth2/auth?tenant=t2", nil)
+	need, updated, err := r.NeedsTenantSelectionByEmail(contextWithRememberedTenant("u@e.com", "t1"), "u@e.com", c, challenge, loginRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if need || updated.TenantID != "t2" {
+		t.Fatalf("expected hinted tenant %q to be selected, got need=%v tenant=%q", "t2", need, updated.TenantID)
+	}
+}
+
+func TestNeedsTenantSelectionRejectsInvalidHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenants  []*Tenant
+		expected string
+	}{
+		{
+			name:     "not a member",
+			tenants:  []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}},
+			expected: TenantHintNotMember,
+		},
+		{
+			name:     "disabled tenant",
+			tenants:  []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: false}},
+			expected: TenantHintDisabled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			challenge := "ch-1"
+			c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+			r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{tenants: test.tenants}, false, nil)
+
+			loginRequest := loginRequestWithHint("https://hydra/oauth2/auth", map[string]interface{}{"default_tenant": "t2"})
+			need, updated, err := r.NeedsTenantSelection(context.Background(), sessionWithEmail("u@e.com"), c, challenge, loginRequest)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !need || updated.TenantID != "" {
+				t.Fatalf("expected selection to be needed, got need=%v tenant=%q", need, updated.TenantID)
+			}
+			if updated.TenantHintError != test.expected {
+				t.Fatalf("expected hint error %q, got %q", test.expected, updated.TenantHintError)
+			}
+		})
+	}
+}
+
+func TestNeedsTenantSelectionNoTenantsSkipsHint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{}, false, nil)
+
+	need, updated, err := r.NeedsTenantSelectionByEmail(context.Background(), "u@e.com", c, challenge, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if need || updated.TenantID != cookies.NoTenantAvailable {
+		t.Fatalf("expected no tenant sentinel, got need=%v tenant=%q", need, updated.TenantID)
+	}
+}
+
+func TestInterceptLoginReportsRejectedHint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	challenge := "ch-1"
+	c := cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge)}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Enabled: true}}}, false, nil)
+
+	loginRequest := loginRequestWithHint("https://hydra/oauth2/auth?tenant=t9", nil)
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, challenge, loginRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.SelectTenant || result.Cookie.TenantHintError != TenantHintNotMember {
+		t.Fatalf("expected tenant selection with hint error, got %+v", result)
+	}
+}
+
+// --- Tenant switch tests ---
+
+func TestCookieTenantResolverStartTenantSwitch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
+
+	w := httptest.NewRecorder()
+	mockCM.EXPECT().SetStateCookie(w, cookies.FlowStateCookie{TenantSwitch: true}).Return(nil)
+
+	if err := r.StartTenantSwitch(w, httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCookieTenantResolverStoreTenantCompletesSwitch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCM := NewMockCookieManagerInterface(ctrl)
+	r := NewCookieTenantResolver(mockCM, &mockTenantLookup{}, false, nil)
+
+	challenge := "switch-challenge"
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+
+	mockCM.EXPECT().GetStateCookie(req).Return(cookies.FlowStateCookie{LoginChallengeHash: cookies.ChallengeHash(challenge), TenantSwitch: true}, nil)
+	mockCM.EXPECT().SetStateCookie(w, cookies.FlowStateCookie{
+		TenantID:           "t2",
+		LoginChallengeHash: cookies.ChallengeHash(challenge),
+	}).Return(nil)
+
+	if err := r.StoreTenant(w, req, "t2", challenge); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestInterceptLoginTenantSwitchIgnoresRememberedAndHintedTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the cookie set by the switch endpoint, not yet bound to a challenge
+	c := cookies.FlowStateCookie{TenantSwitch: true}
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}, {ID: "t2", Name: "Beta", Enabled: true}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	loginRequest := loginRequestWithHint("https://hydra/oauth2/auth?tenant=t2", nil)
+	result, err := r.InterceptLogin(contextWithRememberedTenant("u@e.com", "t1"), sessionWithEmail("u@e.com"), c, "ch-new", loginRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.SelectTenant || result.AcceptLogin {
+		t.Fatalf("expected the user to pick a tenant, got %+v", result)
+	}
+	if !result.Cookie.TenantSwitch || result.Cookie.LoginChallengeHash != cookies.ChallengeHash("ch-new") {
+		t.Fatalf("expected the switch to be bound to the new challenge, got %+v", result.Cookie)
+	}
+}
+
+func TestInterceptLoginTenantSwitchAutoSelectsSingleTenant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := cookies.FlowStateCookie{TenantSwitch: true}
+	svc := &mockTenantLookup{tenants: []*Tenant{{ID: "t1", Name: "Acme", Enabled: true}}}
+	r := NewCookieTenantResolver(NewMockCookieManagerInterface(ctrl), svc, false, nil)
+
+	result, err := r.InterceptLogin(context.Background(), sessionWithEmail("u@e.com"), c, "ch-new", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.AcceptLogin || result.Cookie.TenantID != "t1" {
+		t.Fatalf("expected the only tenant to be accepted, got %+v", result)
+	}
+}
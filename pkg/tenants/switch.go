@@ -0,0 +1,38 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"fmt"
+	"net/url"
+
+	hClient "github.com/ory/hydra-client-go/v2"
+)
+
+// InitiateLoginURIMetadataKey is the OAuth2 client metadata key holding the
+// URI that starts a new authorization request at the client, as in OpenID
+// Connect third party initiated login. A tenant switch redirects there.
+const InitiateLoginURIMetadataKey = "initiate_login_uri"
+
+// initiateLoginURI returns the login initiation URI registered for client,
+// the redirect target is only read from the client registration so the
+// switch endpoint cannot be used as an open redirect.
+func initiateLoginURI(client *hClient.OAuth2Client) (string, error) {
+	if client == nil {
+		return "", fmt.Errorf("no client")
+	}
+
+	metadata, _ := client.GetMetadata().(map[string]interface{})
+	uri, _ := metadata[InitiateLoginURIMetadataKey].(string)
+	if uri == "" {
+		return "", fmt.Errorf("client %s has no %s", client.GetClientId(), InitiateLoginURIMetadataKey)
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("invalid %s %q of client %s", InitiateLoginURIMetadataKey, uri, client.GetClientId())
+	}
+
+	return u.String(), nil
+}
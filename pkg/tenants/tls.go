@@ -0,0 +1,242 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/canonical/identity-platform-login-ui/internal/logging"
+)
+
+// TLSConfig configures the transport security of the tenant-service
+// connection. Without CAFile the system roots are trusted, CertFile and
+// KeyFile enable mutual TLS. ServerName overrides the name verified in the
+// server certificate, it defaults to the host of the dialed address.
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// Validate checks that the settings are consistent, files are only read when
+// the transport credentials are built.
+func (c TLSConfig) Validate() error {
+	if !c.Enabled {
+		if c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" || c.ServerName != "" {
+			return fmt.Errorf("tenant-service TLS settings are set but TLS is disabled")
+		}
+		return nil
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("tenant-service client certificate and key must be set together")
+	}
+
+	return nil
+}
+
+// certReloader serves the client certificate and the trusted roots of the
+// tenant-service connection. Files are checked on every TLS handshake and
+// read again when their modification time changed, so rotated certificates
+// are picked up without a restart. A file that cannot be read keeps the
+// previously loaded content in use.
+type certReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	roots   *x509.CertPool
+	cert    *tls.Certificate
+	caMod   time.Time
+	certMod time.Time
+
+	logger logging.LoggerInterface
+}
+
+// load reads the files that changed since the last call.
+func (r *certReloader) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+
+	if r.caFile != "" {
+		if mod, changed := r.changed(r.caFile, r.caMod); changed {
+			if err := r.loadCA(); err != nil {
+				errs = append(errs, err)
+			} else {
+				r.caMod = mod
+			}
+		}
+	}
+
+	if r.certFile != "" {
+		// the key is written alongside the certificate, either changing
+		// triggers a reload of the pair
+		certMod, certChanged := r.changed(r.certFile, r.certMod)
+		keyMod, keyChanged := r.changed(r.keyFile, r.certMod)
+		if certChanged || keyChanged {
+			if err := r.loadCertificate(); err != nil {
+				errs = append(errs, err)
+			} else {
+				r.certMod = certMod
+				if keyMod.After(certMod) {
+					r.certMod = keyMod
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// changed returns the modification time of path and whether it is more
+// recent than since.
+func (r *certReloader) changed(path string, since time.Time) (time.Time, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		// reported when the file is read
+		return since, true
+	}
+	return info.ModTime(), info.ModTime().After(since)
+}
+
+func (r *certReloader) loadCA() error {
+	pem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return fmt.Errorf("cannot read tenant-service CA bundle: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no certificate found in tenant-service CA bundle %s", r.caFile)
+	}
+
+	r.roots = roots
+	return nil
+}
+
+func (r *certReloader) loadCertificate() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("cannot load tenant-service client certificate: %w", err)
+	}
+
+	r.cert = &cert
+	return nil
+}
+
+// reload is called on every handshake, failures are logged and the previous
+// files stay in use.
+func (r *certReloader) reload() {
+	if err := r.load(); err != nil {
+		r.logger.Errorf("failed to reload tenant-service TLS files, using the previous ones: %v", err)
+	}
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.reload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.cert == nil {
+		// no certificate is sent
+		return &tls.Certificate{}, nil
+	}
+	return r.cert, nil
+}
+
+// VerifyConnection verifies the server certificate chain against the current
+// CA bundle, it replaces the verification done by crypto/tls which cannot
+// pick up a new bundle.
+func (r *certReloader) VerifyConnection(cs tls.ConnectionState) error {
+	r.reload()
+
+	r.mu.RLock()
+	roots := r.roots
+	r.mu.RUnlock()
+
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("tenant service presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("cannot verify tenant-service certificate: %w", err)
+	}
+	return nil
+}
+
+// newTLSCredentials validates cfg, loads its files and returns the transport
+// credentials of the tenant-service connection.
+func newTLSCredentials(cfg TLSConfig, logger logging.LoggerInterface) (credentials.TransportCredentials, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAFile == "" && cfg.CertFile == "" {
+		return credentials.NewTLS(tlsConfig), nil
+	}
+
+	reloader := &certReloader{
+		caFile:   cfg.CAFile,
+		certFile: cfg.CertFile,
+		keyFile:  cfg.KeyFile,
+		logger:   logger,
+	}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	if cfg.CertFile != "" {
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	}
+	if cfg.CAFile != "" {
+		// the chain is verified by VerifyConnection against the reloaded
+		// CA bundle, the hostname is still checked there
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = reloader.VerifyConnection
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// bearerTokenCredentials attaches a static bearer token to every
+// tenant-service call.
+type bearerTokenCredentials string
+
+func (t bearerTokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity prevents the token from being sent in clear text.
+func (t bearerTokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
@@ -0,0 +1,262 @@
+// Copyright 2026 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tenants
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-login-ui/internal/misc/clock"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// issueCertificate creates a certificate for name signed by parent, or a self
+// signed CA when parent is nil.
+func issueCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{name}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("cannot parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal key: %v", err)
+	}
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		wantErr bool
+	}{
+		{name: "disabled", config: TLSConfig{}},
+		{name: "system roots", config: TLSConfig{Enabled: true}},
+		{name: "mutual TLS", config: TLSConfig{Enabled: true, CAFile: "ca.pem", CertFile: "cert.pem", KeyFile: "key.pem", ServerName: "tenants"}},
+		{name: "settings without TLS", config: TLSConfig{CAFile: "ca.pem"}, wantErr: true},
+		{name: "certificate without key", config: TLSConfig{Enabled: true, CertFile: "cert.pem"}, wantErr: true},
+		{name: "key without certificate", config: TLSConfig{Enabled: true, KeyFile: "key.pem"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewTLSCredentialsFailsOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	clock.NewFake().WriteFile(t, filepath.Join(dir, "ca.pem"), []byte("not a certificate"))
+
+	tests := []struct {
+		name   string
+		config TLSConfig
+	}{
+		{name: "missing CA", config: TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}},
+		{name: "invalid CA", config: TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "ca.pem")}},
+		{name: "missing certificate", config: TLSConfig{Enabled: true, CertFile: filepath.Join(dir, "cert.pem"), KeyFile: filepath.Join(dir, "key.pem")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newTLSCredentials(test.config, nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestCertReloaderReloadsChangedCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := issueCertificate(t, "ca", nil)
+	first := issueCertificate(t, "login-ui", ca)
+	second := issueCertificate(t, "login-ui", ca)
+
+	fakeClock := clock.NewFake()
+	fakeClock.WriteFile(t, certFile, first.certPEM)
+	fakeClock.WriteFile(t, keyFile, first.keyPEM)
+
+	r := &certReloader{certFile: certFile, keyFile: keyFile, logger: mockLogger}
+	if err := r.load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cert, _ := r.GetClientCertificate(nil)
+	if !first.cert.Equal(cert.Leaf) {
+		t.Fatal("expected the first certificate")
+	}
+
+	fakeClock.WriteFile(t, certFile, second.certPEM)
+	fakeClock.WriteFile(t, keyFile, second.keyPEM)
+
+	cert, _ = r.GetClientCertificate(nil)
+	if !second.cert.Equal(cert.Leaf) {
+		t.Fatal("expected the rotated certificate")
+	}
+}
+
+func TestCertReloaderKeepsPreviousCertificateOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).MinTimes(1)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := issueCertificate(t, "ca", nil)
+	leaf := issueCertificate(t, "login-ui", ca)
+
+	fakeClock := clock.NewFake()
+	fakeClock.WriteFile(t, certFile, leaf.certPEM)
+	fakeClock.WriteFile(t, keyFile, leaf.keyPEM)
+
+	r := &certReloader{certFile: certFile, keyFile: keyFile, logger: mockLogger}
+	if err := r.load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// a half written rotation
+	fakeClock.WriteFile(t, certFile, []byte("garbage"))
+
+	cert, err := r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !leaf.cert.Equal(cert.Leaf) {
+		t.Fatal("expected the previous certificate")
+	}
+}
+
+func TestCertReloaderVerifyConnection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := issueCertificate(t, "ca", nil)
+	otherCA := issueCertificate(t, "other-ca", nil)
+	server := issueCertificate(t, "tenants.internal", ca)
+	rogue := issueCertificate(t, "tenants.internal", otherCA)
+
+	fakeClock := clock.NewFake()
+	fakeClock.WriteFile(t, caFile, ca.certPEM)
+
+	r := &certReloader{caFile: caFile, logger: mockLogger}
+	if err := r.load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := r.VerifyConnection(tls.ConnectionState{ServerName: "tenants.internal", PeerCertificates: []*x509.Certificate{server.cert}}); err != nil {
+		t.Fatalf("expected the server certificate to be trusted, got %v", err)
+	}
+	if err := r.VerifyConnection(tls.ConnectionState{ServerName: "other.internal", PeerCertificates: []*x509.Certificate{server.cert}}); err == nil {
+		t.Fatal("expected a name mismatch to be rejected")
+	}
+	if err := r.VerifyConnection(tls.ConnectionState{ServerName: "tenants.internal", PeerCertificates: []*x509.Certificate{rogue.cert}}); err == nil {
+		t.Fatal("expected an untrusted certificate to be rejected")
+	}
+	if err := r.VerifyConnection(tls.ConnectionState{ServerName: "tenants.internal"}); err == nil {
+		t.Fatal("expected a missing certificate to be rejected")
+	}
+
+	// the CA bundle is rotated
+	fakeClock.WriteFile(t, caFile, otherCA.certPEM)
+
+	if err := r.VerifyConnection(tls.ConnectionState{ServerName: "tenants.internal", PeerCertificates: []*x509.Certificate{rogue.cert}}); err != nil {
+		t.Fatalf("expected the rotated CA to be trusted, got %v", err)
+	}
+}
+
+func TestBearerTokenCredentials(t *testing.T) {
+	creds := bearerTokenCredentials("secret")
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if md["authorization"] != "Bearer secret" {
+		t.Fatalf("unexpected metadata %v", md)
+	}
+	if !creds.RequireTransportSecurity() {
+		t.Fatal("expected the token to require TLS")
+	}
+}
+
+func TestNewGRPCConnRejectsBearerTokenWithoutTLS(t *testing.T) {
+	if _, err := NewGRPCConn("localhost:50051", TLSConfig{}, "secret", nil, nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestNewGRPCConnRejectsTLSSettingsWithoutTLS(t *testing.T) {
+	if _, err := NewGRPCConn("localhost:50051", TLSConfig{CAFile: "ca.pem"}, "", nil, nil, nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
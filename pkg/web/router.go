@@ -6,10 +6,13 @@ package web
 import (
 	"io/fs"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	chi "github.com/go-chi/chi/v5"
 	middleware "github.com/go-chi/chi/v5/middleware"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	authz "github.com/canonical/identity-platform-login-ui/internal/authorization"
 	"github.com/canonical/identity-platform-login-ui/internal/cookies"
@@ -23,6 +26,7 @@ import (
 	"github.com/canonical/identity-platform-login-ui/pkg/extra"
 	"github.com/canonical/identity-platform-login-ui/pkg/kratos"
 	"github.com/canonical/identity-platform-login-ui/pkg/metrics"
+	"github.com/canonical/identity-platform-login-ui/pkg/mfa"
 	"github.com/canonical/identity-platform-login-ui/pkg/status"
 	"github.com/canonical/identity-platform-login-ui/pkg/tenants"
 	"github.com/canonical/identity-platform-login-ui/pkg/ui"
@@ -49,6 +53,14 @@ func WithAuthzClient(a authz.AuthorizerInterface) Option {
 	}
 }
 
+// WithAuthorizationEnabled tells whether the authz client enforces the
+// authorization model, the checks needing extra calls are skipped otherwise.
+func WithAuthorizationEnabled(enabled bool) Option {
+	return func(r *routerConfig) {
+		r.authorizationEnabled = enabled
+	}
+}
+
 func WithCookieManager(cm *cookies.AuthCookieManager) Option {
 	return func(r *routerConfig) {
 		r.cookieManager = cm
@@ -71,6 +83,21 @@ func WithFlags(verification, mfa, oidcSeq, identifierFirst, multiTenancy bool) O
 	}
 }
 
+// WithMFAPolicy overrides the MFA policy derived from the mfa and oidcSeq flags.
+func WithMFAPolicy(p *mfa.Policy) Option {
+	return func(r *routerConfig) {
+		r.mfaPolicy = p
+	}
+}
+
+// WithBackupCodesThreshold sets the number of unused backup codes at or below
+// which the user is asked to regenerate them.
+func WithBackupCodesThreshold(n int) Option {
+	return func(r *routerConfig) {
+		r.backupCodesThreshold = n
+	}
+}
+
 func WithBaseURL(url string) Option {
 	return func(r *routerConfig) {
 		r.baseURL = url
@@ -107,6 +134,32 @@ func WithTenantsGRPCTimeout(d time.Duration) Option {
 	}
 }
 
+func WithTenantsCache(ttl, negativeTTL time.Duration, size int) Option {
+	return func(r *routerConfig) {
+		r.tenantsCacheTTL = ttl
+		r.tenantsCacheNegativeTTL = negativeTTL
+		r.tenantsCacheSize = size
+	}
+}
+
+func WithTenantsFailOpen(failOpen bool) Option {
+	return func(r *routerConfig) {
+		r.tenantsFailOpen = failOpen
+	}
+}
+
+func WithTenantsHealthClient(client healthpb.HealthClient) Option {
+	return func(r *routerConfig) {
+		r.tenantsHealthClient = client
+	}
+}
+
+func WithTenantsAppConfigSource(source tenants.AppConfigSourceInterface) Option {
+	return func(r *routerConfig) {
+		r.tenantsAppConfigSource = source
+	}
+}
+
 func WithTracing(t tracing.TracingInterface) Option {
 	return func(r *routerConfig) {
 		r.tracer = t
@@ -130,13 +183,16 @@ type routerConfig struct {
 	kratosAdminClient             *ik.Client
 	hydraClient                   *ih.Client
 	authzClient                   authz.AuthorizerInterface
+	authorizationEnabled          bool
 	cookieManager                 *cookies.AuthCookieManager
 	distFS                        fs.FS
 	verificationEnabled           bool
 	mfaEnabled                    bool
 	oidcWebAuthnSequencingEnabled bool
+	mfaPolicy                     *mfa.Policy
 	identifierFirstEnabled        bool
 	multiTenancyEnabled           bool
+	backupCodesThreshold          int
 	baseURL                       string
 	supportEmail                  string
 	featureFlags                  []string
@@ -146,6 +202,12 @@ type routerConfig struct {
 	logger                        logging.LoggerInterface
 	tenantsServiceClient          tenants.TenantServiceClientInterface
 	tenantsGRPCTimeout            time.Duration
+	tenantsCacheTTL               time.Duration
+	tenantsCacheNegativeTTL       time.Duration
+	tenantsCacheSize              int
+	tenantsFailOpen               bool
+	tenantsHealthClient           healthpb.HealthClient
+	tenantsAppConfigSource        tenants.AppConfigSourceInterface
 }
 
 func NewRouter(opts ...Option) http.Handler {
@@ -173,6 +235,18 @@ func buildMiddlewares(config *routerConfig) chi.Middlewares {
 		middlewareCORS([]string{"*"}),
 	)
 
+	// remembered tenants are read once per request for the tenant resolver,
+	// only on the routes resolving tenants so UI assets skip the decryption
+	if config.multiTenancyEnabled && config.tenantsServiceClient != nil {
+		middlewares = append(
+			middlewares,
+			middleware.Maybe(
+				tenants.NewMiddleware(config.cookieManager, config.logger).TenantPreference(),
+				readsTenantPreference,
+			),
+		)
+	}
+
 	if config.logger != nil {
 		middlewares = append(
 			middlewares,
@@ -183,45 +257,81 @@ func buildMiddlewares(config *routerConfig) chi.Middlewares {
 	return middlewares
 }
 
+// tenantPreferencePaths are the API routes looking up the tenants of a user
+var tenantPreferencePaths = []string{
+	"/api/kratos/self-service/login",
+	"/api/v0/tenants",
+	"/api/v0/auth/tenant",
+}
+
+func readsTenantPreference(r *http.Request) bool {
+	for _, path := range tenantPreferencePaths {
+		if strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func registerAPIs(config *routerConfig, router *chi.Mux) {
 	device.NewAPI(
 		device.NewService(config.hydraClient, config.tracer, config.monitor, config.logger),
 		config.tracer,
+		config.monitor,
 		config.logger,
 	).RegisterEndpoints(router)
 
-	kratosService := kratos.NewService(config.kratosClient, config.kratosAdminClient, config.hydraClient, config.authzClient, config.oidcWebAuthnSequencingEnabled, config.multiTenancyEnabled, config.tracer, config.monitor, config.logger)
+	kratosService := kratos.NewService(config.kratosClient, config.kratosAdminClient, config.hydraClient, config.authzClient, config.oidcWebAuthnSequencingEnabled, config.multiTenancyEnabled, config.backupCodesThreshold, config.tracer, config.monitor, config.logger)
 
 	var resolver kratos.TenantResolverInterface = tenants.NewNoOpTenantResolver()
 	if config.multiTenancyEnabled {
 		if config.tenantsServiceClient == nil {
 			config.logger.Warn("multi-tenancy enabled but tenant gRPC client is not configured; falling back to no-op resolver")
 		} else {
-			tenantsService := tenants.NewService(config.tenantsServiceClient, kratosService, config.tenantsGRPCTimeout, config.tracer, config.monitor, config.logger)
-			resolver = tenants.NewCookieTenantResolver(config.cookieManager, tenantsService)
-			tenants.NewAPI(tenantsService, resolver, kratosService, config.baseURL, config.tracer, config.logger).RegisterEndpoints(router)
+			var tenantsService tenants.ServiceInterface = tenants.NewService(config.tenantsServiceClient, kratosService, config.tenantsGRPCTimeout, config.tracer, config.monitor, config.logger)
+			if config.tenantsCacheTTL > 0 {
+				tenantsService = tenants.NewCachedService(tenantsService, kratosService, config.tenantsCacheTTL, config.tenantsCacheNegativeTTL, config.tenantsCacheSize, config.tracer, config.monitor, config.logger)
+			}
+			cookieResolver := tenants.NewCookieTenantResolver(config.cookieManager, tenantsService, config.tenantsFailOpen, config.logger)
+			resolver = cookieResolver
+			tenants.NewAPI(tenantsService, cookieResolver, kratosService, kratosService, kratosService, config.baseURL, config.tracer, config.monitor, config.logger).RegisterEndpoints(router)
 		}
 	}
 
+	var tenantConfig status.TenantAppConfigInterface
+	if config.multiTenancyEnabled && config.tenantsAppConfigSource != nil {
+		tenantConfig = tenants.NewAppConfigResolver(config.tenantsAppConfigSource, config.cookieManager, kratosService, config.tracer, config.logger)
+	}
+
+	mfaPolicy := config.mfaPolicy
+	if mfaPolicy == nil {
+		mfaPolicy = mfa.NewDefaultPolicy(config.mfaEnabled, config.oidcWebAuthnSequencingEnabled)
+	}
+
+	mfaService := mfa.NewService(mfaPolicy, config.authzClient, config.tracer, config.monitor, config.logger)
+
 	kratos.NewAPI(
 		kratosService,
 		config.verificationEnabled,
-		config.mfaEnabled,
-		config.oidcWebAuthnSequencingEnabled,
+		slices.Contains(config.featureFlags, "passkey"),
+		config.authorizationEnabled,
+		mfaService,
 		resolver,
 		config.baseURL,
 		config.cookieManager,
 		config.tracer,
+		config.monitor,
 		config.logger,
 	).RegisterEndpoints(router)
 
 	extra.NewAPI(
 		extra.NewService(config.hydraClient, config.tracer, config.monitor, config.logger),
 		kratosService,
+		mfaService,
 		config.baseURL,
-		config.mfaEnabled,
-		config.oidcWebAuthnSequencingEnabled,
 		config.tracer,
+		config.monitor,
 		config.logger,
 	).RegisterEndpoints(router)
 
@@ -232,7 +342,8 @@ func registerAPIs(config *routerConfig, router *chi.Mux) {
 		config.identifierFirstEnabled,
 		config.multiTenancyEnabled,
 		config.featureFlags,
-		status.NewService(config.kratosClient.MetadataApi(), config.hydraClient.MetadataAPI(), config.tracer, config.monitor, config.logger),
+		status.NewService(config.kratosClient.MetadataApi(), config.hydraClient.MetadataAPI(), config.tenantsHealthClient, config.tracer, config.monitor, config.logger),
+		tenantConfig,
 		config.tracer,
 		config.monitor,
 		config.logger,
@@ -46,3 +46,30 @@ export async function loginIdentifierFirst(
 
   return (await res.json()) as IdentifierFirstResponse;
 }
+
+export type BackupCodes = {
+  configured: boolean;
+  remaining: number;
+  used: { used_at: string }[];
+  threshold: number;
+};
+
+export async function getBackupCodes() {
+  const res = await fetch("/self-service/settings/backup-codes");
+
+  if (!res.ok) {
+    throw new Error(await res.text());
+  }
+
+  return (await res.json()) as BackupCodes;
+}
+
+export async function remindBackupCodesLater() {
+  const res = await fetch("/self-service/settings/backup-codes/remind-later", {
+    method: "POST",
+  });
+
+  if (!res.ok) {
+    throw new Error(await res.text());
+  }
+}
@@ -1,6 +1,10 @@
 export type Tenant = {
   id: string;
   name: string;
+  // set on the tenants listed by the tenant picker API
+  selectable?: boolean;
+  unavailable_reason?: string;
+  display_order?: number;
 };
 
 const parseTenants = (r: Response): Promise<Tenant[]> => {
@@ -19,3 +23,47 @@ export const fetchTenantsByFlow = (flowId: string): Promise<Tenant[]> =>
 
 export const fetchTenantsBySession = (): Promise<Tenant[]> =>
   fetch("/api/v0/tenants").then(parseTenants);
+
+const tenantQuery = (flowId?: string): string =>
+  flowId ? `?flow=${encodeURIComponent(flowId)}` : "";
+
+// Returns the tenant the user chose to remember, null when there is none.
+export const fetchRememberedTenant = (
+  flowId?: string,
+): Promise<Tenant | null> =>
+  fetch(`/api/v0/auth/tenant/remembered${tenantQuery(flowId)}`).then((r) => {
+    if (r.status === 404 || r.status === 401) {
+      return null;
+    }
+    if (!r.ok) {
+      throw new Error(`Tenants API returned ${r.status}`);
+    }
+    return (r.json() as Promise<{ tenant: Tenant }>).then(
+      (body) => body.tenant,
+    );
+  });
+
+export const forgetRememberedTenant = (flowId?: string): Promise<void> =>
+  fetch(`/api/v0/auth/tenant/remembered${tenantQuery(flowId)}`, {
+    method: "DELETE",
+  }).then((r) => {
+    if (!r.ok) {
+      throw new Error(`Tenants API returned ${r.status}`);
+    }
+  });
+
+// Starts a tenant switch for the client, returns the URL that starts a new
+// login of the client.
+export const switchTenant = (clientId: string): Promise<string> =>
+  fetch("/api/v0/auth/tenant/switch", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ client_id: clientId }),
+  }).then((r) => {
+    if (!r.ok) {
+      throw new Error(`Tenants API returned ${r.status}`);
+    }
+    return (r.json() as Promise<{ redirect_to: string }>).then(
+      (body) => body.redirect_to,
+    );
+  });
@@ -2,6 +2,7 @@ import { Card, Col, Navigation, Row, Theme } from "@canonical/react-components";
 import React, { FC, ReactNode } from "react";
 import Head from "next/head";
 import SelfServeNavigation from "./SelfServeNavigation";
+import { useAppConfig } from "../config/useAppConfig";
 
 interface Props {
   children?: ReactNode;
@@ -11,6 +12,8 @@ interface Props {
 }
 
 const PageLayout: FC<Props> = ({ children, title, user, isSelfServe }) => {
+  const { tenant } = useAppConfig();
+
   return (
     <>
       <Head>
@@ -46,13 +49,25 @@ const PageLayout: FC<Props> = ({ children, title, user, isSelfServe }) => {
           </main>
         </div>
       ) : (
-        <Row className="p-strip page-row">
+        <Row
+          className="p-strip page-row"
+          style={{ backgroundColor: tenant?.background_color }}
+        >
           <Col emptyLarge={4} size={6}>
-            <Card className="u-no-padding page-card">
+            <Card
+              className="u-no-padding page-card"
+              style={
+                tenant?.primary_color
+                  ? { borderTop: `4px solid ${tenant.primary_color}` }
+                  : undefined
+              }
+            >
               <Navigation
                 logo={{
-                  src: "https://assets.ubuntu.com/v1/82818827-CoF_white.svg",
-                  title: "Canonical",
+                  src:
+                    tenant?.logo_url ??
+                    "https://assets.ubuntu.com/v1/82818827-CoF_white.svg",
+                  title: tenant?.display_name ?? "Canonical",
                   url: `./login`,
                 }}
                 theme={Theme.DARK}
@@ -60,6 +75,17 @@ const PageLayout: FC<Props> = ({ children, title, user, isSelfServe }) => {
               <div className="p-card__inner page-inner">
                 <h1 className="p-heading--4">{title}</h1>
                 <div>{children}</div>
+                {(tenant?.terms_url || tenant?.privacy_url) && (
+                  <p className="p-text--small u-no-margin--bottom">
+                    {tenant.terms_url && (
+                      <a href={tenant.terms_url}>Terms of service</a>
+                    )}
+                    {tenant.terms_url && tenant.privacy_url && " · "}
+                    {tenant.privacy_url && (
+                      <a href={tenant.privacy_url}>Privacy policy</a>
+                    )}
+                  </p>
+                )}
               </div>
             </Card>
           </Col>
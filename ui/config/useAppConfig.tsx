@@ -2,6 +2,20 @@ import React, { createContext, useContext, useEffect, useState } from "react";
 
 type FeatureFlags = string | string[];
 
+// TenantAppConfig is the branding of the tenant selected for the login.
+interface TenantAppConfig {
+  tenant_id: string;
+  display_name?: string;
+  logo_url?: string;
+  primary_color?: string;
+  background_color?: string;
+  support_email?: string;
+  auth_methods?: string[];
+  flags?: string[];
+  terms_url?: string;
+  privacy_url?: string;
+}
+
 interface AppConfig {
   oidcSequencingEnabled: boolean;
   baseURL: string;
@@ -9,6 +23,7 @@ interface AppConfig {
   multiTenancyEnabled: boolean;
   supportEmail: string;
   flags: FeatureFlags;
+  tenant?: TenantAppConfig;
 }
 
 const defaultAppConfig: AppConfig = {
@@ -56,6 +71,22 @@ function hasFeatureFlag(
   );
 }
 
+// appConfigURL passes the login of the page so that the settings of its
+// tenant are returned once it is selected.
+function appConfigURL(): string {
+  const params = new URLSearchParams(window.location.search);
+  const query = new URLSearchParams();
+  const loginChallenge = params.get("login_challenge");
+  const flow = params.get("flow");
+  if (loginChallenge) {
+    query.set("login_challenge", loginChallenge);
+  } else if (flow) {
+    query.set("flow", flow);
+  }
+  const search = query.toString();
+  return "../api/v0/app-config" + (search ? `?${search}` : "");
+}
+
 function AppConfigProvider({
   children,
 }: {
@@ -66,7 +97,7 @@ function AppConfigProvider({
   );
 
   useEffect(() => {
-    fetch("../api/v0/app-config", { cache: "no-store" })
+    fetch(appConfigURL(), { cache: "no-store" })
       .then((value) => value.json())
       .then(
         (value: {
@@ -76,6 +107,7 @@ function AppConfigProvider({
           multi_tenancy_enabled: boolean;
           support_email: string;
           flags: FeatureFlags;
+          tenant?: TenantAppConfig;
         }) =>
           setContextValue({
             oidcSequencingEnabled: value.oidc_webauthn_sequencing_enabled,
@@ -84,6 +116,7 @@ function AppConfigProvider({
             multiTenancyEnabled: value.multi_tenancy_enabled,
             supportEmail: value.support_email,
             flags: value.flags,
+            tenant: value.tenant,
             configReady: true,
           }),
       )
@@ -94,4 +127,4 @@ function AppConfigProvider({
 }
 
 export { AppConfigProvider, useAppConfig, hasFeatureFlag };
-export type { FeatureFlags };
+export type { FeatureFlags, TenantAppConfig };
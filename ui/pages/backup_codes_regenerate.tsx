@@ -3,12 +3,18 @@ import React, { useEffect, useState } from "react";
 import PageLayout from "../components/PageLayout";
 import { Button } from "@canonical/react-components";
 import { useRouter } from "next/router";
-import { kratos } from "../api/kratos";
+import {
+  BackupCodes,
+  getBackupCodes,
+  kratos,
+  remindBackupCodesLater,
+} from "../api/kratos";
 import { handleFlowError } from "../util/handleFlowError";
 import { LoginFlow } from "@ory/client";
 
 const BackupCodesRegenerate: NextPage = () => {
   const [flow, setFlow] = useState<LoginFlow>();
+  const [backupCodes, setBackupCodes] = useState<BackupCodes>();
 
   const router = useRouter();
   const { flow: flowId } = router.query;
@@ -28,6 +34,10 @@ const BackupCodesRegenerate: NextPage = () => {
     }
   }, [flowId, router, router.isReady, flow]);
 
+  useEffect(() => {
+    void getBackupCodes().then(setBackupCodes).catch(console.error);
+  }, []);
+
   const signInUrl = flow?.return_to ?? "./login";
 
   return (
@@ -36,6 +46,12 @@ const BackupCodesRegenerate: NextPage = () => {
         You&apos;ve just used a backup code. Would you like to generate new ones
         to ensure you have a full set?
       </p>
+      {backupCodes && (
+        <p className="u-text--muted">
+          You have {backupCodes.remaining} backup{" "}
+          {backupCodes.remaining === 1 ? "code" : "codes"} left.
+        </p>
+      )}
       <p className="u-text--muted">
         Generating new codes will invalidate all previous codes.
       </p>
@@ -48,10 +64,14 @@ const BackupCodesRegenerate: NextPage = () => {
           Generate new codes
         </Button>
         <Button
-          onClick={() => (window.location.href = signInUrl)}
+          onClick={() =>
+            void remindBackupCodesLater()
+              .catch(console.error)
+              .finally(() => (window.location.href = signInUrl))
+          }
           appearance="link"
         >
-          I don&apos;t need new codes, sign in
+          Remind me later, sign in
         </Button>
       </div>
     </PageLayout>
@@ -4,7 +4,7 @@ import {
   UiNodeInputAttributes,
   UpdateLoginFlowBody,
 } from "@ory/client";
-import { CheckboxInput, Spinner } from "@canonical/react-components";
+import { Button, CheckboxInput, Spinner } from "@canonical/react-components";
 import { AxiosError } from "axios";
 import type { NextPage } from "next";
 import { useRouter } from "next/router";
@@ -30,6 +30,16 @@ import {
   toggleWebauthnSkip,
 } from "../util/webauthnAutoLogin";
 import { getCsrfNode, getCsrfToken } from "../util/getCsrfNode";
+import { hasFeatureFlag, useAppConfig } from "../config/useAppConfig";
+import { passkeyConditionalLogin } from "../util/passkeyConditionalLogin";
+import {
+  Tenant,
+  fetchRememberedTenant,
+  forgetRememberedTenant,
+} from "../api/tenants";
+
+// firstFactorGroups are the login methods a tenant can choose to offer
+const firstFactorGroups = ["password", "oidc", "webauthn", "passkey", "code"];
 
 type AppConfig = {
   oidc_webauthn_sequencing_enabled?: boolean;
@@ -206,6 +216,9 @@ const Login: NextPage = () => {
         if (values.method === "webauthn") {
           return "webauthn";
         }
+        if (values.method === "passkey") {
+          return "passkey";
+        }
         if (values.method === "lookup_secret") {
           return "lookup_secret";
         }
@@ -298,6 +311,78 @@ const Login: NextPage = () => {
     },
     [flow, router, login_challenge],
   );
+
+  const appConfig = useAppConfig();
+  const isPasskeyEnabled = hasFeatureFlag("passkey", appConfig);
+  const hasPasskeyChallenge =
+    flow?.ui.nodes.some((node) => node.group === "passkey") ?? false;
+
+  // Offer passkeys in the browser autofill while the user is asked for their
+  // identifier, the assertion does not need one.
+  useEffect(() => {
+    if (!isPasskeyEnabled || !hasPasskeyChallenge || !flow?.id) {
+      return;
+    }
+
+    const controller = new AbortController();
+    void passkeyConditionalLogin(flow.id, controller.signal)
+      .then((values) => {
+        if (values) {
+          return handleSubmit(values as UpdateLoginFlowBody);
+        }
+      })
+      .catch((err: Error) => {
+        if (err.name !== "AbortError") {
+          console.error("Passkey login failed:", err);
+        }
+      });
+
+    return () => controller.abort();
+  }, [isPasskeyEnabled, hasPasskeyChallenge, flow?.id, handleSubmit]);
+
+  // Show the tenant picked from the remembered choice once the user entered
+  // their identifier, with a way to pick another one.
+  const [rememberedTenant, setRememberedTenant] = useState<Tenant | null>(
+    null,
+  );
+  const tenantLoginChallenge = flow?.oauth2_login_challenge;
+  useEffect(() => {
+    if (
+      !appConfig.multiTenancyEnabled ||
+      !flow?.id ||
+      !tenantLoginChallenge ||
+      isIdentifierFirst ||
+      flow.requested_aal === "aal2"
+    ) {
+      return;
+    }
+
+    void fetchRememberedTenant(flow.id)
+      .then(setRememberedTenant)
+      .catch(console.error);
+  }, [
+    appConfig.multiTenancyEnabled,
+    flow?.id,
+    flow?.requested_aal,
+    tenantLoginChallenge,
+    isIdentifierFirst,
+  ]);
+
+  const switchTenant = () => {
+    if (!flow?.id || !tenantLoginChallenge) {
+      return;
+    }
+
+    const params = new URLSearchParams({
+      login_challenge: tenantLoginChallenge,
+      flow: flow.id,
+    });
+    void forgetRememberedTenant(flow.id)
+      .then(() => {
+        window.location.href = `./select_tenant?${params.toString()}`;
+      })
+      .catch(console.error);
+  };
   const reqName = flow?.oauth2_login_request?.client?.client_name ?? "";
   const reqDomain = flow?.oauth2_login_request?.client?.client_uri
     ? new URL(flow.oauth2_login_request.client.client_uri).hostname
@@ -399,6 +484,17 @@ const Login: NextPage = () => {
       });
     }
 
+    // hide the first factor methods the tenant does not offer, second
+    // factors are governed by the MFA policy
+    const tenantAuthMethods = appConfig.tenant?.auth_methods;
+    if (tenantAuthMethods && flow?.requested_aal !== "aal2") {
+      renderFlow.ui.nodes = renderFlow.ui.nodes.filter(
+        (node) =>
+          !firstFactorGroups.includes(node.group) ||
+          tenantAuthMethods.includes(node.group),
+      );
+    }
+
     // ensure oidc options are presented after username/password inputs
     renderFlow.ui.nodes.sort((a, b) => {
       const toValue = (node: UiNode) => (node.group === "oidc" ? 1 : -1);
@@ -499,6 +595,18 @@ const Login: NextPage = () => {
               {getTitleSuffix(reqName, reqDomain)}
             </p>
           )}
+          {rememberedTenant && (
+            <p className="u-text--muted">
+              Signing in to {rememberedTenant.name}.{" "}
+              <Button
+                appearance="link"
+                className="u-no-margin--bottom"
+                onClick={switchTenant}
+              >
+                Switch tenant
+              </Button>
+            </p>
+          )}
           {flow ? (
             <Flow onSubmit={handleSubmit} flow={renderFlow} />
           ) : (
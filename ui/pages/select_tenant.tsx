@@ -1,7 +1,12 @@
 import type { NextPage } from "next";
 import { useRouter } from "next/router";
-import React, { useCallback, useEffect, useState } from "react";
-import { Button, Notification, Spinner } from "@canonical/react-components";
+import React, { useCallback, useEffect, useRef, useState } from "react";
+import {
+  Button,
+  CheckboxInput,
+  Notification,
+  Spinner,
+} from "@canonical/react-components";
 import {
   Tenant,
   fetchTenantsByFlow,
@@ -10,13 +15,34 @@ import {
 import { useAppConfig } from "../config/useAppConfig";
 import PageLayout from "../components/PageLayout";
 
+// Explains why the tenant requested by the application was not selected, the
+// codes are set by the backend in the hint_error query parameter.
+const hintErrorMessages: Record<string, string> = {
+  tenant_hint_not_member:
+    "The application asked to sign you in to a tenant you are not a member of. Please select one of your tenants.",
+  tenant_hint_disabled:
+    "The tenant requested by the application is disabled. Please select another tenant.",
+};
+
+// Explains why a tenant listed in the picker cannot be selected.
+const unavailableReasonMessages: Record<string, string> = {
+  tenant_disabled: "This tenant is disabled.",
+};
+
 const SelectTenant: NextPage = () => {
   const router = useRouter();
-  const { flow: flowId } = router.query;
+  const { flow: flowId, hint_error: hintError } = router.query;
+  const hintErrorMessage =
+    typeof hintError === "string"
+      ? (hintErrorMessages[hintError] ??
+        "The tenant requested by the application is not available. Please select a tenant.")
+      : null;
   const { multiTenancyEnabled, configReady } = useAppConfig();
   const [tenants, setTenants] = useState<Tenant[]>([]);
   const [error, setError] = useState<string | null>(null);
   const [loading, setLoading] = useState(false);
+  // a ref so that toggling it does not reload the tenants
+  const remember = useRef(false);
 
   const submitTenantSelection = useCallback(
     (tenantId: string) => {
@@ -27,9 +53,10 @@ const SelectTenant: NextPage = () => {
         return;
       }
 
-      const body: Record<string, string> = {
+      const body: Record<string, string | boolean> = {
         login_challenge,
         tenant_id: tenantId,
+        remember: remember.current,
       };
       if (flow && typeof flow === "string") {
         body.flow = flow;
@@ -75,8 +102,16 @@ const SelectTenant: NextPage = () => {
     loader
       .then((result) => {
         setTenants(result);
-        if (result.length <= 1) {
-          submitTenantSelection(result.length === 1 ? result[0].id : "");
+        // let the user acknowledge a rejected tenant hint before signing in,
+        // and a single tenant that cannot be selected is shown with its reason
+        if (result.length === 0) {
+          submitTenantSelection("");
+        } else if (
+          result.length === 1 &&
+          result[0].selectable !== false &&
+          !hintErrorMessage
+        ) {
+          submitTenantSelection(result[0].id);
         }
       })
       .catch((err) => {
@@ -92,10 +127,13 @@ const SelectTenant: NextPage = () => {
     submitTenantSelection,
     configReady,
     multiTenancyEnabled,
+    hintErrorMessage,
   ]);
 
   if (!router.isReady) return null;
 
+  const selectableCount = tenants.filter((t) => t.selectable !== false).length;
+
   return (
     <PageLayout title="Select a tenant">
       {loading && (
@@ -103,6 +141,11 @@ const SelectTenant: NextPage = () => {
           <Spinner text="Loading tenants…" />
         </div>
       )}
+      {hintErrorMessage && !error && (
+        <Notification severity="caution" inline>
+          {hintErrorMessage}
+        </Notification>
+      )}
       {error && (
         <Notification severity="negative" inline>
           {error}
@@ -117,14 +160,31 @@ const SelectTenant: NextPage = () => {
             <li key={tenant.id} className="p-list__item">
               <Button
                 className="u-no-margin--bottom p-select-tenant__button"
+                disabled={tenant.selectable === false}
                 onClick={() => submitTenantSelection(tenant.id)}
               >
                 {tenant.name}
               </Button>
+              {tenant.selectable === false && (
+                <p className="p-form-help-text">
+                  {unavailableReasonMessages[
+                    tenant.unavailable_reason ?? ""
+                  ] ?? "This tenant is not available."}
+                </p>
+              )}
             </li>
           ))}
         </ul>
       )}
+      {!loading && selectableCount > 1 && (
+        <CheckboxInput
+          label="Remember my choice"
+          defaultChecked={false}
+          onChange={(e: React.ChangeEvent<HTMLInputElement>) => {
+            remember.current = e.target.checked;
+          }}
+        />
+      )}
     </PageLayout>
   );
 };
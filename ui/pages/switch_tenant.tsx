@@ -0,0 +1,51 @@
+import type { NextPage } from "next";
+import { useRouter } from "next/router";
+import React, { useState } from "react";
+import { Button, Notification } from "@canonical/react-components";
+import { switchTenant } from "../api/tenants";
+import PageLayout from "../components/PageLayout";
+
+// The switch waits for the user to confirm, so that a link from another site
+// cannot switch the tenant on their behalf.
+const SwitchTenant: NextPage = () => {
+  const router = useRouter();
+  const { client_id: clientId } = router.query;
+  const [error, setError] = useState<string | null>(null);
+  const [submitting, setSubmitting] = useState(false);
+
+  if (!router.isReady) return null;
+
+  const submit = () => {
+    if (typeof clientId !== "string" || !clientId) {
+      setError("The application is missing. Please go back and try again.");
+      return;
+    }
+
+    setSubmitting(true);
+    switchTenant(clientId)
+      .then((redirectTo) => {
+        window.location.href = redirectTo;
+      })
+      .catch((err: Error) => {
+        console.error(err);
+        setError("Failed to switch tenant. Please try again.");
+        setSubmitting(false);
+      });
+  };
+
+  return (
+    <PageLayout title="Switch tenant">
+      {error && (
+        <Notification severity="negative" inline>
+          {error}
+        </Notification>
+      )}
+      <p>You will be asked to select the tenant to sign in to.</p>
+      <Button appearance="positive" disabled={submitting} onClick={submit}>
+        Switch tenant
+      </Button>
+    </PageLayout>
+  );
+};
+
+export default SwitchTenant;
@@ -0,0 +1,87 @@
+type PasskeyLoginOptions = {
+  flow_id: string;
+  csrf_token: string;
+  mediation: CredentialMediationRequirement;
+  publicKey: {
+    challenge: string;
+    rpId?: string;
+    timeout?: number;
+    userVerification?: UserVerificationRequirement;
+  };
+};
+
+export type PasskeyLoginValues = {
+  method: "passkey";
+  csrf_token: string;
+  passkey_login: string;
+};
+
+const fromBase64Url = (value: string): ArrayBuffer => {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const binary = atob(base64.padEnd(Math.ceil(base64.length / 4) * 4, "="));
+  return Uint8Array.from(binary, (c) => c.charCodeAt(0)).buffer;
+};
+
+const toBase64Url = (value: ArrayBuffer | null): string =>
+  value
+    ? btoa(String.fromCharCode(...new Uint8Array(value)))
+        .replace(/\+/g, "-")
+        .replace(/\//g, "_")
+        .replace(/=+$/, "")
+    : "";
+
+const isConditionalMediationAvailable = async (): Promise<boolean> =>
+  typeof window !== "undefined" &&
+  window.PublicKeyCredential !== undefined &&
+  typeof PublicKeyCredential.isConditionalMediationAvailable === "function" &&
+  PublicKeyCredential.isConditionalMediationAvailable();
+
+// Offers the passkeys of the user in the browser autofill, resolves with the
+// values to submit to the login flow once one is picked.
+export const passkeyConditionalLogin = async (
+  flowId: string,
+  signal: AbortSignal,
+): Promise<PasskeyLoginValues | undefined> => {
+  if (!(await isConditionalMediationAvailable())) {
+    return undefined;
+  }
+
+  const res = await fetch(
+    `/self-service/login/passkey?flow=${encodeURIComponent(flowId)}`,
+    { signal },
+  );
+  if (!res.ok) {
+    return undefined;
+  }
+
+  const options = (await res.json()) as PasskeyLoginOptions;
+  const credential = (await navigator.credentials.get({
+    mediation: options.mediation,
+    signal,
+    publicKey: {
+      ...options.publicKey,
+      challenge: fromBase64Url(options.publicKey.challenge),
+    },
+  })) as PublicKeyCredential | null;
+
+  if (!credential) {
+    return undefined;
+  }
+
+  const response = credential.response as AuthenticatorAssertionResponse;
+  return {
+    method: "passkey",
+    csrf_token: options.csrf_token,
+    passkey_login: JSON.stringify({
+      id: credential.id,
+      rawId: toBase64Url(credential.rawId),
+      type: credential.type,
+      response: {
+        authenticatorData: toBase64Url(response.authenticatorData),
+        clientDataJSON: toBase64Url(response.clientDataJSON),
+        signature: toBase64Url(response.signature),
+        userHandle: toBase64Url(response.userHandle),
+      },
+    }),
+  };
+};